@@ -0,0 +1,50 @@
+package agouti
+
+import (
+	"fmt"
+
+	"github.com/sclevine/agouti/api"
+)
+
+// Actions is a sequence of W3C input actions that may be performed on a page.
+// Input sources are added using the methods of the embedded *api.Actions.
+// For example, to shift-click an element:
+//    elements, _ := page.Find("#some-element").Elements()
+//    actions := page.Actions()
+//    keyboard := actions.Key("keyboard")
+//    mouse := actions.Pointer("mouse", api.MousePointer)
+//    keyboard.Down(api.ShiftKey)
+//    actions.Tick()
+//    mouse.Move(elements[0], 0, 0, 0).Down(api.LeftButton).Up(api.LeftButton)
+//    actions.Tick()
+//    keyboard.Up(api.ShiftKey)
+//    err := actions.Perform()
+//
+// Actions are only supported by WebDrivers that use the W3C protocol.
+type Actions struct {
+	*api.Actions
+	session apiSession
+}
+
+// Actions returns a new, empty sequence of actions for the page.
+func (p *Page) Actions() *Actions {
+	return &Actions{api.NewActions(), p.session}
+}
+
+// Perform dispatches all of the actions in the sequence. Keys and pointer
+// buttons that are held down when the sequence ends remain held down until
+// Release is called.
+func (a *Actions) Perform() error {
+	if err := a.session.PerformActions(a.Actions); err != nil {
+		return fmt.Errorf("failed to perform actions: %w", err)
+	}
+	return nil
+}
+
+// Release releases all keys and pointer buttons that are currently held down.
+func (a *Actions) Release() error {
+	if err := a.session.ReleaseActions(); err != nil {
+		return fmt.Errorf("failed to release actions: %w", err)
+	}
+	return nil
+}
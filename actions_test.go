@@ -0,0 +1,55 @@
+package agouti_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti"
+	"github.com/sclevine/agouti/api"
+	. "github.com/sclevine/agouti/internal/matchers"
+	"github.com/sclevine/agouti/internal/mocks"
+)
+
+var _ = Describe("Actions", func() {
+	var (
+		page    *Page
+		session *mocks.Session
+		actions *Actions
+	)
+
+	BeforeEach(func() {
+		session = &mocks.Session{}
+		page = NewTestPage(session)
+		actions = page.Actions()
+	})
+
+	Describe("#Perform", func() {
+		It("should successfully perform the actions using the session", func() {
+			actions.Pointer("mouse", api.MousePointer).Down(api.LeftButton).Up(api.LeftButton)
+			Expect(actions.Perform()).To(Succeed())
+			Expect(session.PerformActionsCall.Actions).To(ExactlyEqual(actions.Actions))
+		})
+
+		Context("when performing the actions fails", func() {
+			It("should return an error", func() {
+				session.PerformActionsCall.Err = errors.New("some error")
+				Expect(actions.Perform()).To(MatchError("failed to perform actions: some error"))
+			})
+		})
+	})
+
+	Describe("#Release", func() {
+		It("should successfully release the actions using the session", func() {
+			Expect(actions.Release()).To(Succeed())
+			Expect(session.ReleaseActionsCall.Called).To(BeTrue())
+		})
+
+		Context("when releasing the actions fails", func() {
+			It("should return an error", func() {
+				session.ReleaseActionsCall.Err = errors.New("some error")
+				Expect(actions.Release()).To(MatchError("failed to release actions: some error"))
+			})
+		})
+	})
+})
@@ -19,7 +19,8 @@ import (
 // (and not the NewPage method) for this Option to take effect on any
 // PhantomJS page.
 func PhantomJS(options ...Option) *WebDriver {
-	command := []string{"phantomjs", "--webdriver={{.Address}}"}
+	binary := driverBinary("phantomjs", "AGOUTI_PHANTOMJS", options)
+	command := []string{binary, "--webdriver={{.Address}}"}
 	defaultOptions := config{}.Merge(options)
 	if !defaultOptions.RejectInvalidSSL {
 		command = append(command, "--ignore-ssl-errors=true")
@@ -32,6 +33,10 @@ func PhantomJS(options ...Option) *WebDriver {
 // Provided Options will apply as default arguments for new pages.
 // New pages will accept invalid SSL certificates by default. This
 // may be disabled using the RejectInvalidSSL Option.
+//
+// Before ChromeDriver is started, a warning is written to the writer provided
+// by the Warnings Option (or os.Stderr) if its major version differs from
+// that of Chrome (or the binary provided by ChromeOptions).
 func ChromeDriver(options ...Option) *WebDriver {
 	var binaryName string
 	if runtime.GOOS == "windows" {
@@ -39,8 +44,11 @@ func ChromeDriver(options ...Option) *WebDriver {
 	} else {
 		binaryName = "chromedriver"
 	}
-	command := []string{binaryName, "--port={{.Port}}"}
-	return NewWebDriver("http://{{.Address}}", command, options...)
+	binary := driverBinary(binaryName, "AGOUTI_CHROMEDRIVER", options)
+	command := []string{binary, "--port={{.Port}}"}
+	webDriver := NewWebDriver("http://{{.Address}}", command, options...)
+	webDriver.versionCheck = chromeVersionCheck(binary, options)
+	return webDriver
 }
 
 // EdgeDriver returns an instance of a EdgeDriver WebDriver.
@@ -55,7 +63,8 @@ func EdgeDriver(options ...Option) *WebDriver {
 	} else {
 		return nil
 	}
-	command := []string{binaryName, "--port={{.Port}}"}
+	binary := driverBinary(binaryName, "AGOUTI_EDGEDRIVER", options)
+	command := []string{binary, "--port={{.Port}}"}
 	// Using {{.Address}} means using 127.0.0.1
 	// But MicrosoftWebDriver only supports localhost, not 127.0.0.1
 	return NewWebDriver("http://localhost:{{.Port}}", command, options...)
@@ -67,7 +76,8 @@ func EdgeDriver(options ...Option) *WebDriver {
 // New pages will accept invalid SSL certificates by default. This
 // may be disabled using the RejectInvalidSSL Option.
 func Selenium(options ...Option) *WebDriver {
-	command := []string{"selenium-server", "-port", "{{.Port}}"}
+	binary := driverBinary("selenium-server", "AGOUTI_SELENIUM", options)
+	command := []string{binary, "-port", "{{.Port}}"}
 	return NewWebDriver("http://{{.Address}}/wd/hub", command, options...)
 }
 
@@ -118,6 +128,7 @@ func GeckoDriver(options ...Option) *WebDriver {
 	} else {
 		binaryName = "geckodriver"
 	}
-	command := []string{binaryName, "--port={{.Port}}"}
+	binary := driverBinary(binaryName, "AGOUTI_GECKODRIVER", options)
+	command := []string{binary, "--port={{.Port}}"}
 	return NewWebDriver("http://{{.Address}}", command, options...)
 }
@@ -0,0 +1,13 @@
+package agoutitest_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAgoutitest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Agoutitest Suite")
+}
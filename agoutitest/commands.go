@@ -0,0 +1,328 @@
+package agoutitest
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/sclevine/agouti/agoutitest/internal/dom"
+	"github.com/sclevine/agouti/api"
+)
+
+// 1x1 transparent PNG
+const screenshotPNG = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII="
+
+func (s *Session) execute(method, endpoint string, body []byte) (interface{}, error) {
+	parts := strings.Split(endpoint, "/")
+	command := method + " " + endpoint
+
+	switch {
+	case command == "DELETE ":
+		s.server.deleteSession(s)
+		return nil, nil
+	case s.popup != nil && !isAlertCommand(endpoint):
+		return nil, newError("unexpected alert open", "unexpected alert open: %s", *s.popup)
+	case s.window == nil && !isWindowCommand(endpoint):
+		return nil, newError("no such window", "the current window is closed")
+	case parts[0] == "element" && len(parts) > 2 && parts[1] != "active":
+		return s.executeElement(method, parts[1], strings.Join(parts[2:], "/"), body)
+	case parts[0] == "window" && len(parts) > 2:
+		return s.executeWindow(method, parts[1], strings.Join(parts[2:], "/"), body)
+	case parts[0] == "cookie" && len(parts) == 2 && method == "DELETE":
+		if index := cookieNamed(s.cookies, parts[1]); index >= 0 {
+			s.cookies = append(s.cookies[:index], s.cookies[index+1:]...)
+		}
+		return nil, nil
+	}
+
+	switch command {
+	case "GET url":
+		return s.window.url(), nil
+	case "POST url":
+		var request struct{ URL string }
+		if err := decode(body, &request); err != nil {
+			return nil, err
+		}
+		s.navigate(request.URL)
+		return nil, nil
+	case "GET title":
+		if title := s.window.document.Find("title"); title != nil {
+			return strings.TrimSpace(title.TextContent()), nil
+		}
+		return "", nil
+	case "GET source":
+		return s.window.context().HTML(), nil
+	case "POST back":
+		if s.window.index > 0 {
+			s.window.index--
+			s.reload()
+		}
+		return nil, nil
+	case "POST forward":
+		if s.window.index < len(s.window.history)-1 {
+			s.window.index++
+			s.reload()
+		}
+		return nil, nil
+	case "POST refresh":
+		s.reload()
+		return nil, nil
+
+	case "POST element", "POST elements":
+		return s.findElements(s.window.context(), command == "POST element", body)
+	case "GET element/active", "POST element/active":
+		active := s.window.active
+		if active == nil || active.Root() != s.window.context() {
+			active = s.window.context().Find("body")
+		}
+		if active == nil {
+			return nil, newError("no such element", "no active element")
+		}
+		return s.elementReference(active), nil
+
+	case "GET cookie":
+		cookies := []*api.Cookie{}
+		return append(cookies, s.cookies...), nil
+	case "POST cookie":
+		var request struct{ Cookie *api.Cookie }
+		if err := decode(body, &request); err != nil {
+			return nil, err
+		}
+		if request.Cookie == nil || request.Cookie.Name == "" {
+			return nil, newError("invalid argument", "missing cookie name")
+		}
+		if index := cookieNamed(s.cookies, request.Cookie.Name); index >= 0 {
+			s.cookies[index] = request.Cookie
+		} else {
+			s.cookies = append(s.cookies, request.Cookie)
+		}
+		return nil, nil
+	case "DELETE cookie":
+		s.cookies = nil
+		return nil, nil
+
+	case "GET window_handle", "GET window":
+		if s.window == nil {
+			return nil, newError("no such window", "the current window is closed")
+		}
+		return s.window.handle, nil
+	case "GET window_handles", "GET window/handles":
+		handles := []string{}
+		for _, existing := range s.windows {
+			handles = append(handles, existing.handle)
+		}
+		return handles, nil
+	case "POST window":
+		var request struct{ Name, Handle string }
+		if err := decode(body, &request); err != nil {
+			return nil, err
+		}
+		target := s.findWindow(request.Name + request.Handle)
+		if target == nil {
+			return nil, newError("no such window", "no window named %s", request.Name+request.Handle)
+		}
+		s.window = target
+		return nil, nil
+	case "DELETE window":
+		s.closeWindow()
+		handles := []string{}
+		for _, existing := range s.windows {
+			handles = append(handles, existing.handle)
+		}
+		return handles, nil
+	case "POST window/rect":
+		return s.executeWindow(method, s.window.handle, "size", body)
+	case "GET window/rect":
+		return map[string]int{"x": 0, "y": 0, "width": s.window.width, "height": s.window.height}, nil
+
+	case "POST frame":
+		return nil, s.switchToFrame(body)
+	case "POST frame/parent":
+		if frames := s.window.frames; len(frames) > 0 {
+			s.window.frames = frames[:len(frames)-1]
+		}
+		return nil, nil
+
+	case "GET alert_text", "GET alert/text":
+		if s.popup == nil {
+			return nil, newError("no such alert", "no alert open")
+		}
+		return *s.popup, nil
+	case "POST alert_text", "POST alert/text":
+		if s.popup == nil {
+			return nil, newError("no such alert", "no alert open")
+		}
+		return nil, nil
+	case "POST accept_alert", "POST alert/accept", "POST dismiss_alert", "POST alert/dismiss":
+		if s.popup == nil {
+			return nil, newError("no such alert", "no alert open")
+		}
+		s.popup = nil
+		return nil, nil
+
+	case "POST execute", "POST execute/sync", "POST execute_async", "POST execute/async":
+		return nil, newError("unsupported operation", "JavaScript is not supported; use Server.Handle to script %s", endpoint)
+
+	case "DELETE local_storage", "DELETE session_storage":
+		return nil, nil
+	case "POST timeouts", "POST timeouts/implicit_wait", "POST timeouts/async_script":
+		return nil, nil
+
+	case "POST log", "POST se/log":
+		var request struct{ Type string }
+		if err := decode(body, &request); err != nil {
+			return nil, err
+		}
+		logs := append([]api.Log{}, s.logs[request.Type]...)
+		delete(s.logs, request.Type)
+		return logs, nil
+	case "GET log/types", "GET se/log/types":
+		return []string{"browser", "driver"}, nil
+	case "GET screenshot":
+		return screenshotPNG, nil
+
+	case "POST moveto":
+		var request struct{ Element string }
+		if err := decode(body, &request); err != nil {
+			return nil, err
+		}
+		if request.Element != "" {
+			node, err := s.element(request.Element)
+			if err != nil {
+				return nil, err
+			}
+			s.mouse = node
+		}
+		return nil, nil
+	case "POST click", "POST doubleclick":
+		if s.mouse != nil && s.mouse.Root() == s.window.context() {
+			return nil, s.click(s.mouse)
+		}
+		return nil, nil
+	case "POST buttondown", "POST buttonup":
+		return nil, nil
+	case "POST keys":
+		active := s.window.active
+		if active == nil || active.Root() != s.window.context() || !isEditable(active) {
+			return nil, nil
+		}
+		return nil, s.sendKeys(active, body)
+
+	case "POST touch/click", "POST touch/doubleclick", "POST touch/longclick":
+		var request struct{ Element string }
+		if err := decode(body, &request); err != nil {
+			return nil, err
+		}
+		node, err := s.element(request.Element)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.click(node)
+	case "POST touch/down", "POST touch/up", "POST touch/move", "POST touch/scroll", "POST touch/flick":
+		return nil, nil
+	}
+
+	return nil, newError("unknown command", "unknown command: %s /session/%s/%s", method, s.ID, endpoint)
+}
+
+func (s *Session) executeWindow(method, handle, endpoint string, body []byte) (interface{}, error) {
+	target := s.findWindow(handle)
+	if handle == "current" {
+		target = s.window
+	}
+	if target == nil {
+		return nil, newError("no such window", "no window with handle %s", handle)
+	}
+
+	switch method + " " + endpoint {
+	case "POST size":
+		var request struct{ Width, Height int }
+		if err := decode(body, &request); err != nil {
+			return nil, err
+		}
+		target.width, target.height = request.Width, request.Height
+		return nil, nil
+	case "GET size":
+		return map[string]int{"width": target.width, "height": target.height}, nil
+	case "POST maximize":
+		target.width, target.height = 1920, 1080
+		return nil, nil
+	}
+	return nil, newError("unknown command", "unknown command: %s window/%s/%s", method, handle, endpoint)
+}
+
+func (s *Session) switchToFrame(body []byte) error {
+	var request struct{ ID interface{} }
+	if err := decode(body, &request); err != nil {
+		return err
+	}
+
+	var frame *dom.Node
+	frames := frameElements(s.window.context())
+	switch id := request.ID.(type) {
+	case nil:
+		s.window.frames = nil
+		return nil
+	case float64:
+		if int(id) < 0 || int(id) >= len(frames) {
+			return newError("no such frame", "no frame with index %d", int(id))
+		}
+		frame = frames[int(id)]
+	case string:
+		for _, candidate := range frames {
+			if value, _ := candidate.Attr("id"); value == id {
+				frame = candidate
+				break
+			}
+			if value, _ := candidate.Attr("name"); value == id {
+				frame = candidate
+				break
+			}
+		}
+		if frame == nil {
+			return newError("no such frame", "no frame named %s", id)
+		}
+	case map[string]interface{}:
+		elementID, _ := id["ELEMENT"].(string)
+		if elementID == "" {
+			elementID, _ = id[w3cElementKey].(string)
+		}
+		node, err := s.element(elementID)
+		if err != nil {
+			return err
+		}
+		if node.Tag != "iframe" && node.Tag != "frame" {
+			return newError("no such frame", "element %s is not a frame", elementID)
+		}
+		frame = node
+	default:
+		return newError("invalid argument", "invalid frame ID %v", id)
+	}
+
+	s.window.frames = append(s.window.frames, s.frameDocument(frame))
+	return nil
+}
+
+func frameElements(document *dom.Node) []*dom.Node {
+	var frames []*dom.Node
+	for _, descendant := range document.Descendants() {
+		if descendant.Tag == "iframe" || descendant.Tag == "frame" {
+			frames = append(frames, descendant)
+		}
+	}
+	return frames
+}
+
+func isWindowCommand(endpoint string) bool {
+	switch endpoint {
+	case "window_handle", "window_handles", "window", "window/handles":
+		return true
+	}
+	return false
+}
+
+func decode(body []byte, request interface{}) error {
+	if err := json.Unmarshal(body, request); err != nil {
+		return newError("invalid argument", "invalid request body: %s", err)
+	}
+	return nil
+}
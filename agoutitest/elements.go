@@ -0,0 +1,518 @@
+package agoutitest
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/sclevine/agouti/agoutitest/internal/dom"
+)
+
+var booleanAttributes = map[string]bool{
+	"checked": true, "selected": true, "disabled": true, "readonly": true,
+	"required": true, "multiple": true, "hidden": true, "autofocus": true,
+}
+
+func (s *Session) executeElement(method, id, endpoint string, body []byte) (interface{}, error) {
+	node, err := s.element(id)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(endpoint, "/", 2)
+	if len(parts) == 2 && method == "GET" {
+		switch parts[0] {
+		case "attribute":
+			return attribute(node, parts[1]), nil
+		case "css":
+			return cssProperty(node, parts[1]), nil
+		case "equals":
+			other, err := s.element(parts[1])
+			if err != nil {
+				return nil, err
+			}
+			return node == other, nil
+		}
+	}
+
+	switch method + " " + endpoint {
+	case "POST element", "POST elements":
+		return s.findElements(node, endpoint == "element", body)
+	case "GET text":
+		return node.VisibleText(), nil
+	case "GET name":
+		return node.Tag, nil
+	case "GET selected":
+		return selected(node), nil
+	case "GET enabled":
+		return !node.HasAttr("disabled"), nil
+	case "GET displayed":
+		return node.Displayed(), nil
+	case "GET location":
+		return map[string]int{"x": 0, "y": 0}, nil
+	case "GET size":
+		return map[string]int{"width": 0, "height": 0}, nil
+	case "GET rect":
+		return map[string]int{"x": 0, "y": 0, "width": 0, "height": 0}, nil
+	case "POST click":
+		return nil, s.click(node)
+	case "POST clear":
+		if !isEditable(node) {
+			return nil, newError("invalid element state", "element %s is not editable", id)
+		}
+		node.SetAttr("value", "")
+		return nil, nil
+	case "POST value":
+		return nil, s.sendKeys(node, body)
+	case "POST submit":
+		form := node
+		if form.Tag != "form" {
+			form = node.Ancestor("form")
+		}
+		if form == nil {
+			return nil, newError("invalid element state", "element %s is not in a form", id)
+		}
+		s.submit(form, nil)
+		return nil, nil
+	}
+
+	return nil, newError("unknown command", "unknown command: %s element/%s/%s", method, id, endpoint)
+}
+
+func (s *Session) findElements(root *dom.Node, single bool, body []byte) (interface{}, error) {
+	var request struct{ Using, Value string }
+	if err := decode(body, &request); err != nil {
+		return nil, err
+	}
+
+	matches, err := find(root, request.Using, request.Value)
+	if err != nil {
+		return nil, newError("invalid selector", "%s", err)
+	}
+
+	if single {
+		if len(matches) == 0 {
+			return nil, newError("no such element", "unable to locate element: {%q: %q}", request.Using, request.Value)
+		}
+		return s.elementReference(matches[0]), nil
+	}
+
+	references := []map[string]string{}
+	for _, match := range matches {
+		references = append(references, s.elementReference(match))
+	}
+	return references, nil
+}
+
+func find(root *dom.Node, using, value string) ([]*dom.Node, error) {
+	switch using {
+	case "css selector":
+		return root.SelectCSS(value)
+	case "xpath":
+		nodes, err := root.SelectXPath(value)
+		if err != nil {
+			return nil, err
+		}
+		var elements []*dom.Node
+		for _, node := range nodes {
+			if node.IsElement() {
+				elements = append(elements, node)
+			}
+		}
+		return elements, nil
+	}
+
+	var filter func(node *dom.Node) bool
+	switch using {
+	case "link text":
+		filter = func(node *dom.Node) bool { return node.Tag == "a" && node.VisibleText() == strings.TrimSpace(value) }
+	case "partial link text":
+		filter = func(node *dom.Node) bool { return node.Tag == "a" && strings.Contains(node.VisibleText(), value) }
+	case "name", "id":
+		filter = func(node *dom.Node) bool {
+			attr, ok := node.Attr(using)
+			return ok && attr == value
+		}
+	case "class name":
+		filter = func(node *dom.Node) bool {
+			class, _ := node.Attr("class")
+			return containsWord(class, value)
+		}
+	case "tag name":
+		filter = func(node *dom.Node) bool { return node.Tag == strings.ToLower(value) }
+	default:
+		return nil, newError("invalid argument", "unsupported locator strategy %q", using)
+	}
+
+	var matches []*dom.Node
+	for _, node := range root.Descendants() {
+		if filter(node) {
+			matches = append(matches, node)
+		}
+	}
+	return matches, nil
+}
+
+func (s *Session) click(node *dom.Node) error {
+	if !node.Displayed() {
+		return newError("element not interactable", "element <%s> is not displayed", node.Tag)
+	}
+
+	context := s.window.context()
+	for _, click := range s.server.clicks {
+		matches, _ := context.SelectCSS(click.selector)
+		for _, match := range matches {
+			if match == node {
+				handler := click.handler
+				s.callbacks = append(s.callbacks, func() { handler(s) })
+				break
+			}
+		}
+	}
+
+	s.window.active = node
+	if node.HasAttr("disabled") {
+		return nil
+	}
+
+	inputType := strings.ToLower(attr(node, "type"))
+	switch {
+	case node.Tag == "input" && inputType == "checkbox":
+		if node.HasAttr("checked") {
+			node.RemoveAttr("checked")
+		} else {
+			node.SetAttr("checked", "")
+		}
+	case node.Tag == "input" && inputType == "radio":
+		for _, radio := range radioGroup(node) {
+			radio.RemoveAttr("checked")
+		}
+		node.SetAttr("checked", "")
+	case node.Tag == "option":
+		selectOption(node)
+	case isSubmitButton(node):
+		if form := node.Ancestor("form"); form != nil {
+			s.submit(form, node)
+		}
+	case node.Tag == "label":
+		if target := labelTarget(node); target != nil && target != node {
+			return s.click(target)
+		}
+	default:
+		if link := closest(node, "a"); link != nil && link.HasAttr("href") {
+			href := attr(link, "href")
+			if strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+				return nil
+			}
+			switch target := attr(link, "target"); target {
+			case "", "_self", "_top", "_parent":
+				s.navigate(href)
+			case "_blank":
+				s.openWindow(s.resolve(href), "")
+			default:
+				if existing := s.findWindow(target); existing != nil {
+					current := s.window
+					s.window = existing
+					s.navigate(href)
+					s.window = current
+				} else {
+					s.openWindow(s.resolve(href), target)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// special keys from https://www.w3.org/TR/webdriver/#keyboard-actions
+const (
+	keyBackspace = '\ue003'
+	keyReturn    = '\ue006'
+	keyEnter     = '\ue007'
+)
+
+func (s *Session) sendKeys(node *dom.Node, body []byte) error {
+	var request struct {
+		Value []string
+		Text  string
+	}
+	if err := decode(body, &request); err != nil {
+		return err
+	}
+	if !isEditable(node) {
+		return newError("element not interactable", "element <%s> is not editable", node.Tag)
+	}
+
+	s.window.active = node
+	text := []rune(value(node))
+	for _, key := range []rune(strings.Join(request.Value, "") + request.Text) {
+		switch {
+		case key == keyBackspace:
+			if len(text) > 0 {
+				text = text[:len(text)-1]
+			}
+		case key == keyReturn || key == keyEnter:
+			if node.Tag == "textarea" {
+				text = append(text, '\n')
+			} else if form := node.Ancestor("form"); form != nil {
+				node.SetAttr("value", string(text))
+				s.submit(form, nil)
+				return nil
+			}
+		case key >= '\ue000' && key <= '\uf8ff':
+		default:
+			text = append(text, key)
+		}
+	}
+	node.SetAttr("value", string(text))
+	return nil
+}
+
+func (s *Session) submit(form *dom.Node, submitter *dom.Node) {
+	values := url.Values{}
+	for _, field := range form.Descendants() {
+		name := attr(field, "name")
+		if name == "" || field.HasAttr("disabled") {
+			continue
+		}
+		inputType := strings.ToLower(attr(field, "type"))
+		switch field.Tag {
+		case "input":
+			switch inputType {
+			case "checkbox", "radio":
+				if field.HasAttr("checked") {
+					values.Add(name, valueOr(field, "on"))
+				}
+			case "submit", "image", "button", "reset", "file":
+				if field == submitter {
+					values.Add(name, attr(field, "value"))
+				}
+			default:
+				values.Add(name, attr(field, "value"))
+			}
+		case "button":
+			if field == submitter {
+				values.Add(name, attr(field, "value"))
+			}
+		case "textarea":
+			values.Add(name, value(field))
+		case "select":
+			for _, option := range options(field) {
+				if selected(option) {
+					values.Add(name, value(option))
+				}
+			}
+		}
+	}
+
+	method := strings.ToUpper(attr(form, "method"))
+	if method == "" {
+		method = "GET"
+	}
+	action := s.resolve(attr(form, "action"))
+	if method == "GET" {
+		if parsed, err := url.Parse(action); err == nil {
+			parsed.RawQuery = values.Encode()
+			action = parsed.String()
+		}
+	}
+
+	s.submissions = append(s.submissions, Submission{URL: action, Method: method, Values: values})
+	s.navigate(action)
+}
+
+func attribute(node *dom.Node, name string) interface{} {
+	name = strings.ToLower(name)
+	switch {
+	case name == "value" && (isEditable(node) || node.Tag == "select" || node.Tag == "option"):
+		return value(node)
+	case name == "selected" || name == "checked":
+		if selected(node) {
+			return "true"
+		}
+		return nil
+	case booleanAttributes[name]:
+		if node.HasAttr(name) {
+			return "true"
+		}
+		return nil
+	}
+	if value, ok := node.Attr(name); ok {
+		return value
+	}
+	return nil
+}
+
+func cssProperty(node *dom.Node, property string) string {
+	for _, declaration := range strings.Split(attr(node, "style"), ";") {
+		parts := strings.SplitN(declaration, ":", 2)
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), property) {
+			return strings.TrimSpace(parts[1])
+		}
+	}
+	return ""
+}
+
+func value(node *dom.Node) string {
+	switch node.Tag {
+	case "textarea":
+		if value, ok := node.Attr("value"); ok {
+			return value
+		}
+		return node.TextContent()
+	case "select":
+		for _, option := range options(node) {
+			if selected(option) {
+				return value(option)
+			}
+		}
+		return ""
+	case "option":
+		if value, ok := node.Attr("value"); ok {
+			return value
+		}
+		return dom.NormalizeSpace(node.TextContent())
+	case "input":
+		inputType := strings.ToLower(attr(node, "type"))
+		if inputType == "checkbox" || inputType == "radio" {
+			return valueOr(node, "on")
+		}
+	}
+	return attr(node, "value")
+}
+
+func selected(node *dom.Node) bool {
+	if node.Tag != "option" {
+		return node.HasAttr("checked")
+	}
+	if node.HasAttr("selected") {
+		return true
+	}
+	list := node.Ancestor("select")
+	if list == nil || list.HasAttr("multiple") {
+		return false
+	}
+	all := options(list)
+	for _, option := range all {
+		if option.HasAttr("selected") {
+			return false
+		}
+	}
+	return len(all) > 0 && all[0] == node
+}
+
+func selectOption(option *dom.Node) {
+	list := option.Ancestor("select")
+	if list != nil && list.HasAttr("multiple") {
+		if option.HasAttr("selected") {
+			option.RemoveAttr("selected")
+		} else {
+			option.SetAttr("selected", "")
+		}
+		return
+	}
+	if list != nil {
+		for _, other := range options(list) {
+			other.RemoveAttr("selected")
+		}
+	}
+	option.SetAttr("selected", "")
+}
+
+func options(list *dom.Node) []*dom.Node {
+	var options []*dom.Node
+	for _, descendant := range list.Descendants() {
+		if descendant.Tag == "option" {
+			options = append(options, descendant)
+		}
+	}
+	return options
+}
+
+func radioGroup(radio *dom.Node) []*dom.Node {
+	name := attr(radio, "name")
+	if name == "" {
+		return nil
+	}
+	scope := radio.Ancestor("form")
+	if scope == nil {
+		scope = radio.Root()
+	}
+	var group []*dom.Node
+	for _, descendant := range scope.Descendants() {
+		if descendant.Tag == "input" && strings.EqualFold(attr(descendant, "type"), "radio") && attr(descendant, "name") == name {
+			group = append(group, descendant)
+		}
+	}
+	return group
+}
+
+func labelTarget(label *dom.Node) *dom.Node {
+	if id := attr(label, "for"); id != "" {
+		for _, descendant := range label.Root().Descendants() {
+			if attr(descendant, "id") == id {
+				return descendant
+			}
+		}
+		return nil
+	}
+	for _, descendant := range label.Descendants() {
+		switch descendant.Tag {
+		case "input", "select", "textarea", "button":
+			return descendant
+		}
+	}
+	return nil
+}
+
+func isSubmitButton(node *dom.Node) bool {
+	inputType := strings.ToLower(attr(node, "type"))
+	switch node.Tag {
+	case "button":
+		return inputType == "" || inputType == "submit"
+	case "input":
+		return inputType == "submit" || inputType == "image"
+	}
+	return false
+}
+
+func isEditable(node *dom.Node) bool {
+	switch node.Tag {
+	case "textarea":
+		return true
+	case "input":
+		switch strings.ToLower(attr(node, "type")) {
+		case "checkbox", "radio", "submit", "button", "reset", "image", "hidden", "file":
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func closest(node *dom.Node, tag string) *dom.Node {
+	if node.Tag == tag {
+		return node
+	}
+	return node.Ancestor(tag)
+}
+
+func attr(node *dom.Node, name string) string {
+	value, _ := node.Attr(name)
+	return value
+}
+
+func valueOr(node *dom.Node, fallback string) string {
+	if value, ok := node.Attr("value"); ok {
+		return value
+	}
+	return fallback
+}
+
+func containsWord(list, word string) bool {
+	for _, field := range strings.Fields(list) {
+		if field == word {
+			return true
+		}
+	}
+	return false
+}
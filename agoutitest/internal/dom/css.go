@@ -0,0 +1,471 @@
+package dom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SelectCSS returns all descendant elements of the node matching the CSS
+// selector in document order. Supported selectors include type, universal,
+// ID, class, and attribute selectors, the descendant, child, and sibling
+// combinators, selector lists, and the :first-child, :last-child,
+// :only-child, :nth-child(), :first-of-type, :last-of-type, :checked,
+// :disabled, :enabled, :empty, and :not() pseudo-classes.
+func (n *Node) SelectCSS(selector string) ([]*Node, error) {
+	selectors, err := parseCSS(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*Node
+	for _, descendant := range n.Descendants() {
+		for _, complex := range selectors {
+			if complex.match(descendant, n) {
+				matches = append(matches, descendant)
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+type complexSelector struct {
+	compounds   []compoundSelector
+	combinators []byte
+}
+
+type compoundSelector struct {
+	tag     string
+	filters []func(node *Node) bool
+}
+
+func (c complexSelector) match(node, scope *Node) bool {
+	return c.matchAt(len(c.compounds)-1, node, scope)
+}
+
+func (c complexSelector) matchAt(index int, node, scope *Node) bool {
+	if !c.compounds[index].match(node) {
+		return false
+	}
+	if index == 0 {
+		return true
+	}
+
+	switch c.combinators[index-1] {
+	case '>':
+		parent := node.Parent
+		return parent != nil && parent != scope && parent.IsElement() && c.matchAt(index-1, parent, scope)
+	case '+':
+		previous := previousSibling(node)
+		return previous != nil && c.matchAt(index-1, previous, scope)
+	case '~':
+		for previous := previousSibling(node); previous != nil; previous = previousSibling(previous) {
+			if c.matchAt(index-1, previous, scope) {
+				return true
+			}
+		}
+		return false
+	default:
+		for parent := node.Parent; parent != nil && parent != scope && parent.IsElement(); parent = parent.Parent {
+			if c.matchAt(index-1, parent, scope) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (c compoundSelector) match(node *Node) bool {
+	if !node.IsElement() || (c.tag != "" && c.tag != "*" && c.tag != node.Tag) {
+		return false
+	}
+	for _, filter := range c.filters {
+		if !filter(node) {
+			return false
+		}
+	}
+	return true
+}
+
+func previousSibling(node *Node) *Node {
+	if node.Parent == nil {
+		return nil
+	}
+	var previous *Node
+	for _, sibling := range node.Parent.Elements() {
+		if sibling == node {
+			return previous
+		}
+		previous = sibling
+	}
+	return nil
+}
+
+func elementIndex(node *Node, sameType, fromEnd bool) (index, count int) {
+	if node.Parent == nil {
+		return 1, 1
+	}
+	var siblings []*Node
+	for _, sibling := range node.Parent.Elements() {
+		if !sameType || sibling.Tag == node.Tag {
+			siblings = append(siblings, sibling)
+		}
+	}
+	for i, sibling := range siblings {
+		if sibling == node {
+			index = i + 1
+		}
+	}
+	if fromEnd {
+		index = len(siblings) - index + 1
+	}
+	return index, len(siblings)
+}
+
+type cssParser struct {
+	source string
+	pos    int
+}
+
+func parseCSS(selector string) ([]complexSelector, error) {
+	parser := &cssParser{source: selector}
+	selectors, err := parser.parseList()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSS selector %q: %s", selector, err)
+	}
+	if parser.pos < len(parser.source) {
+		return nil, fmt.Errorf("invalid CSS selector %q: unexpected %q", selector, parser.source[parser.pos:])
+	}
+	return selectors, nil
+}
+
+func (p *cssParser) parseList() ([]complexSelector, error) {
+	var selectors []complexSelector
+	for {
+		p.skipSpace()
+		complex, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, complex)
+		p.skipSpace()
+		if !p.consume(',') {
+			return selectors, nil
+		}
+	}
+}
+
+func (p *cssParser) parseComplex() (complexSelector, error) {
+	var complex complexSelector
+	for {
+		compound, err := p.parseCompound()
+		if err != nil {
+			return complex, err
+		}
+		complex.compounds = append(complex.compounds, compound)
+
+		hadSpace := p.skipSpace()
+		if p.done() || p.peek() == ',' || p.peek() == ')' {
+			return complex, nil
+		}
+		combinator := byte(' ')
+		if c := p.peek(); c == '>' || c == '+' || c == '~' {
+			combinator = c
+			p.pos++
+			p.skipSpace()
+		} else if !hadSpace {
+			return complex, fmt.Errorf("unexpected %q", p.source[p.pos:])
+		}
+		complex.combinators = append(complex.combinators, combinator)
+	}
+}
+
+func (p *cssParser) parseCompound() (compoundSelector, error) {
+	var compound compoundSelector
+	if p.consume('*') {
+		compound.tag = "*"
+	} else if name := p.readIdent(); name != "" {
+		compound.tag = strings.ToLower(name)
+	}
+
+	for !p.done() {
+		var filter func(node *Node) bool
+		var err error
+		switch p.peek() {
+		case '#':
+			p.pos++
+			id := p.readIdent()
+			filter = func(node *Node) bool {
+				value, ok := node.Attr("id")
+				return ok && value == id
+			}
+		case '.':
+			p.pos++
+			class := p.readIdent()
+			filter = func(node *Node) bool {
+				value, _ := node.Attr("class")
+				return containsWord(value, class)
+			}
+		case '[':
+			p.pos++
+			filter, err = p.parseAttribute()
+		case ':':
+			p.pos++
+			filter, err = p.parsePseudo()
+		default:
+			if compound.tag == "" && len(compound.filters) == 0 {
+				return compound, fmt.Errorf("unexpected %q", p.source[p.pos:])
+			}
+			return compound, nil
+		}
+		if err != nil {
+			return compound, err
+		}
+		compound.filters = append(compound.filters, filter)
+	}
+
+	if compound.tag == "" && len(compound.filters) == 0 {
+		return compound, fmt.Errorf("empty selector")
+	}
+	return compound, nil
+}
+
+func (p *cssParser) parseAttribute() (func(node *Node) bool, error) {
+	p.skipSpace()
+	name := strings.ToLower(p.readIdent())
+	if name == "" {
+		return nil, fmt.Errorf("missing attribute name")
+	}
+	p.skipSpace()
+	if p.consume(']') {
+		return func(node *Node) bool { return node.HasAttr(name) }, nil
+	}
+
+	operator := ""
+	if c := p.peek(); strings.IndexByte("~|^$*", c) >= 0 {
+		operator = string(c)
+		p.pos++
+	}
+	if !p.consume('=') {
+		return nil, fmt.Errorf("invalid attribute selector")
+	}
+	p.skipSpace()
+	value, err := p.readValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	insensitive := false
+	if p.consume('i') || p.consume('I') {
+		insensitive = true
+		p.skipSpace()
+	}
+	if !p.consume(']') {
+		return nil, fmt.Errorf("unterminated attribute selector")
+	}
+	if insensitive {
+		value = strings.ToLower(value)
+	}
+
+	return func(node *Node) bool {
+		actual, ok := node.Attr(name)
+		if !ok {
+			return false
+		}
+		if insensitive {
+			actual = strings.ToLower(actual)
+		}
+		switch operator {
+		case "~":
+			return containsWord(actual, value)
+		case "|":
+			return actual == value || strings.HasPrefix(actual, value+"-")
+		case "^":
+			return value != "" && strings.HasPrefix(actual, value)
+		case "$":
+			return value != "" && strings.HasSuffix(actual, value)
+		case "*":
+			return value != "" && strings.Contains(actual, value)
+		}
+		return actual == value
+	}, nil
+}
+
+func (p *cssParser) parsePseudo() (func(node *Node) bool, error) {
+	name := strings.ToLower(p.readIdent())
+	switch name {
+	case "first-child":
+		return nthFilter(0, 1, false, false), nil
+	case "last-child":
+		return nthFilter(0, 1, false, true), nil
+	case "first-of-type":
+		return nthFilter(0, 1, true, false), nil
+	case "last-of-type":
+		return nthFilter(0, 1, true, true), nil
+	case "only-child":
+		return func(node *Node) bool {
+			_, count := elementIndex(node, false, false)
+			return count == 1
+		}, nil
+	case "checked":
+		return func(node *Node) bool {
+			return node.HasAttr("checked") || (node.Tag == "option" && node.HasAttr("selected"))
+		}, nil
+	case "disabled":
+		return func(node *Node) bool { return node.HasAttr("disabled") }, nil
+	case "enabled":
+		return func(node *Node) bool { return !node.HasAttr("disabled") }, nil
+	case "empty":
+		return func(node *Node) bool { return len(node.Children) == 0 }, nil
+	case "nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type":
+		if !p.consume('(') {
+			return nil, fmt.Errorf("missing argument to :%s", name)
+		}
+		end := strings.IndexByte(p.source[p.pos:], ')')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated :%s", name)
+		}
+		a, b, err := parseNth(p.source[p.pos : p.pos+end])
+		if err != nil {
+			return nil, err
+		}
+		p.pos += end + 1
+		return nthFilter(a, b, strings.HasSuffix(name, "of-type"), strings.Contains(name, "last")), nil
+	case "not":
+		if !p.consume('(') {
+			return nil, fmt.Errorf("missing argument to :not")
+		}
+		selectors, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(')') {
+			return nil, fmt.Errorf("unterminated :not")
+		}
+		return func(node *Node) bool {
+			for _, complex := range selectors {
+				if complex.match(node, nil) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported pseudo-class :%s", name)
+}
+
+func nthFilter(a, b int, sameType, fromEnd bool) func(node *Node) bool {
+	return func(node *Node) bool {
+		index, _ := elementIndex(node, sameType, fromEnd)
+		if a == 0 {
+			return index == b
+		}
+		return (index-b)%a == 0 && (index-b)/a >= 0
+	}
+}
+
+func parseNth(expression string) (a, b int, err error) {
+	expression = strings.ToLower(strings.Replace(expression, " ", "", -1))
+	switch expression {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+	n := strings.IndexByte(expression, 'n')
+	if n < 0 {
+		b, err = strconv.Atoi(expression)
+		return 0, b, err
+	}
+	switch coefficient := expression[:n]; coefficient {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		if a, err = strconv.Atoi(coefficient); err != nil {
+			return 0, 0, err
+		}
+	}
+	if offset := expression[n+1:]; offset != "" {
+		if b, err = strconv.Atoi(offset); err != nil {
+			return 0, 0, err
+		}
+	}
+	return a, b, nil
+}
+
+func (p *cssParser) readIdent() string {
+	var ident strings.Builder
+	for !p.done() {
+		c := p.peek()
+		if c == '\\' && p.pos+1 < len(p.source) {
+			ident.WriteByte(p.source[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		if !(isNameChar(c) || c >= 0x80) || c == ':' {
+			break
+		}
+		ident.WriteByte(c)
+		p.pos++
+	}
+	return ident.String()
+}
+
+func (p *cssParser) readValue() (string, error) {
+	quote := p.peek()
+	if quote != '"' && quote != '\'' {
+		return p.readIdent(), nil
+	}
+	p.pos++
+	var value strings.Builder
+	for !p.done() {
+		c := p.peek()
+		p.pos++
+		switch {
+		case c == '\\' && !p.done():
+			value.WriteByte(p.peek())
+			p.pos++
+		case c == quote:
+			return value.String(), nil
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *cssParser) skipSpace() bool {
+	start := p.pos
+	for !p.done() && strings.IndexByte(" \t\n\r\f", p.peek()) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *cssParser) consume(c byte) bool {
+	if !p.done() && p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *cssParser) peek() byte {
+	return p.source[p.pos]
+}
+
+func (p *cssParser) done() bool {
+	return p.pos >= len(p.source)
+}
+
+func containsWord(list, word string) bool {
+	for _, field := range strings.Fields(list) {
+		if field == word {
+			return true
+		}
+	}
+	return false
+}
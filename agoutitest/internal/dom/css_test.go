@@ -0,0 +1,92 @@
+package dom_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/agoutitest/internal/dom"
+)
+
+var _ = Describe("CSS selectors", func() {
+	var document *Node
+
+	BeforeEach(func() {
+		document = Parse(`
+			<div id="main" class="box big">
+				<p class="first">one</p>
+				<p lang="en-US">two</p>
+				<span><p data-x="abc">three</p></span>
+				<input type="checkbox" checked>
+				<input type="text" disabled>
+			</div>
+			<ul><li>a</li><li>b</li><li>c</li><li>d</li></ul>`)
+	})
+
+	ids := func(selector string) []string {
+		nodes, err := document.SelectCSS(selector)
+		Expect(err).NotTo(HaveOccurred())
+		texts := []string{}
+		for _, node := range nodes {
+			texts = append(texts, node.Tag+":"+NormalizeSpace(node.TextContent()))
+		}
+		return texts
+	}
+
+	It("should match type, ID, class, and universal selectors", func() {
+		Expect(ids("p")).To(Equal([]string{"p:one", "p:two", "p:three"}))
+		Expect(ids("#main")).To(HaveLen(1))
+		Expect(ids("div.box.big")).To(HaveLen(1))
+		Expect(ids(".missing")).To(BeEmpty())
+		Expect(ids("ul > *")).To(HaveLen(4))
+	})
+
+	It("should match combinators", func() {
+		Expect(ids("div p")).To(Equal([]string{"p:one", "p:two", "p:three"}))
+		Expect(ids("div > p")).To(Equal([]string{"p:one", "p:two"}))
+		Expect(ids("p.first + p")).To(Equal([]string{"p:two"}))
+		Expect(ids("p.first ~ span")).To(Equal([]string{"span:three"}))
+	})
+
+	It("should match attribute selectors", func() {
+		Expect(ids("[data-x]")).To(Equal([]string{"p:three"}))
+		Expect(ids(`[data-x="abc"]`)).To(Equal([]string{"p:three"}))
+		Expect(ids("[data-x^=a]")).To(Equal([]string{"p:three"}))
+		Expect(ids("[data-x$=c]")).To(Equal([]string{"p:three"}))
+		Expect(ids("[data-x*=b]")).To(Equal([]string{"p:three"}))
+		Expect(ids("[class~=big]")).To(HaveLen(1))
+		Expect(ids("[lang|=en]")).To(Equal([]string{"p:two"}))
+		Expect(ids("[data-x=ABC i]")).To(Equal([]string{"p:three"}))
+	})
+
+	It("should match pseudo-classes", func() {
+		Expect(ids("li:first-child")).To(Equal([]string{"li:a"}))
+		Expect(ids("li:last-child")).To(Equal([]string{"li:d"}))
+		Expect(ids("li:nth-child(2n)")).To(Equal([]string{"li:b", "li:d"}))
+		Expect(ids("li:nth-child(odd)")).To(Equal([]string{"li:a", "li:c"}))
+		Expect(ids("li:nth-last-child(1)")).To(Equal([]string{"li:d"}))
+		Expect(ids("p:first-of-type")).To(Equal([]string{"p:one", "p:three"}))
+		Expect(ids("input:checked")).To(HaveLen(1))
+		Expect(ids("input:disabled")).To(HaveLen(1))
+		Expect(ids("li:not(:first-child, :last-child)")).To(Equal([]string{"li:b", "li:c"}))
+	})
+
+	It("should match selector lists in document order", func() {
+		Expect(ids("li:last-child, p.first")).To(Equal([]string{"p:one", "li:d"}))
+	})
+
+	It("should only match descendants of the node", func() {
+		span := document.Find("span")
+		nodes, err := span.SelectCSS("p")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodes).To(HaveLen(1))
+		nodes, err = span.SelectCSS("div p")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodes).To(BeEmpty())
+	})
+
+	It("should return an error for invalid selectors", func() {
+		_, err := document.SelectCSS("p[")
+		Expect(err).To(MatchError(ContainSubstring(`invalid CSS selector "p["`)))
+		_, err = document.SelectCSS("p:hover")
+		Expect(err).To(MatchError(ContainSubstring("unsupported pseudo-class :hover")))
+	})
+})
@@ -0,0 +1,13 @@
+package dom_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestDOM(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DOM Suite")
+}
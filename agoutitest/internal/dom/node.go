@@ -0,0 +1,225 @@
+package dom
+
+import (
+	"strings"
+)
+
+// A Node is an element, text node, or document in an in-memory DOM.
+// Element state (such as values and checked boxes) is stored as attributes.
+type Node struct {
+	// Tag is the lowercase tag name of an element, "#text" for text nodes,
+	// or "#document" for documents.
+	Tag      string
+	Text     string
+	Attrs    []Attr
+	Parent   *Node
+	Children []*Node
+}
+
+type Attr struct {
+	Name  string
+	Value string
+}
+
+const (
+	documentTag = "#document"
+	textTag     = "#text"
+)
+
+func (n *Node) IsElement() bool {
+	return n.Tag != documentTag && n.Tag != textTag
+}
+
+func (n *Node) IsDocument() bool {
+	return n.Tag == documentTag
+}
+
+// Attr returns the value of the provided attribute and whether it is present.
+func (n *Node) Attr(name string) (string, bool) {
+	name = strings.ToLower(name)
+	for _, attr := range n.Attrs {
+		if attr.Name == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+func (n *Node) HasAttr(name string) bool {
+	_, ok := n.Attr(name)
+	return ok
+}
+
+func (n *Node) SetAttr(name, value string) {
+	name = strings.ToLower(name)
+	for i, attr := range n.Attrs {
+		if attr.Name == name {
+			n.Attrs[i].Value = value
+			return
+		}
+	}
+	n.Attrs = append(n.Attrs, Attr{name, value})
+}
+
+func (n *Node) RemoveAttr(name string) {
+	name = strings.ToLower(name)
+	for i, attr := range n.Attrs {
+		if attr.Name == name {
+			n.Attrs = append(n.Attrs[:i], n.Attrs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Root returns the document or detached subtree root containing the node.
+func (n *Node) Root() *Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// Contains returns true if other is the node or a descendant of the node.
+func (n *Node) Contains(other *Node) bool {
+	for ; other != nil; other = other.Parent {
+		if other == n {
+			return true
+		}
+	}
+	return false
+}
+
+// Elements returns the element children of the node.
+func (n *Node) Elements() []*Node {
+	var elements []*Node
+	for _, child := range n.Children {
+		if child.IsElement() {
+			elements = append(elements, child)
+		}
+	}
+	return elements
+}
+
+// Descendants returns all descendant elements of the node in document order.
+func (n *Node) Descendants() []*Node {
+	var descendants []*Node
+	for _, child := range n.Elements() {
+		descendants = append(descendants, child)
+		descendants = append(descendants, child.Descendants()...)
+	}
+	return descendants
+}
+
+// Ancestor returns the closest ancestor element with the provided tag name.
+func (n *Node) Ancestor(tag string) *Node {
+	for parent := n.Parent; parent != nil; parent = parent.Parent {
+		if parent.Tag == tag {
+			return parent
+		}
+	}
+	return nil
+}
+
+// TextContent returns the concatenated text of all descendant text nodes.
+func (n *Node) TextContent() string {
+	if n.Tag == textTag {
+		return n.Text
+	}
+	var text strings.Builder
+	for _, child := range n.Children {
+		text.WriteString(child.TextContent())
+	}
+	return text.String()
+}
+
+// VisibleText returns the normalized text content of the node, excluding
+// hidden elements, scripts, and styles, with line breaks between blocks.
+func (n *Node) VisibleText() string {
+	var lines []string
+	var line strings.Builder
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		switch {
+		case node.Tag == textTag:
+			line.WriteString(node.Text)
+			return
+		case node.IsElement() && !node.Displayed():
+			return
+		case node.Tag == "br":
+			lines = append(lines, line.String())
+			line.Reset()
+			return
+		}
+		block := blockElements[node.Tag]
+		if block {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+		if block {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+	}
+	walk(n)
+	lines = append(lines, line.String())
+
+	var normalized []string
+	for _, text := range lines {
+		if text = NormalizeSpace(text); text != "" {
+			normalized = append(normalized, text)
+		}
+	}
+	return strings.Join(normalized, "\n")
+}
+
+// Displayed returns true unless the element or one of its ancestors is hidden
+// using the hidden attribute, an inline style, or a non-rendered tag.
+func (n *Node) Displayed() bool {
+	for node := n; node != nil && node.IsElement(); node = node.Parent {
+		if hiddenElements[node.Tag] || node.HasAttr("hidden") {
+			return false
+		}
+		if inputType, _ := node.Attr("type"); node.Tag == "input" && strings.EqualFold(inputType, "hidden") {
+			return false
+		}
+		style, _ := node.Attr("style")
+		style = strings.ToLower(strings.Replace(style, " ", "", -1))
+		if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+			return false
+		}
+	}
+	return true
+}
+
+// Find returns the first descendant element with the provided tag name.
+func (n *Node) Find(tag string) *Node {
+	for _, descendant := range n.Descendants() {
+		if descendant.Tag == tag {
+			return descendant
+		}
+	}
+	return nil
+}
+
+// NormalizeSpace trims and collapses whitespace like the XPath normalize-space function.
+func NormalizeSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "tr": true,
+	"ul": true, "body": true, "html": true, "option": true,
+}
+
+var hiddenElements = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true,
+	"title": true, "meta": true, "link": true, "noscript": true,
+}
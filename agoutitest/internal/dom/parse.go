@@ -0,0 +1,244 @@
+package dom
+
+import (
+	"html"
+	"strings"
+)
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+var rawTextElements = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true,
+}
+
+// elements that are implicitly closed when a sibling of the listed tags opens
+var implicitlyClosed = map[string][]string{
+	"li":       {"li"},
+	"option":   {"option"},
+	"p":        {"p"},
+	"tr":       {"tr", "td", "th"},
+	"td":       {"td", "th"},
+	"th":       {"td", "th"},
+	"dt":       {"dt", "dd"},
+	"dd":       {"dt", "dd"},
+	"optgroup": {"optgroup", "option"},
+}
+
+// Parse parses an HTML document leniently. Comments, doctypes, and processing
+// instructions are ignored, and unclosed elements are closed at the end of
+// their parent.
+func Parse(source string) *Node {
+	parser := &parser{source: source}
+	document := &Node{Tag: documentTag}
+	parser.stack = []*Node{document}
+	parser.parse()
+	return document
+}
+
+type parser struct {
+	source string
+	pos    int
+	stack  []*Node
+}
+
+func (p *parser) current() *Node {
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *parser) append(node *Node) {
+	parent := p.current()
+	node.Parent = parent
+	parent.Children = append(parent.Children, node)
+}
+
+func (p *parser) parse() {
+	for p.pos < len(p.source) {
+		next := strings.IndexByte(p.source[p.pos:], '<')
+		if next < 0 {
+			p.text(p.source[p.pos:])
+			return
+		}
+		p.text(p.source[p.pos : p.pos+next])
+		p.pos += next
+
+		rest := p.source[p.pos:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			p.skipPast("-->")
+		case strings.HasPrefix(rest, "<!"), strings.HasPrefix(rest, "<?"):
+			p.skipPast(">")
+		case strings.HasPrefix(rest, "</"):
+			p.closeTag()
+		case len(rest) > 1 && isNameStart(rest[1]):
+			p.openTag()
+		default:
+			p.text("<")
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) skipPast(terminator string) {
+	end := strings.Index(p.source[p.pos:], terminator)
+	if end < 0 {
+		p.pos = len(p.source)
+		return
+	}
+	p.pos += end + len(terminator)
+}
+
+func (p *parser) text(text string) {
+	if text == "" {
+		return
+	}
+	p.append(&Node{Tag: textTag, Text: html.UnescapeString(text)})
+}
+
+func (p *parser) closeTag() {
+	p.pos += 2
+	tag := strings.ToLower(p.readName())
+	p.skipPast(">")
+	for i := len(p.stack) - 1; i > 0; i-- {
+		if p.stack[i].Tag == tag {
+			p.stack = p.stack[:i]
+			return
+		}
+	}
+}
+
+func (p *parser) openTag() {
+	p.pos++
+	element := &Node{Tag: strings.ToLower(p.readName())}
+	selfClosing := p.readAttrs(element)
+
+	for closes := implicitlyClosed[element.Tag]; contains(closes, p.current().Tag); {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+
+	p.append(element)
+	if selfClosing || voidElements[element.Tag] {
+		return
+	}
+
+	if rawTextElements[element.Tag] {
+		end := strings.Index(strings.ToLower(p.source[p.pos:]), "</"+element.Tag)
+		if end < 0 {
+			end = len(p.source) - p.pos
+		}
+		text := p.source[p.pos : p.pos+end]
+		if element.Tag == "textarea" || element.Tag == "title" {
+			text = html.UnescapeString(text)
+		}
+		if text != "" {
+			element.Children = []*Node{{Tag: textTag, Text: text, Parent: element}}
+		}
+		p.pos += end
+		p.skipPast(">")
+		return
+	}
+
+	p.stack = append(p.stack, element)
+}
+
+func (p *parser) readAttrs(element *Node) (selfClosing bool) {
+	for p.pos < len(p.source) {
+		p.skipSpace()
+		if p.pos >= len(p.source) {
+			return false
+		}
+		switch p.source[p.pos] {
+		case '>':
+			p.pos++
+			return false
+		case '/':
+			p.pos++
+			if p.pos < len(p.source) && p.source[p.pos] == '>' {
+				p.pos++
+				return true
+			}
+			continue
+		}
+
+		name := strings.ToLower(p.readAttrName())
+		if name == "" {
+			p.pos++
+			continue
+		}
+		p.skipSpace()
+		value := ""
+		if p.pos < len(p.source) && p.source[p.pos] == '=' {
+			p.pos++
+			p.skipSpace()
+			value = html.UnescapeString(p.readAttrValue())
+		}
+		if !element.HasAttr(name) {
+			element.Attrs = append(element.Attrs, Attr{name, value})
+		}
+	}
+	return false
+}
+
+func (p *parser) readName() string {
+	start := p.pos
+	for p.pos < len(p.source) && isNameChar(p.source[p.pos]) {
+		p.pos++
+	}
+	return p.source[start:p.pos]
+}
+
+func (p *parser) readAttrName() string {
+	start := p.pos
+	for p.pos < len(p.source) && !strings.ContainsRune(" \t\n\r\f/>=", rune(p.source[p.pos])) {
+		p.pos++
+	}
+	return p.source[start:p.pos]
+}
+
+func (p *parser) readAttrValue() string {
+	if p.pos >= len(p.source) {
+		return ""
+	}
+	if quote := p.source[p.pos]; quote == '"' || quote == '\'' {
+		end := strings.IndexByte(p.source[p.pos+1:], quote)
+		if end < 0 {
+			value := p.source[p.pos+1:]
+			p.pos = len(p.source)
+			return value
+		}
+		value := p.source[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value
+	}
+	start := p.pos
+	for p.pos < len(p.source) && !strings.ContainsRune(" \t\n\r\f>", rune(p.source[p.pos])) {
+		p.pos++
+	}
+	return p.source[start:p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.source) && strings.ContainsRune(" \t\n\r\f", rune(p.source[p.pos])) {
+		p.pos++
+	}
+}
+
+func isNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9' || c == '-' || c == '_' || c == ':'
+}
+
+func contains(tags []string, tag string) bool {
+	for _, candidate := range tags {
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package dom_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/agoutitest/internal/dom"
+)
+
+var _ = Describe("Parse", func() {
+	It("should build a tree of elements and text", func() {
+		document := Parse(`<!DOCTYPE html><html><body><p class="a">Hello <b>world</b></p><!-- comment --></body></html>`)
+		Expect(document.IsDocument()).To(BeTrue())
+		paragraph := document.Find("p")
+		class, _ := paragraph.Attr("class")
+		Expect(class).To(Equal("a"))
+		Expect(paragraph.TextContent()).To(Equal("Hello world"))
+		Expect(paragraph.Parent.Tag).To(Equal("body"))
+	})
+
+	It("should handle void, self-closing, and raw text elements", func() {
+		document := Parse(`<div><input name=a><br/><script>if (a < b) {}</script><span>x</span></div>`)
+		Expect(document.Find("div").Elements()).To(HaveLen(4))
+		Expect(document.Find("script").TextContent()).To(Equal("if (a < b) {}"))
+	})
+
+	It("should implicitly close list items, options, and table cells", func() {
+		document := Parse(`<ul><li>one<li>two</ul><select><option>a<option>b</select><table><tr><td>1<td>2<tr><td>3</table>`)
+		Expect(document.Find("ul").Elements()).To(HaveLen(2))
+		Expect(document.Find("select").Elements()).To(HaveLen(2))
+		Expect(document.Find("table").Elements()).To(HaveLen(2))
+	})
+
+	It("should unescape entities in text and attributes", func() {
+		document := Parse(`<a title="a &amp; b">&lt;tag&gt;</a>`)
+		title, _ := document.Find("a").Attr("title")
+		Expect(title).To(Equal("a & b"))
+		Expect(document.Find("a").TextContent()).To(Equal("<tag>"))
+	})
+
+	It("should serialize the document back to HTML", func() {
+		document := Parse(`<p id="x">a &amp; b<br></p>`)
+		Expect(document.HTML()).To(Equal(`<p id="x">a &amp; b<br></p>`))
+	})
+
+	Describe("#VisibleText", func() {
+		It("should return normalized text with line breaks between blocks", func() {
+			document := Parse(`<body><div>  one
+			two </div><span>three</span><p hidden>hidden</p><script>script</script><span style="display: none">none</span></body>`)
+			Expect(document.VisibleText()).To(Equal("one two\nthree"))
+		})
+	})
+
+	Describe("#Displayed", func() {
+		It("should return false for hidden elements and their descendants", func() {
+			document := Parse(`<div style="visibility:hidden"><span>a</span></div><input type="hidden"><b>b</b>`)
+			Expect(document.Find("span").Displayed()).To(BeFalse())
+			Expect(document.Find("input").Displayed()).To(BeFalse())
+			Expect(document.Find("b").Displayed()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package dom
+
+import (
+	"html"
+	"strings"
+)
+
+// HTML returns the serialized HTML of the node, including the node itself
+// unless it is a document.
+func (n *Node) HTML() string {
+	var out strings.Builder
+	n.render(&out)
+	return out.String()
+}
+
+// InnerHTML returns the serialized HTML of the children of the node.
+func (n *Node) InnerHTML() string {
+	var out strings.Builder
+	for _, child := range n.Children {
+		child.render(&out)
+	}
+	return out.String()
+}
+
+func (n *Node) render(out *strings.Builder) {
+	switch {
+	case n.Tag == textTag:
+		if n.Parent != nil && rawTextElements[n.Parent.Tag] && n.Parent.Tag != "textarea" && n.Parent.Tag != "title" {
+			out.WriteString(n.Text)
+		} else {
+			out.WriteString(html.EscapeString(n.Text))
+		}
+		return
+	case n.IsDocument():
+		for _, child := range n.Children {
+			child.render(out)
+		}
+		return
+	}
+
+	out.WriteString("<" + n.Tag)
+	for _, attr := range n.Attrs {
+		out.WriteString(" " + attr.Name + `="` + html.EscapeString(attr.Value) + `"`)
+	}
+	out.WriteString(">")
+	if voidElements[n.Tag] {
+		return
+	}
+	for _, child := range n.Children {
+		child.render(out)
+	}
+	out.WriteString("</" + n.Tag + ">")
+}
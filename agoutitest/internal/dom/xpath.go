@@ -0,0 +1,951 @@
+package dom
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SelectXPath evaluates the XPath expression using the node as the context
+// node and returns the matching elements in document order. A subset of
+// XPath 1.0 is supported, including the common axes, abbreviated syntax,
+// predicates, unions, boolean and comparison operators, and the core string,
+// boolean, and node-set functions.
+func (n *Node) SelectXPath(expression string) ([]*Node, error) {
+	parser := &xpathParser{}
+	if err := parser.tokenize(expression); err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %s", expression, err)
+	}
+	expr, err := parser.parseExpr()
+	if err == nil && !parser.done() {
+		err = fmt.Errorf("unexpected %q", parser.peek().value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %s", expression, err)
+	}
+
+	evaluator := &xpathEvaluator{}
+	result, err := expr(evaluator, xpathContext{item: xpathItem{node: n}, position: 1, size: 1})
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %s", expression, err)
+	}
+	items, ok := result.([]xpathItem)
+	if !ok {
+		return nil, fmt.Errorf("invalid XPath %q: result is not a node-set", expression)
+	}
+
+	var elements []*Node
+	for _, item := range items {
+		if item.attr == nil && item.node.IsElement() {
+			elements = append(elements, item.node)
+		}
+	}
+	return elements, nil
+}
+
+// An xpathItem is an element, text, or document node, or an attribute of an element.
+type xpathItem struct {
+	node *Node
+	attr *Attr
+}
+
+func (i xpathItem) stringValue() string {
+	if i.attr != nil {
+		return i.attr.Value
+	}
+	return i.node.TextContent()
+}
+
+type xpathContext struct {
+	item     xpathItem
+	position int
+	size     int
+}
+
+// values are []xpathItem, string, float64, or bool
+type xpathExpr func(e *xpathEvaluator, ctx xpathContext) (interface{}, error)
+
+type xpathEvaluator struct {
+	order map[*Node]int
+}
+
+func (e *xpathEvaluator) sort(items []xpathItem) []xpathItem {
+	if len(items) == 0 {
+		return items
+	}
+	if e.order == nil {
+		e.order = map[*Node]int{}
+		var walk func(node *Node)
+		walk = func(node *Node) {
+			e.order[node] = len(e.order)
+			for _, child := range node.Children {
+				walk(child)
+			}
+		}
+		walk(items[0].node.Root())
+	}
+
+	seen := map[xpathItem]bool{}
+	var unique []xpathItem
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		a, b := unique[i], unique[j]
+		if a.node != b.node {
+			return e.order[a.node] < e.order[b.node]
+		}
+		return a.attr == nil && b.attr != nil
+	})
+	return unique
+}
+
+type xpathToken struct {
+	kind  string // "op", "name", "literal", "number"
+	value string
+}
+
+type xpathParser struct {
+	tokens []xpathToken
+	pos    int
+}
+
+func (p *xpathParser) tokenize(source string) error {
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case strings.IndexByte(" \t\n\r", c) >= 0:
+			i++
+		case strings.HasPrefix(source[i:], "//"), strings.HasPrefix(source[i:], ".."),
+			strings.HasPrefix(source[i:], "::"), strings.HasPrefix(source[i:], "!="),
+			strings.HasPrefix(source[i:], "<="), strings.HasPrefix(source[i:], ">="):
+			p.tokens = append(p.tokens, xpathToken{"op", source[i : i+2]})
+			i += 2
+		case c == '.' && (i+1 >= len(source) || source[i+1] < '0' || source[i+1] > '9'):
+			p.tokens = append(p.tokens, xpathToken{"op", "."})
+			i++
+		case strings.IndexByte("/()[]@,|=<>+-*", c) >= 0:
+			p.tokens = append(p.tokens, xpathToken{"op", string(c)})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(source[i+1:], c)
+			if end < 0 {
+				return fmt.Errorf("unterminated string")
+			}
+			p.tokens = append(p.tokens, xpathToken{"literal", source[i+1 : i+1+end]})
+			i += end + 2
+		case c >= '0' && c <= '9' || c == '.':
+			start := i
+			for i < len(source) && (source[i] >= '0' && source[i] <= '9' || source[i] == '.') {
+				i++
+			}
+			p.tokens = append(p.tokens, xpathToken{"number", source[start:i]})
+		case isNameStart(c) || c == '_':
+			start := i
+			for i < len(source) && (isNameChar(source[i]) && source[i] != ':' || source[i] == '.') {
+				i++
+			}
+			p.tokens = append(p.tokens, xpathToken{"name", source[start:i]})
+		default:
+			return fmt.Errorf("unexpected %q", source[i:])
+		}
+	}
+	return nil
+}
+
+func (p *xpathParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *xpathParser) peek() xpathToken {
+	if p.done() {
+		return xpathToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *xpathParser) peekAt(offset int) xpathToken {
+	if p.pos+offset >= len(p.tokens) {
+		return xpathToken{}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *xpathParser) consumeOp(values ...string) (string, bool) {
+	token := p.peek()
+	if token.kind != "op" {
+		return "", false
+	}
+	for _, value := range values {
+		if token.value == value {
+			p.pos++
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (p *xpathParser) consumeName(value string) bool {
+	if token := p.peek(); token.kind == "name" && token.value == value {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *xpathParser) expectOp(value string) error {
+	if _, ok := p.consumeOp(value); !ok {
+		if p.done() {
+			return fmt.Errorf("expected %q at end of expression", value)
+		}
+		return fmt.Errorf("expected %q but found %q", value, p.peek().value)
+	}
+	return nil
+}
+
+func (p *xpathParser) parseExpr() (xpathExpr, error) {
+	return p.parseBinary(0)
+}
+
+var xpathPrecedence = [][]string{
+	{"or"},
+	{"and"},
+	{"=", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+}
+
+func (p *xpathParser) parseBinary(level int) (xpathExpr, error) {
+	if level == len(xpathPrecedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := "", false
+		if level < 2 {
+			if ok = p.consumeName(xpathPrecedence[level][0]); ok {
+				operator = xpathPrecedence[level][0]
+			}
+		} else {
+			operator, ok = p.consumeOp(xpathPrecedence[level]...)
+		}
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr(operator, left, right)
+	}
+}
+
+func (p *xpathParser) parseUnary() (xpathExpr, error) {
+	if _, ok := p.consumeOp("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(e *xpathEvaluator, ctx xpathContext) (interface{}, error) {
+			value, err := operand(e, ctx)
+			return -toNumber(value), err
+		}, nil
+	}
+	return p.parseUnion()
+}
+
+func (p *xpathParser) parseUnion() (xpathExpr, error) {
+	left, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.consumeOp("|"); !ok {
+			return left, nil
+		}
+		right, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		first := left
+		left = func(e *xpathEvaluator, ctx xpathContext) (interface{}, error) {
+			leftItems, err := evalNodeSet(e, ctx, first)
+			if err != nil {
+				return nil, err
+			}
+			rightItems, err := evalNodeSet(e, ctx, right)
+			if err != nil {
+				return nil, err
+			}
+			return e.sort(append(leftItems, rightItems...)), nil
+		}
+	}
+}
+
+func (p *xpathParser) parsePath() (xpathExpr, error) {
+	token := p.peek()
+	isPrimary := token.kind == "literal" || token.kind == "number" ||
+		token.kind == "op" && token.value == "(" ||
+		token.kind == "name" && p.peekAt(1).value == "(" && !isNodeType(token.value)
+
+	if !isPrimary {
+		return p.parseLocationPath()
+	}
+
+	primary, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	predicates, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	filter := primary
+	if len(predicates) > 0 {
+		filter = func(e *xpathEvaluator, ctx xpathContext) (interface{}, error) {
+			items, err := evalNodeSet(e, ctx, primary)
+			if err != nil {
+				return nil, err
+			}
+			return applyPredicates(e, items, predicates)
+		}
+	}
+
+	separator, ok := p.consumeOp("/", "//")
+	if !ok {
+		return filter, nil
+	}
+	steps, err := p.parseRelativePath(separator == "//")
+	if err != nil {
+		return nil, err
+	}
+	return func(e *xpathEvaluator, ctx xpathContext) (interface{}, error) {
+		items, err := evalNodeSet(e, ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return evalSteps(e, items, steps)
+	}, nil
+}
+
+func (p *xpathParser) parsePrimary() (xpathExpr, error) {
+	token := p.peek()
+	p.pos++
+	switch token.kind {
+	case "literal":
+		return func(*xpathEvaluator, xpathContext) (interface{}, error) { return token.value, nil }, nil
+	case "number":
+		number, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(*xpathEvaluator, xpathContext) (interface{}, error) { return number, nil }, nil
+	case "op":
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expectOp(")")
+	}
+
+	p.pos++ // (
+	var arguments []xpathExpr
+	if _, ok := p.consumeOp(")"); !ok {
+		for {
+			argument, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			arguments = append(arguments, argument)
+			if _, ok := p.consumeOp(","); !ok {
+				break
+			}
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+	}
+	return functionExpr(token.value, arguments)
+}
+
+func isNodeType(name string) bool {
+	return name == "text" || name == "node"
+}
+
+type xpathStep struct {
+	axis       string
+	test       string
+	predicates []xpathExpr
+}
+
+func (p *xpathParser) parseLocationPath() (xpathExpr, error) {
+	absolute := false
+	descendant := false
+	if separator, ok := p.consumeOp("/", "//"); ok {
+		absolute = true
+		descendant = separator == "//"
+	}
+
+	var steps []xpathStep
+	if !absolute || descendant || p.startsStep() {
+		var err error
+		if steps, err = p.parseRelativePath(descendant); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(e *xpathEvaluator, ctx xpathContext) (interface{}, error) {
+		start := ctx.item
+		if absolute {
+			start = xpathItem{node: start.node.Root()}
+		}
+		return evalSteps(e, []xpathItem{start}, steps)
+	}, nil
+}
+
+func (p *xpathParser) startsStep() bool {
+	token := p.peek()
+	return token.kind == "name" || token.kind == "op" && strings.Contains(". .. @ *", token.value)
+}
+
+func (p *xpathParser) parseRelativePath(descendant bool) ([]xpathStep, error) {
+	var steps []xpathStep
+	for {
+		if descendant {
+			steps = append(steps, xpathStep{axis: "descendant-or-self", test: "node()"})
+		}
+		step, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+
+		separator, ok := p.consumeOp("/", "//")
+		if !ok {
+			return steps, nil
+		}
+		descendant = separator == "//"
+	}
+}
+
+func (p *xpathParser) parseStep() (xpathStep, error) {
+	if _, ok := p.consumeOp("."); ok {
+		return xpathStep{axis: "self", test: "node()"}, nil
+	}
+	if _, ok := p.consumeOp(".."); ok {
+		return xpathStep{axis: "parent", test: "node()"}, nil
+	}
+
+	step := xpathStep{axis: "child"}
+	if _, ok := p.consumeOp("@"); ok {
+		step.axis = "attribute"
+	} else if p.peek().kind == "name" && p.peekAt(1).value == "::" {
+		step.axis = p.peek().value
+		p.pos += 2
+		if _, ok := axes[step.axis]; !ok {
+			return step, fmt.Errorf("unsupported axis %q", step.axis)
+		}
+	}
+
+	token := p.peek()
+	switch {
+	case token.kind == "op" && token.value == "*":
+		step.test = "*"
+		p.pos++
+	case token.kind == "name" && isNodeType(token.value) && p.peekAt(1).value == "(":
+		step.test = token.value + "()"
+		p.pos += 2
+		if err := p.expectOp(")"); err != nil {
+			return step, err
+		}
+	case token.kind == "name":
+		step.test = strings.ToLower(token.value)
+		p.pos++
+	default:
+		if p.done() {
+			return step, fmt.Errorf("expected a step at end of expression")
+		}
+		return step, fmt.Errorf("unexpected %q", token.value)
+	}
+
+	var err error
+	step.predicates, err = p.parsePredicates()
+	return step, err
+}
+
+func (p *xpathParser) parsePredicates() ([]xpathExpr, error) {
+	var predicates []xpathExpr
+	for {
+		if _, ok := p.consumeOp("["); !ok {
+			return predicates, nil
+		}
+		predicate, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp("]"); err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, predicate)
+	}
+}
+
+var axes = map[string]func(item xpathItem) []xpathItem{
+	"child": func(item xpathItem) []xpathItem {
+		if item.attr != nil {
+			return nil
+		}
+		return nodeItems(item.node.Children)
+	},
+	"descendant": func(item xpathItem) []xpathItem {
+		if item.attr != nil {
+			return nil
+		}
+		return nodeItems(allDescendants(item.node))
+	},
+	"descendant-or-self": func(item xpathItem) []xpathItem {
+		if item.attr != nil {
+			return []xpathItem{item}
+		}
+		return append([]xpathItem{item}, nodeItems(allDescendants(item.node))...)
+	},
+	"self": func(item xpathItem) []xpathItem {
+		return []xpathItem{item}
+	},
+	"parent": func(item xpathItem) []xpathItem {
+		if item.attr != nil {
+			return []xpathItem{{node: item.node}}
+		}
+		if item.node.Parent == nil {
+			return nil
+		}
+		return []xpathItem{{node: item.node.Parent}}
+	},
+	"ancestor": func(item xpathItem) []xpathItem {
+		return ancestors(item, false)
+	},
+	"ancestor-or-self": func(item xpathItem) []xpathItem {
+		return ancestors(item, true)
+	},
+	"following-sibling": func(item xpathItem) []xpathItem {
+		return siblings(item, true)
+	},
+	"preceding-sibling": func(item xpathItem) []xpathItem {
+		return siblings(item, false)
+	},
+	"attribute": func(item xpathItem) []xpathItem {
+		if item.attr != nil {
+			return nil
+		}
+		var items []xpathItem
+		for i := range item.node.Attrs {
+			items = append(items, xpathItem{node: item.node, attr: &item.node.Attrs[i]})
+		}
+		return items
+	},
+}
+
+func nodeItems(nodes []*Node) []xpathItem {
+	items := make([]xpathItem, len(nodes))
+	for i, node := range nodes {
+		items[i] = xpathItem{node: node}
+	}
+	return items
+}
+
+func allDescendants(node *Node) []*Node {
+	var descendants []*Node
+	for _, child := range node.Children {
+		descendants = append(descendants, child)
+		descendants = append(descendants, allDescendants(child)...)
+	}
+	return descendants
+}
+
+func ancestors(item xpathItem, self bool) []xpathItem {
+	var items []xpathItem
+	if self {
+		items = append(items, item)
+	}
+	node := item.node
+	if item.attr == nil {
+		node = node.Parent
+	}
+	for ; node != nil; node = node.Parent {
+		items = append(items, xpathItem{node: node})
+	}
+	return items
+}
+
+func siblings(item xpathItem, following bool) []xpathItem {
+	if item.attr != nil || item.node.Parent == nil {
+		return nil
+	}
+	children := item.node.Parent.Children
+	for i, child := range children {
+		if child != item.node {
+			continue
+		}
+		if following {
+			return nodeItems(children[i+1:])
+		}
+		var items []xpathItem
+		for j := i - 1; j >= 0; j-- {
+			items = append(items, xpathItem{node: children[j]})
+		}
+		return items
+	}
+	return nil
+}
+
+func (s xpathStep) matches(item xpathItem) bool {
+	if s.axis == "attribute" {
+		return item.attr != nil && (s.test == "*" || s.test == "node()" || s.test == item.attr.Name)
+	}
+	if item.attr != nil {
+		return s.test == "node()"
+	}
+	switch s.test {
+	case "node()":
+		return true
+	case "text()":
+		return item.node.Tag == textTag
+	case "*":
+		return item.node.IsElement()
+	}
+	return item.node.IsElement() && item.node.Tag == s.test
+}
+
+func evalSteps(e *xpathEvaluator, items []xpathItem, steps []xpathStep) ([]xpathItem, error) {
+	for _, step := range steps {
+		var next []xpathItem
+		for _, item := range items {
+			var candidates []xpathItem
+			for _, candidate := range axes[step.axis](item) {
+				if step.matches(candidate) {
+					candidates = append(candidates, candidate)
+				}
+			}
+			filtered, err := applyPredicates(e, candidates, step.predicates)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, filtered...)
+		}
+		items = e.sort(next)
+	}
+	return items, nil
+}
+
+func applyPredicates(e *xpathEvaluator, items []xpathItem, predicates []xpathExpr) ([]xpathItem, error) {
+	for _, predicate := range predicates {
+		var filtered []xpathItem
+		for i, item := range items {
+			value, err := predicate(e, xpathContext{item: item, position: i + 1, size: len(items)})
+			if err != nil {
+				return nil, err
+			}
+			if number, ok := value.(float64); ok {
+				if number == float64(i+1) {
+					filtered = append(filtered, item)
+				}
+			} else if toBool(value) {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+	return items, nil
+}
+
+func evalNodeSet(e *xpathEvaluator, ctx xpathContext, expr xpathExpr) ([]xpathItem, error) {
+	value, err := expr(e, ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := value.([]xpathItem)
+	if !ok {
+		return nil, fmt.Errorf("expression is not a node-set")
+	}
+	return items, nil
+}
+
+func binaryExpr(operator string, left, right xpathExpr) xpathExpr {
+	return func(e *xpathEvaluator, ctx xpathContext) (interface{}, error) {
+		leftValue, err := left(e, ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch operator {
+		case "or":
+			if toBool(leftValue) {
+				return true, nil
+			}
+		case "and":
+			if !toBool(leftValue) {
+				return false, nil
+			}
+		}
+		rightValue, err := right(e, ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch operator {
+		case "or", "and":
+			return toBool(rightValue), nil
+		case "+":
+			return toNumber(leftValue) + toNumber(rightValue), nil
+		case "-":
+			return toNumber(leftValue) - toNumber(rightValue), nil
+		}
+		return compare(operator, leftValue, rightValue), nil
+	}
+}
+
+func compare(operator string, left, right interface{}) bool {
+	leftItems, leftIsSet := left.([]xpathItem)
+	rightItems, rightIsSet := right.([]xpathItem)
+	switch {
+	case leftIsSet:
+		for _, item := range leftItems {
+			if compare(operator, item.stringValue(), right) {
+				return true
+			}
+		}
+		return false
+	case rightIsSet:
+		for _, item := range rightItems {
+			if compare(operator, left, item.stringValue()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if operator == "=" || operator == "!=" {
+		var equal bool
+		_, leftIsBool := left.(bool)
+		_, rightIsBool := right.(bool)
+		_, leftIsNumber := left.(float64)
+		_, rightIsNumber := right.(float64)
+		switch {
+		case leftIsBool || rightIsBool:
+			equal = toBool(left) == toBool(right)
+		case leftIsNumber || rightIsNumber:
+			equal = toNumber(left) == toNumber(right)
+		default:
+			equal = toString(left) == toString(right)
+		}
+		return equal == (operator == "=")
+	}
+
+	leftNumber, rightNumber := toNumber(left), toNumber(right)
+	switch operator {
+	case "<":
+		return leftNumber < rightNumber
+	case "<=":
+		return leftNumber <= rightNumber
+	case ">":
+		return leftNumber > rightNumber
+	}
+	return leftNumber >= rightNumber
+}
+
+func toString(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		if value == math.Trunc(value) && !math.IsInf(value, 0) {
+			return strconv.FormatInt(int64(value), 10)
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []xpathItem:
+		if len(value) == 0 {
+			return ""
+		}
+		return value[0].stringValue()
+	}
+	return ""
+}
+
+func toNumber(value interface{}) float64 {
+	switch value := value.(type) {
+	case float64:
+		return value
+	case bool:
+		if value {
+			return 1
+		}
+		return 0
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(toString(value)), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return number
+}
+
+func toBool(value interface{}) bool {
+	switch value := value.(type) {
+	case bool:
+		return value
+	case float64:
+		return value != 0 && !math.IsNaN(value)
+	case string:
+		return value != ""
+	case []xpathItem:
+		return len(value) > 0
+	}
+	return false
+}
+
+func functionExpr(name string, arguments []xpathExpr) (xpathExpr, error) {
+	function, ok := xpathFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported function %s()", name)
+	}
+	return func(e *xpathEvaluator, ctx xpathContext) (interface{}, error) {
+		var values []interface{}
+		for _, argument := range arguments {
+			value, err := argument(e, ctx)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return function(ctx, values)
+	}, nil
+}
+
+func stringArgument(ctx xpathContext, values []interface{}) string {
+	if len(values) == 0 {
+		return ctx.item.stringValue()
+	}
+	return toString(values[0])
+}
+
+func expectArguments(name string, values []interface{}, count int) error {
+	if len(values) != count {
+		return fmt.Errorf("%s() expects %d arguments", name, count)
+	}
+	return nil
+}
+
+var xpathFunctions = map[string]func(ctx xpathContext, values []interface{}) (interface{}, error){
+	"last": func(ctx xpathContext, _ []interface{}) (interface{}, error) {
+		return float64(ctx.size), nil
+	},
+	"position": func(ctx xpathContext, _ []interface{}) (interface{}, error) {
+		return float64(ctx.position), nil
+	},
+	"count": func(_ xpathContext, values []interface{}) (interface{}, error) {
+		if len(values) != 1 {
+			return nil, fmt.Errorf("count() expects a node-set")
+		}
+		items, ok := values[0].([]xpathItem)
+		if !ok {
+			return nil, fmt.Errorf("count() expects a node-set")
+		}
+		return float64(len(items)), nil
+	},
+	"string": func(ctx xpathContext, values []interface{}) (interface{}, error) {
+		return stringArgument(ctx, values), nil
+	},
+	"normalize-space": func(ctx xpathContext, values []interface{}) (interface{}, error) {
+		return NormalizeSpace(stringArgument(ctx, values)), nil
+	},
+	"string-length": func(ctx xpathContext, values []interface{}) (interface{}, error) {
+		return float64(len([]rune(stringArgument(ctx, values)))), nil
+	},
+	"concat": func(_ xpathContext, values []interface{}) (interface{}, error) {
+		var result strings.Builder
+		for _, value := range values {
+			result.WriteString(toString(value))
+		}
+		return result.String(), nil
+	},
+	"contains": func(_ xpathContext, values []interface{}) (interface{}, error) {
+		if err := expectArguments("contains", values, 2); err != nil {
+			return nil, err
+		}
+		return strings.Contains(toString(values[0]), toString(values[1])), nil
+	},
+	"starts-with": func(_ xpathContext, values []interface{}) (interface{}, error) {
+		if err := expectArguments("starts-with", values, 2); err != nil {
+			return nil, err
+		}
+		return strings.HasPrefix(toString(values[0]), toString(values[1])), nil
+	},
+	"translate": func(_ xpathContext, values []interface{}) (interface{}, error) {
+		if err := expectArguments("translate", values, 3); err != nil {
+			return nil, err
+		}
+		from, to := []rune(toString(values[1])), []rune(toString(values[2]))
+		var result strings.Builder
+		for _, c := range toString(values[0]) {
+			index := strings.IndexRune(string(from), c)
+			if index < 0 {
+				result.WriteRune(c)
+			} else if index = len([]rune(string(from)[:index])); index < len(to) {
+				result.WriteRune(to[index])
+			}
+		}
+		return result.String(), nil
+	},
+	"not": func(_ xpathContext, values []interface{}) (interface{}, error) {
+		if err := expectArguments("not", values, 1); err != nil {
+			return nil, err
+		}
+		return !toBool(values[0]), nil
+	},
+	"boolean": func(_ xpathContext, values []interface{}) (interface{}, error) {
+		if err := expectArguments("boolean", values, 1); err != nil {
+			return nil, err
+		}
+		return toBool(values[0]), nil
+	},
+	"number": func(ctx xpathContext, values []interface{}) (interface{}, error) {
+		return toNumber(stringArgument(ctx, values)), nil
+	},
+	"true": func(xpathContext, []interface{}) (interface{}, error) {
+		return true, nil
+	},
+	"false": func(xpathContext, []interface{}) (interface{}, error) {
+		return false, nil
+	},
+	"name": func(ctx xpathContext, values []interface{}) (interface{}, error) {
+		item := ctx.item
+		if len(values) > 0 {
+			items, ok := values[0].([]xpathItem)
+			if !ok {
+				return nil, fmt.Errorf("name() expects a node-set")
+			}
+			if len(items) == 0 {
+				return "", nil
+			}
+			item = items[0]
+		}
+		if item.attr != nil {
+			return item.attr.Name, nil
+		}
+		if !item.node.IsElement() {
+			return "", nil
+		}
+		return item.node.Tag, nil
+	},
+}
+
+func init() {
+	xpathFunctions["local-name"] = xpathFunctions["name"]
+}
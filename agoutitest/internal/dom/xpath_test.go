@@ -0,0 +1,75 @@
+package dom_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/agoutitest/internal/dom"
+)
+
+var _ = Describe("XPath expressions", func() {
+	var document *Node
+
+	BeforeEach(func() {
+		document = Parse(`
+			<form>
+				<label for="name"> Name </label><input id="name">
+				<label>Age <input id="age"></label>
+				<button>Save</button>
+				<input id="send" type="submit" value="Send">
+				<select><option>Red</option><option value="b"> Blue </option></select>
+			</form>
+			<ul><li>a</li><li>b</li><li>c</li></ul>`)
+	})
+
+	selectXPath := func(node *Node, expression string) []string {
+		nodes, err := node.SelectXPath(expression)
+		Expect(err).NotTo(HaveOccurred())
+		results := []string{}
+		for _, node := range nodes {
+			id, ok := node.Attr("id")
+			if !ok {
+				id = NormalizeSpace(node.TextContent())
+			}
+			results = append(results, node.Tag+":"+id)
+		}
+		return results
+	}
+
+	It("should select elements by path and predicate", func() {
+		Expect(selectXPath(document, "//li")).To(Equal([]string{"li:a", "li:b", "li:c"}))
+		Expect(selectXPath(document, "//ul/li[2]")).To(Equal([]string{"li:b"}))
+		Expect(selectXPath(document, "//li[last()]")).To(Equal([]string{"li:c"}))
+		Expect(selectXPath(document, "//li[position() > 1]")).To(Equal([]string{"li:b", "li:c"}))
+		Expect(selectXPath(document, "//li[. = 'b']/following-sibling::li")).To(Equal([]string{"li:c"}))
+		Expect(selectXPath(document, "//li[contains(text(), 'c') or starts-with(., 'a')]")).To(Equal([]string{"li:a", "li:c"}))
+	})
+
+	It("should select elements using agouti's label expression", func() {
+		label := `//input[@id=(//label[normalize-space()="%s"]/@for)] | //label[normalize-space()="%[1]s"]/input`
+		Expect(selectXPath(document, fmt.Sprintf(label, "Name"))).To(Equal([]string{"input:name"}))
+		Expect(selectXPath(document, fmt.Sprintf(label, "Age"))).To(Equal([]string{"input:age"}))
+	})
+
+	It("should select elements using agouti's button expression", func() {
+		button := `//input[@type="submit" or @type="button"][normalize-space(@value)="%s"] | //button[normalize-space()="%[1]s"]`
+		Expect(selectXPath(document, fmt.Sprintf(button, "Save"))).To(Equal([]string{"button:Save"}))
+		Expect(selectXPath(document, fmt.Sprintf(button, "Send"))).To(Equal([]string{"input:send"}))
+	})
+
+	It("should select relative to the provided node", func() {
+		list := document.Find("select")
+		Expect(selectXPath(list, `./option[normalize-space()="Blue"]`)).To(Equal([]string{"option:Blue"}))
+		Expect(selectXPath(list, `./option[@value="b"]/..`)).To(Equal([]string{"select:Red Blue"}))
+	})
+
+	It("should return an error for invalid expressions", func() {
+		_, err := document.SelectXPath("//li[")
+		Expect(err).To(HaveOccurred())
+		_, err = document.SelectXPath("//li[unknown()]")
+		Expect(err).To(HaveOccurred())
+		_, err = document.SelectXPath("count(//li)")
+		Expect(err).To(MatchError(ContainSubstring("result is not a node-set")))
+	})
+})
@@ -0,0 +1,293 @@
+// Package agoutitest provides an in-process fake WebDriver for testing code
+// that uses the agouti and api packages without a browser.
+//
+// A Server speaks the JSON Wire Protocol over an httptest.Server and renders
+// HTML fixtures into an in-memory DOM. It supports session creation, element
+// lookup (CSS, XPath, link text, name, ID, class, and tag name), text and
+// attribute queries, clicking, typing, form submission, navigation, cookies,
+// windows, frames, and popups. JavaScript is not executed, but any command
+// may be scripted using Handle, and clicks may be scripted using OnClick.
+//
+// Example:
+//    server := agoutitest.NewServer()
+//    defer server.Close()
+//    server.Page("http://example.com/", `<a href="/next">Next</a>`)
+//    page, _ := agouti.NewPage(server.URL())
+//    page.Navigate("http://example.com/")
+//    page.FindByLink("Next").Click()
+package agoutitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/sclevine/agouti/agoutitest/internal/dom"
+	"github.com/sclevine/agouti/api"
+)
+
+// A Server is a fake WebDriver. Its methods are safe for concurrent use.
+type Server struct {
+	server   *httptest.Server
+	mutex    sync.Mutex
+	pages    map[string]string
+	handlers map[string]HandlerFunc
+	clicks   []clickHandler
+	sessions []*Session
+	lastID   int
+}
+
+// A HandlerFunc responds to a WebDriver command for a session. The returned
+// value is encoded as the value of the response. Returning an *api.Error
+// responds with its Code and Message, and any other error responds with an
+// "unknown error".
+type HandlerFunc func(session *Session, body []byte) (interface{}, error)
+
+type clickHandler struct {
+	selector string
+	handler  func(session *Session)
+}
+
+// NewServer starts and returns a new Server. It should be closed when it is
+// no longer needed.
+func NewServer() *Server {
+	server := &Server{
+		pages:    map[string]string{},
+		handlers: map[string]HandlerFunc{},
+	}
+	server.server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+// URL returns the WebDriver URL of the server, for use with agouti.NewPage.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Page registers an HTML fixture that is loaded when a session navigates to
+// the provided URL. Navigating to an unregistered URL loads a "Not Found" page.
+func (s *Server) Page(url, html string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pages[url] = html
+}
+
+// Handle overrides the response to a command, where endpoint is relative to
+// the session URL (ex. "execute" or "element/some-id/click"). Handlers take
+// precedence over the built-in commands.
+func (s *Server) Handle(method, endpoint string, handler HandlerFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[method+" "+strings.Trim(endpoint, "/")] = handler
+}
+
+// OnClick registers a handler that is called after an element matching the
+// provided CSS selector is clicked.
+func (s *Server) OnClick(selector string, handler func(session *Session)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clicks = append(s.clicks, clickHandler{selector, handler})
+}
+
+// Sessions returns all sessions that have been created and not deleted.
+func (s *Server) Sessions() []*Session {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*Session(nil), s.sessions...)
+}
+
+func (s *Server) serveHTTP(response http.ResponseWriter, request *http.Request) {
+	body, _ := ioutil.ReadAll(request.Body)
+	parts := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "status" && request.Method == "GET":
+		writeValue(response, "", map[string]interface{}{"ready": true})
+	case len(parts) == 1 && parts[0] == "session" && request.Method == "POST":
+		session := s.newSession(body)
+		writeValue(response, session.ID, session.Capabilities())
+	case len(parts) >= 2 && parts[0] == "session":
+		s.serveCommand(response, request.Method, parts[1], strings.Join(parts[2:], "/"), body)
+	default:
+		writeError(response, "", newError("unknown command", "unknown command: %s %s", request.Method, request.URL.Path))
+	}
+}
+
+func (s *Server) serveCommand(response http.ResponseWriter, method, sessionID, endpoint string, body []byte) {
+	s.mutex.Lock()
+	session := s.session(sessionID)
+	handler := s.handlers[method+" "+endpoint]
+	s.mutex.Unlock()
+
+	if session == nil {
+		writeError(response, sessionID, newError("invalid session id", "no session with ID %s", sessionID))
+		return
+	}
+
+	var value interface{}
+	var err error
+	if handler != nil {
+		value, err = handler(session, body)
+	} else {
+		var callbacks []func()
+		s.mutex.Lock()
+		value, err = session.execute(method, endpoint, body)
+		callbacks, session.callbacks = session.callbacks, nil
+		s.mutex.Unlock()
+		for _, callback := range callbacks {
+			callback()
+		}
+	}
+
+	if err != nil {
+		writeError(response, sessionID, err)
+		return
+	}
+	writeValue(response, sessionID, value)
+}
+
+func (s *Server) newSession(body []byte) *Session {
+	var request struct {
+		DesiredCapabilities map[string]interface{}
+		Capabilities        struct {
+			AlwaysMatch map[string]interface{}
+		}
+	}
+	json.Unmarshal(body, &request)
+	capabilities := request.DesiredCapabilities
+	if capabilities == nil {
+		capabilities = request.Capabilities.AlwaysMatch
+	}
+	if capabilities == nil {
+		capabilities = map[string]interface{}{}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastID++
+	session := newSession(s, fmt.Sprintf("session-%d", s.lastID), capabilities)
+	s.sessions = append(s.sessions, session)
+	return session
+}
+
+func (s *Server) session(id string) *Session {
+	for _, session := range s.sessions {
+		if session.ID == id {
+			return session
+		}
+	}
+	return nil
+}
+
+func (s *Server) deleteSession(session *Session) {
+	for i, existing := range s.sessions {
+		if existing == session {
+			s.sessions = append(s.sessions[:i], s.sessions[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Server) page(url string) *dom.Node {
+	if url == "about:blank" {
+		return dom.Parse("")
+	}
+	html, ok := s.pages[url]
+	if !ok {
+		html, ok = s.pages[strip(url, "#")]
+	}
+	if !ok {
+		html, ok = s.pages[strip(url, "?")]
+	}
+	if !ok {
+		return dom.Parse(notFoundHTML)
+	}
+	return dom.Parse(html)
+}
+
+const notFoundHTML = `<html><head><title>Not Found</title></head><body><h1>Not Found</h1></body></html>`
+
+func newError(code, format string, arguments ...interface{}) *api.Error {
+	return &api.Error{Code: code, Message: fmt.Sprintf(format, arguments...)}
+}
+
+// status codes from https://www.w3.org/TR/webdriver/#errors
+var errorStatusCodes = map[string]int{
+	"element click intercepted": 400,
+	"element not interactable":  400,
+	"invalid argument":          400,
+	"invalid element state":     400,
+	"invalid selector":          400,
+	"no such alert":             404,
+	"no such element":           404,
+	"no such frame":             404,
+	"no such window":            404,
+	"stale element reference":   404,
+	"invalid session id":        404,
+	"unknown command":           404,
+}
+
+// legacy JSON Wire status codes for clients that do not read W3C error codes
+var legacyStatuses = map[string]int{
+	"no such element":          7,
+	"no such frame":            8,
+	"unknown command":          9,
+	"stale element reference":  10,
+	"element not interactable": 11,
+	"invalid element state":    12,
+	"unknown error":            13,
+	"javascript error":         17,
+	"invalid selector":         32,
+	"no such window":           23,
+	"unexpected alert open":    26,
+	"no such alert":            27,
+	"invalid session id":       6,
+}
+
+func writeValue(response http.ResponseWriter, sessionID string, value interface{}) {
+	response.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(response).Encode(map[string]interface{}{
+		"sessionId": sessionID,
+		"status":    0,
+		"value":     value,
+	})
+}
+
+func writeError(response http.ResponseWriter, sessionID string, err error) {
+	responseError, ok := err.(*api.Error)
+	if !ok {
+		responseError = &api.Error{Code: "unknown error", Message: err.Error()}
+	}
+	statusCode := responseError.StatusCode
+	if statusCode == 0 {
+		statusCode = errorStatusCodes[responseError.Code]
+	}
+	if statusCode == 0 {
+		statusCode = 500
+	}
+	status := legacyStatuses[responseError.Code]
+	if status == 0 {
+		status = 13
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(statusCode)
+	json.NewEncoder(response).Encode(map[string]interface{}{
+		"sessionId": sessionID,
+		"status":    status,
+		"value": map[string]string{
+			"error":      responseError.Code,
+			"message":    responseError.Message,
+			"stacktrace": "",
+		},
+	})
+}
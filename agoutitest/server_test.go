@@ -0,0 +1,190 @@
+package agoutitest_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/agouti"
+	. "github.com/sclevine/agouti/agoutitest"
+	"github.com/sclevine/agouti/api"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		server *Server
+		page   *agouti.Page
+	)
+
+	BeforeEach(func() {
+		server = NewServer()
+		server.Page("http://example.com/", `
+			<html>
+				<head><title>Example</title></head>
+				<body>
+					<h1 id="header">Welcome</h1>
+					<a href="/next">Next Page</a>
+					<a href="/other" target="_blank">New Window</a>
+					<p class="hidden" style="display: none">Hidden</p>
+					<form action="/submit" method="post">
+						<label for="name">Name</label>
+						<input id="name" name="name" value="old">
+						<label><input type="checkbox" name="agree"> Agree</label>
+						<select name="color"><option>Red</option><option value="b">Blue</option></select>
+						<input type="radio" name="size" value="s" checked><input type="radio" name="size" value="l">
+						<button name="action" value="save">Save</button>
+					</form>
+					<iframe name="inner" srcdoc="<p id='framed'>Framed</p>"></iframe>
+				</body>
+			</html>`)
+		server.Page("http://example.com/next", `<title>Next</title><p>Next</p>`)
+		server.Page("http://example.com/other", `<title>Other</title>`)
+
+		var err error
+		page, err = agouti.NewPage(server.URL())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(page.Navigate("http://example.com/")).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should create and destroy sessions", func() {
+		Expect(server.Sessions()).To(HaveLen(1))
+		Expect(server.Sessions()[0].Capabilities()).To(HaveKey("acceptSslCerts"))
+		Expect(page.Destroy()).To(Succeed())
+		Expect(server.Sessions()).To(BeEmpty())
+	})
+
+	It("should navigate between fixtures", func() {
+		Expect(page.Title()).To(Equal("Example"))
+		Expect(page.FindByLink("Next Page").Click()).To(Succeed())
+		Expect(page.URL()).To(Equal("http://example.com/next"))
+		Expect(page.Title()).To(Equal("Next"))
+		Expect(page.Back()).To(Succeed())
+		Expect(page.Title()).To(Equal("Example"))
+		Expect(page.Forward()).To(Succeed())
+		Expect(page.Title()).To(Equal("Next"))
+		Expect(page.Navigate("http://example.com/missing")).To(Succeed())
+		Expect(page.Title()).To(Equal("Not Found"))
+	})
+
+	It("should find elements and report their properties", func() {
+		Expect(page.Find("#header").Text()).To(Equal("Welcome"))
+		Expect(page.FindByXPath("//h1").Text()).To(Equal("Welcome"))
+		Expect(page.FindByID("name").Attribute("value")).To(Equal("old"))
+		Expect(page.FindByName("agree").Selected()).To(BeFalse())
+		Expect(page.FindByClass("hidden").Visible()).To(BeFalse())
+		Expect(page.FindByClass("hidden").CSS("display")).To(Equal("none"))
+		Expect(page.FindByLabel("Name").Count()).To(Equal(1))
+		Expect(page.FindByButton("Save").Count()).To(Equal(1))
+		Expect(page.All("a").Count()).To(Equal(2))
+		Expect(page.Find("form").All("input").Count()).To(Equal(4))
+	})
+
+	It("should return typed errors for missing elements", func() {
+		_, err := page.Session().GetElement(api.Selector{Using: "css selector", Value: "#missing"})
+		Expect(api.IsNoSuchElement(err)).To(BeTrue())
+		_, err = page.Find("p:hover").Text()
+		Expect(api.IsInvalidSelector(err)).To(BeTrue())
+	})
+
+	It("should fill and submit forms", func() {
+		Expect(page.FindByLabel("Name").Fill("Bob")).To(Succeed())
+		Expect(page.FindByLabel("Name").Attribute("value")).To(Equal("Bob"))
+		Expect(page.FindByName("agree").Check()).To(Succeed())
+		Expect(page.FindByName("agree").Selected()).To(BeTrue())
+		Expect(page.Find("select").Select("Blue")).To(Succeed())
+		Expect(page.Find(`input[value="l"]`).Click()).To(Succeed())
+		Expect(page.Find(`input[value="s"]`).Selected()).To(BeFalse())
+		Expect(page.FindByButton("Save").Click()).To(Succeed())
+
+		submissions := server.Sessions()[0].Submissions()
+		Expect(submissions).To(Equal([]Submission{{
+			URL:    "http://example.com/submit",
+			Method: "POST",
+			Values: url.Values{
+				"name":   {"Bob"},
+				"agree":  {"on"},
+				"color":  {"b"},
+				"size":   {"l"},
+				"action": {"save"},
+			},
+		}}))
+	})
+
+	It("should manage cookies", func() {
+		Expect(page.SetCookie(&http.Cookie{Name: "some", Value: "cookie"})).To(Succeed())
+		Expect(page.SetCookie(&http.Cookie{Name: "other", Value: "cookie"})).To(Succeed())
+		Expect(page.DeleteCookie("other")).To(Succeed())
+		cookies, err := page.GetCookies()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cookies).To(HaveLen(1))
+		Expect(cookies[0].Name).To(Equal("some"))
+		Expect(server.Sessions()[0].Cookies()[0].Value).To(Equal("cookie"))
+	})
+
+	It("should open and switch between windows", func() {
+		Expect(page.FindByLink("New Window").Click()).To(Succeed())
+		Expect(page.WindowCount()).To(Equal(2))
+		Expect(page.Title()).To(Equal("Example"))
+		Expect(page.NextWindow()).To(Succeed())
+		Expect(page.Title()).To(Equal("Other"))
+		Expect(page.CloseWindow()).To(Succeed())
+		Expect(page.WindowCount()).To(Equal(1))
+		_, err := page.Title()
+		Expect(api.IsNoSuchWindow(err)).To(BeTrue())
+	})
+
+	It("should switch into frames", func() {
+		Expect(page.Find("iframe").SwitchToFrame()).To(Succeed())
+		Expect(page.Find("#framed").Text()).To(Equal("Framed"))
+		Expect(page.SwitchToParentFrame()).To(Succeed())
+		Expect(page.All("#framed").Count()).To(Equal(0))
+	})
+
+	It("should script popups using click handlers", func() {
+		server.OnClick("h1", func(session *Session) {
+			session.OpenPopup("some alert")
+		})
+		Expect(page.Find("h1").Click()).To(Succeed())
+		Expect(page.PopupText()).To(Equal("some alert"))
+		_, err := page.Title()
+		Expect(err).To(MatchError(ContainSubstring("unexpected alert open")))
+		Expect(page.ConfirmPopup()).To(Succeed())
+		Expect(page.Title()).To(Equal("Example"))
+	})
+
+	It("should report stale elements after the document changes", func() {
+		elements, err := page.Find("h1").Elements()
+		Expect(err).NotTo(HaveOccurred())
+		server.Sessions()[0].SetHTML(`<h1>Replaced</h1>`)
+		_, err = elements[0].GetText()
+		Expect(api.IsStale(err)).To(BeTrue())
+		Expect(page.Find("h1").Text()).To(Equal("Replaced"))
+	})
+
+	It("should allow commands to be overridden", func() {
+		server.Handle("POST", "execute", func(session *Session, body []byte) (interface{}, error) {
+			return 42, nil
+		})
+		var result int
+		Expect(page.RunScript("return 42;", nil, &result)).To(Succeed())
+		Expect(result).To(Equal(42))
+
+		server.Handle("GET", "title", func(session *Session, body []byte) (interface{}, error) {
+			return nil, &api.Error{Code: "javascript error", Message: "some error"}
+		})
+		_, err := page.Title()
+		Expect(api.IsJavaScriptError(err)).To(BeTrue())
+
+		server.Handle("GET", "url", func(session *Session, body []byte) (interface{}, error) {
+			return nil, errors.New("some error")
+		})
+		_, err = page.URL()
+		Expect(err).To(MatchError("failed to retrieve URL: request unsuccessful: some error"))
+	})
+})
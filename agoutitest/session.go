@@ -0,0 +1,328 @@
+package agoutitest
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/sclevine/agouti/agoutitest/internal/dom"
+	"github.com/sclevine/agouti/api"
+)
+
+// A Session is a fake WebDriver session. Its methods may be used by handlers
+// to inspect or script the state of the session.
+type Session struct {
+	ID string
+
+	server       *Server
+	capabilities map[string]interface{}
+	windows      []*window
+	window       *window
+	lastWindow   int
+	cookies      []*api.Cookie
+	submissions  []Submission
+	logs         map[string][]api.Log
+	popup        *string
+	mouse        *dom.Node
+	elements     map[string]*dom.Node
+	elementIDs   map[*dom.Node]string
+	callbacks    []func()
+}
+
+// A Submission is a form submitted by a session.
+type Submission struct {
+	URL    string
+	Method string
+	Values url.Values
+}
+
+type window struct {
+	handle        string
+	name          string
+	history       []string
+	index         int
+	document      *dom.Node
+	frames        []*dom.Node
+	frameDocs     map[*dom.Node]*dom.Node
+	active        *dom.Node
+	width, height int
+}
+
+func newSession(server *Server, id string, capabilities map[string]interface{}) *Session {
+	session := &Session{
+		ID:           id,
+		server:       server,
+		capabilities: capabilities,
+		logs:         map[string][]api.Log{},
+		elements:     map[string]*dom.Node{},
+		elementIDs:   map[*dom.Node]string{},
+	}
+	session.openWindow("about:blank", "")
+	return session
+}
+
+// Capabilities returns the capabilities requested by the client.
+func (s *Session) Capabilities() map[string]interface{} {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	capabilities := map[string]interface{}{}
+	for key, value := range s.capabilities {
+		capabilities[key] = value
+	}
+	return capabilities
+}
+
+// URL returns the URL of the current window, or an empty string if the
+// current window is closed.
+func (s *Session) URL() string {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	if s.window == nil {
+		return ""
+	}
+	return s.window.url()
+}
+
+// HTML returns the serialized document of the current window.
+func (s *Session) HTML() string {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	if s.window == nil {
+		return ""
+	}
+	return s.window.document.HTML()
+}
+
+// Navigate loads the provided URL in the current window.
+func (s *Session) Navigate(url string) {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	if s.window != nil {
+		s.navigate(url)
+	}
+}
+
+// SetHTML replaces the document of the current window without changing its
+// URL. Elements from the previous document become stale.
+func (s *Session) SetHTML(html string) {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	if s.window != nil {
+		s.window.load(dom.Parse(html))
+	}
+}
+
+// OpenWindow opens the provided URL in a new window with the provided name.
+// The current window is not changed.
+func (s *Session) OpenWindow(url, name string) {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	s.openWindow(s.resolve(url), name)
+}
+
+// OpenPopup opens a JavaScript alert with the provided text. Most commands
+// fail with an "unexpected alert open" error until the popup is closed.
+func (s *Session) OpenPopup(text string) {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	s.popup = &text
+}
+
+// Popup returns the text of the open popup and whether a popup is open.
+func (s *Session) Popup() (string, bool) {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	if s.popup == nil {
+		return "", false
+	}
+	return *s.popup, true
+}
+
+// Cookies returns the cookies set in the session.
+func (s *Session) Cookies() []*api.Cookie {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	var cookies []*api.Cookie
+	for _, cookie := range s.cookies {
+		copied := *cookie
+		cookies = append(cookies, &copied)
+	}
+	return cookies
+}
+
+// Submissions returns the forms submitted in the session.
+func (s *Session) Submissions() []Submission {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	return append([]Submission(nil), s.submissions...)
+}
+
+// AddLog adds a log entry that is returned by the next request for logs of
+// the provided type (ex. "browser").
+func (s *Session) AddLog(logType string, log api.Log) {
+	s.server.mutex.Lock()
+	defer s.server.mutex.Unlock()
+	s.logs[logType] = append(s.logs[logType], log)
+}
+
+func (s *Session) openWindow(url, name string) *window {
+	s.lastWindow++
+	newWindow := &window{
+		handle: fmt.Sprintf("window-%d", s.lastWindow),
+		name:   name,
+		index:  -1,
+		width:  1024,
+		height: 768,
+	}
+	s.windows = append(s.windows, newWindow)
+	previous := s.window
+	s.window = newWindow
+	s.navigate(url)
+	s.window = previous
+	if s.window == nil {
+		s.window = newWindow
+	}
+	return newWindow
+}
+
+func (s *Session) closeWindow() {
+	for i, existing := range s.windows {
+		if existing == s.window {
+			s.windows = append(s.windows[:i], s.windows[i+1:]...)
+			break
+		}
+	}
+	s.window = nil
+}
+
+func (s *Session) findWindow(nameOrHandle string) *window {
+	for _, existing := range s.windows {
+		if existing.handle == nameOrHandle {
+			return existing
+		}
+	}
+	for _, existing := range s.windows {
+		if existing.name != "" && existing.name == nameOrHandle {
+			return existing
+		}
+	}
+	return nil
+}
+
+func (s *Session) resolve(rawURL string) string {
+	if s.window == nil {
+		return rawURL
+	}
+	base, err := url.Parse(s.window.url())
+	if err != nil || !base.IsAbs() || base.Scheme == "about" {
+		return rawURL
+	}
+	reference, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return base.ResolveReference(reference).String()
+}
+
+func (s *Session) navigate(rawURL string) {
+	url := s.resolve(rawURL)
+	current := s.window
+	current.history = append(current.history[:current.index+1], url)
+	current.index = len(current.history) - 1
+	s.reload()
+}
+
+func (s *Session) reload() {
+	document := s.server.page(s.window.url())
+	s.window.load(document)
+}
+
+func (w *window) url() string {
+	if w.index < 0 {
+		return "about:blank"
+	}
+	return w.history[w.index]
+}
+
+func (w *window) load(document *dom.Node) {
+	w.document = document
+	w.frames = nil
+	w.frameDocs = map[*dom.Node]*dom.Node{}
+	w.active = nil
+}
+
+// context returns the document of the selected frame.
+func (w *window) context() *dom.Node {
+	if len(w.frames) > 0 {
+		return w.frames[len(w.frames)-1]
+	}
+	return w.document
+}
+
+func (s *Session) frameDocument(frame *dom.Node) *dom.Node {
+	if document, ok := s.window.frameDocs[frame]; ok {
+		return document
+	}
+	var document *dom.Node
+	if srcdoc, ok := frame.Attr("srcdoc"); ok {
+		document = dom.Parse(srcdoc)
+	} else {
+		src, _ := frame.Attr("src")
+		if src == "" {
+			src = "about:blank"
+		}
+		document = s.server.page(s.resolve(src))
+	}
+	s.window.frameDocs[frame] = document
+	return document
+}
+
+func (s *Session) elementID(node *dom.Node) string {
+	if id, ok := s.elementIDs[node]; ok {
+		return id
+	}
+	id := fmt.Sprintf("element-%d", len(s.elements)+1)
+	s.elements[id] = node
+	s.elementIDs[node] = id
+	return id
+}
+
+func (s *Session) element(id string) (*dom.Node, error) {
+	node, ok := s.elements[id]
+	if !ok {
+		return nil, newError("no such element", "no element with ID %s", id)
+	}
+	if node.Root() != s.window.context() {
+		return nil, newError("stale element reference", "element %s is not attached to the page document", id)
+	}
+	return node, nil
+}
+
+func (s *Session) elementReference(node *dom.Node) map[string]string {
+	id := s.elementID(node)
+	return map[string]string{"ELEMENT": id, w3cElementKey: id}
+}
+
+const w3cElementKey = "element-6066-11e4-a52e-4f735466cecf"
+
+func isAlertCommand(endpoint string) bool {
+	switch endpoint {
+	case "alert_text", "accept_alert", "dismiss_alert", "alert/text", "alert/accept", "alert/dismiss",
+		"window_handle", "window_handles", "window", "window/handles":
+		return true
+	}
+	return false
+}
+
+func cookieNamed(cookies []*api.Cookie, name string) int {
+	for i, cookie := range cookies {
+		if cookie.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func strip(url, separator string) string {
+	return strings.SplitN(url, separator, 2)[0]
+}
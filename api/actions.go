@@ -0,0 +1,319 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions is a W3C action sequence composed of one or more input sources.
+// Each action added to an input source occupies one tick. Actions in the same
+// tick are dispatched together, so sources should be aligned using Tick
+// before actions that must happen simultaneously (such as chorded keys).
+type Actions struct {
+	sources []inputSource
+}
+
+type inputSource interface {
+	json.Marshaler
+	length() int
+	pad(ticks int)
+}
+
+func NewActions() *Actions {
+	return &Actions{}
+}
+
+// Tick pads each input source with pauses so that the next action added to
+// any source will be dispatched after all previously added actions.
+func (a *Actions) Tick() *Actions {
+	ticks := 0
+	for _, source := range a.sources {
+		if source.length() > ticks {
+			ticks = source.length()
+		}
+	}
+	for _, source := range a.sources {
+		source.pad(ticks)
+	}
+	return a
+}
+
+// Pointer returns the pointer input source with the provided ID, adding it
+// to the sequence if it is not already present.
+func (a *Actions) Pointer(id string, pointerType PointerType) *PointerSource {
+	for _, source := range a.sources {
+		if pointer, ok := source.(*PointerSource); ok && pointer.ID == id {
+			return pointer
+		}
+	}
+	pointer := &PointerSource{sourceActions: sourceActions{ID: id}, PointerType: pointerType}
+	a.sources = append(a.sources, pointer)
+	return pointer
+}
+
+// Key returns the key input source with the provided ID, adding it to the
+// sequence if it is not already present.
+func (a *Actions) Key(id string) *KeySource {
+	for _, source := range a.sources {
+		if key, ok := source.(*KeySource); ok && key.ID == id {
+			return key
+		}
+	}
+	key := &KeySource{sourceActions{ID: id}}
+	a.sources = append(a.sources, key)
+	return key
+}
+
+// Wheel returns the wheel input source with the provided ID, adding it to
+// the sequence if it is not already present.
+func (a *Actions) Wheel(id string) *WheelSource {
+	for _, source := range a.sources {
+		if wheel, ok := source.(*WheelSource); ok && wheel.ID == id {
+			return wheel
+		}
+	}
+	wheel := &WheelSource{sourceActions{ID: id}}
+	a.sources = append(a.sources, wheel)
+	return wheel
+}
+
+// None returns the null input source with the provided ID, adding it to the
+// sequence if it is not already present. Null sources may only pause.
+func (a *Actions) None(id string) *NoneSource {
+	for _, source := range a.sources {
+		if none, ok := source.(*NoneSource); ok && none.ID == id {
+			return none
+		}
+	}
+	none := &NoneSource{sourceActions{ID: id}}
+	a.sources = append(a.sources, none)
+	return none
+}
+
+func (a *Actions) MarshalJSON() ([]byte, error) {
+	sources := a.sources
+	if sources == nil {
+		sources = []inputSource{}
+	}
+	return json.Marshal(struct {
+		Actions []inputSource `json:"actions"`
+	}{sources})
+}
+
+type PointerType string
+
+const (
+	MousePointer PointerType = "mouse"
+	PenPointer   PointerType = "pen"
+	TouchPointer PointerType = "touch"
+)
+
+// An Origin specifies the position that pointer and wheel offsets are
+// relative to. An *Element origin refers to the center of the element.
+type Origin interface {
+	actionOrigin() interface{}
+}
+
+type namedOrigin string
+
+func (o namedOrigin) actionOrigin() interface{} {
+	return string(o)
+}
+
+var (
+	ViewportOrigin Origin = namedOrigin("viewport")
+	PointerOrigin  Origin = namedOrigin("pointer")
+)
+
+func (e *Element) actionOrigin() interface{} {
+	return e.w3cReference()
+}
+
+type action map[string]interface{}
+
+func pauseAction(duration time.Duration) action {
+	return action{"type": "pause", "duration": milliseconds(duration)}
+}
+
+func milliseconds(duration time.Duration) int64 {
+	return int64(duration / time.Millisecond)
+}
+
+type sourceActions struct {
+	ID      string
+	actions []action
+}
+
+func (s *sourceActions) length() int {
+	return len(s.actions)
+}
+
+func (s *sourceActions) pad(ticks int) {
+	for len(s.actions) < ticks {
+		s.actions = append(s.actions, pauseAction(0))
+	}
+}
+
+func (s *sourceActions) marshal(sourceType string, parameters interface{}) ([]byte, error) {
+	actions := s.actions
+	if actions == nil {
+		actions = []action{}
+	}
+	return json.Marshal(struct {
+		Type       string      `json:"type"`
+		ID         string      `json:"id"`
+		Parameters interface{} `json:"parameters,omitempty"`
+		Actions    []action    `json:"actions"`
+	}{sourceType, s.ID, parameters, actions})
+}
+
+// A PointerSource is a mouse, pen, or touch input source.
+type PointerSource struct {
+	sourceActions
+	PointerType PointerType
+}
+
+func (p *PointerSource) Pause(duration time.Duration) *PointerSource {
+	p.actions = append(p.actions, pauseAction(duration))
+	return p
+}
+
+// Move moves the pointer to the offset from the origin over the provided duration.
+func (p *PointerSource) Move(origin Origin, x, y int, duration time.Duration) *PointerSource {
+	if origin == nil {
+		origin = ViewportOrigin
+	}
+	p.actions = append(p.actions, action{
+		"type":     "pointerMove",
+		"origin":   origin.actionOrigin(),
+		"x":        x,
+		"y":        y,
+		"duration": milliseconds(duration),
+	})
+	return p
+}
+
+func (p *PointerSource) Down(button Button) *PointerSource {
+	p.actions = append(p.actions, action{"type": "pointerDown", "button": button})
+	return p
+}
+
+func (p *PointerSource) Up(button Button) *PointerSource {
+	p.actions = append(p.actions, action{"type": "pointerUp", "button": button})
+	return p
+}
+
+func (p *PointerSource) MarshalJSON() ([]byte, error) {
+	parameters := struct {
+		PointerType PointerType `json:"pointerType"`
+	}{p.PointerType}
+	return p.marshal("pointer", parameters)
+}
+
+// A KeySource is a keyboard input source. Keys may be any single character
+// or one of the special key constants (ex. ShiftKey).
+type KeySource struct {
+	sourceActions
+}
+
+func (k *KeySource) Pause(duration time.Duration) *KeySource {
+	k.actions = append(k.actions, pauseAction(duration))
+	return k
+}
+
+func (k *KeySource) Down(key string) *KeySource {
+	k.actions = append(k.actions, action{"type": "keyDown", "value": key})
+	return k
+}
+
+func (k *KeySource) Up(key string) *KeySource {
+	k.actions = append(k.actions, action{"type": "keyUp", "value": key})
+	return k
+}
+
+// Type presses and releases each character of the provided text.
+func (k *KeySource) Type(text string) *KeySource {
+	for _, key := range text {
+		k.Down(string(key)).Up(string(key))
+	}
+	return k
+}
+
+func (k *KeySource) MarshalJSON() ([]byte, error) {
+	return k.marshal("key", nil)
+}
+
+// A WheelSource is a scroll wheel input source.
+type WheelSource struct {
+	sourceActions
+}
+
+func (w *WheelSource) Pause(duration time.Duration) *WheelSource {
+	w.actions = append(w.actions, pauseAction(duration))
+	return w
+}
+
+// Scroll scrolls by the provided deltas with the wheel positioned at the offset
+// from the origin. Only ViewportOrigin and *Element origins are valid.
+func (w *WheelSource) Scroll(origin Origin, x, y, deltaX, deltaY int, duration time.Duration) *WheelSource {
+	if origin == nil {
+		origin = ViewportOrigin
+	}
+	w.actions = append(w.actions, action{
+		"type":     "scroll",
+		"origin":   origin.actionOrigin(),
+		"x":        x,
+		"y":        y,
+		"deltaX":   deltaX,
+		"deltaY":   deltaY,
+		"duration": milliseconds(duration),
+	})
+	return w
+}
+
+func (w *WheelSource) MarshalJSON() ([]byte, error) {
+	return w.marshal("wheel", nil)
+}
+
+// A NoneSource is a null input source that may only be used to pause.
+type NoneSource struct {
+	sourceActions
+}
+
+func (n *NoneSource) Pause(duration time.Duration) *NoneSource {
+	n.actions = append(n.actions, pauseAction(duration))
+	return n
+}
+
+func (n *NoneSource) MarshalJSON() ([]byte, error) {
+	return n.marshal("none", nil)
+}
+
+// Special key values for use with a KeySource
+const (
+	NullKey       = "\ue000"
+	CancelKey     = "\ue001"
+	HelpKey       = "\ue002"
+	BackspaceKey  = "\ue003"
+	TabKey        = "\ue004"
+	ClearKey      = "\ue005"
+	ReturnKey     = "\ue006"
+	EnterKey      = "\ue007"
+	ShiftKey      = "\ue008"
+	ControlKey    = "\ue009"
+	AltKey        = "\ue00a"
+	PauseKey      = "\ue00b"
+	EscapeKey     = "\ue00c"
+	SpaceKey      = "\ue00d"
+	PageUpKey     = "\ue00e"
+	PageDownKey   = "\ue00f"
+	EndKey        = "\ue010"
+	HomeKey       = "\ue011"
+	LeftArrowKey  = "\ue012"
+	UpArrowKey    = "\ue013"
+	RightArrowKey = "\ue014"
+	DownArrowKey  = "\ue015"
+	InsertKey     = "\ue016"
+	DeleteKey     = "\ue017"
+	MetaKey       = "\ue03d"
+)
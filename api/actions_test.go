@@ -0,0 +1,149 @@
+package api_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/api"
+)
+
+var _ = Describe("Actions", func() {
+	var actions *Actions
+
+	BeforeEach(func() {
+		actions = NewActions()
+	})
+
+	actionsJSON := func() string {
+		actionsJSON, err := json.Marshal(actions)
+		Expect(err).NotTo(HaveOccurred())
+		return string(actionsJSON)
+	}
+
+	It("should encode an empty sequence", func() {
+		Expect(actionsJSON()).To(MatchJSON(`{"actions": []}`))
+	})
+
+	Describe("#Pointer", func() {
+		It("should encode pointer moves, presses, and pauses", func() {
+			actions.Pointer("mouse", MousePointer).
+				Move(ViewportOrigin, 10, 20, 100*time.Millisecond).
+				Down(LeftButton).
+				Pause(time.Second).
+				Move(PointerOrigin, 5, 0, 0).
+				Up(LeftButton)
+			Expect(actionsJSON()).To(MatchJSON(`{"actions": [{
+				"type": "pointer",
+				"id": "mouse",
+				"parameters": {"pointerType": "mouse"},
+				"actions": [
+					{"type": "pointerMove", "origin": "viewport", "x": 10, "y": 20, "duration": 100},
+					{"type": "pointerDown", "button": 0},
+					{"type": "pause", "duration": 1000},
+					{"type": "pointerMove", "origin": "pointer", "x": 5, "y": 0, "duration": 0},
+					{"type": "pointerUp", "button": 0}
+				]
+			}]}`))
+		})
+
+		It("should encode element origins as W3C element references", func() {
+			actions.Pointer("finger", TouchPointer).Move(&Element{ID: "some\u002did"}, 1, 2, 0)
+			Expect(actionsJSON()).To(MatchJSON(`{"actions": [{
+				"type": "pointer",
+				"id": "finger",
+				"parameters": {"pointerType": "touch"},
+				"actions": [
+					{"type": "pointerMove", "origin": {"element\u002d6066\u002d11e4\u002da52e\u002d4f735466cecf": "some\u002did"}, "x": 1, "y": 2, "duration": 0}
+				]
+			}]}`))
+		})
+
+		It("should default to the viewport origin", func() {
+			actions.Pointer("pen", PenPointer).Move(nil, 1, 2, 0)
+			Expect(actionsJSON()).To(ContainSubstring(`"origin":"viewport"`))
+		})
+
+		It("should return the existing source when the ID is already present", func() {
+			mouse := actions.Pointer("mouse", MousePointer)
+			Expect(actions.Pointer("mouse", MousePointer)).To(BeIdenticalTo(mouse))
+		})
+	})
+
+	Describe("#Key", func() {
+		It("should encode key presses and typed text", func() {
+			actions.Key("keyboard").Down(ShiftKey).Type("ab").Up(ShiftKey)
+			Expect(actionsJSON()).To(MatchJSON(`{"actions": [{
+				"type": "key",
+				"id": "keyboard",
+				"actions": [
+					{"type": "keyDown", "value": "\ue008"},
+					{"type": "keyDown", "value": "a"},
+					{"type": "keyUp", "value": "a"},
+					{"type": "keyDown", "value": "b"},
+					{"type": "keyUp", "value": "b"},
+					{"type": "keyUp", "value": "\ue008"}
+				]
+			}]}`))
+		})
+	})
+
+	Describe("#Wheel", func() {
+		It("should encode scrolls", func() {
+			actions.Wheel("wheel").Scroll(ViewportOrigin, 10, 20, 0, 300, 50*time.Millisecond)
+			Expect(actionsJSON()).To(MatchJSON(`{"actions": [{
+				"type": "wheel",
+				"id": "wheel",
+				"actions": [
+					{"type": "scroll", "origin": "viewport", "x": 10, "y": 20, "deltaX": 0, "deltaY": 300, "duration": 50}
+				]
+			}]}`))
+		})
+	})
+
+	Describe("#None", func() {
+		It("should encode pauses", func() {
+			actions.None("none").Pause(10 * time.Millisecond)
+			Expect(actionsJSON()).To(MatchJSON(`{"actions": [{
+				"type": "none",
+				"id": "none",
+				"actions": [{"type": "pause", "duration": 10}]
+			}]}`))
+		})
+	})
+
+	Describe("#Tick", func() {
+		It("should pad each source with pauses to align the next actions", func() {
+			keyboard := actions.Key("keyboard")
+			mouse := actions.Pointer("mouse", MousePointer)
+			keyboard.Down(ControlKey)
+			actions.Tick()
+			mouse.Down(LeftButton).Up(LeftButton)
+			actions.Tick()
+			keyboard.Up(ControlKey)
+			Expect(actionsJSON()).To(MatchJSON(`{"actions": [
+				{
+					"type": "key",
+					"id": "keyboard",
+					"actions": [
+						{"type": "keyDown", "value": "\ue009"},
+						{"type": "pause", "duration": 0},
+						{"type": "pause", "duration": 0},
+						{"type": "keyUp", "value": "\ue009"}
+					]
+				},
+				{
+					"type": "pointer",
+					"id": "mouse",
+					"parameters": {"pointerType": "mouse"},
+					"actions": [
+						{"type": "pause", "duration": 0},
+						{"type": "pointerDown", "button": 0},
+						{"type": "pointerUp", "button": 0}
+					]
+				}
+			]}`))
+		})
+	})
+})
@@ -2,6 +2,10 @@
 // All methods map directly to endpoints of the WebDriver Wire Protocol:
 // https://code.google.com/p/selenium/wiki/JsonWireProtocol
 //
+// Sessions opened against drivers that respond using the W3C WebDriver
+// protocol (https://www.w3.org/TR/webdriver/) send the equivalent W3C
+// requests instead. See Session.W3C.
+//
 // This package was previously internal to the agouti package. It currently
 // does not have a fixed API, but this will change in the near future
 // (with the addition of adequate documentation).
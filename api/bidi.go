@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sclevine/agouti/internal/rpc"
+	"github.com/sclevine/agouti/internal/websocket"
+)
+
+// A BiDi is a WebDriver BiDi connection (https://w3c.github.io/webdriver-bidi/)
+// to the browser of a session. BiDi connections are available for sessions
+// that are opened with the "webSocketUrl" capability set to true. A BiDi is
+// safe for concurrent use.
+type BiDi struct {
+	client *rpc.Client
+
+	mutex  sync.Mutex
+	events map[string]int
+}
+
+// A BiDiEvent is a WebDriver BiDi event, such as "log.entryAdded".
+// Its Decode method decodes the event parameters into the provided value.
+type BiDiEvent = rpc.Event
+
+// BiDiURL returns the WebDriver BiDi websocket URL of the session, or an
+// empty string if the session was not opened with the "webSocketUrl"
+// capability.
+func (s *Session) BiDiURL() string {
+	url, _ := s.Capabilities["webSocketUrl"].(string)
+	return url
+}
+
+// OpenBiDi opens a WebDriver BiDi connection to the browser of the session.
+// The connection must be closed when it is no longer needed.
+func (s *Session) OpenBiDi(ctx context.Context) (*BiDi, error) {
+	url := s.BiDiURL()
+	if url == "" {
+		return nil, errors.New("BiDi is not enabled for this session, see the webSocketUrl capability")
+	}
+	conn, err := websocket.Dial(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return &BiDi{client: rpc.New(conn), events: map[string]int{}}, nil
+}
+
+// Execute sends a BiDi command with the provided parameters and waits for the
+// browser to respond. If result is not nil, the command result is decoded
+// into it. Execute returns an *Error if the command fails.
+func (b *BiDi) Execute(ctx context.Context, method string, params, result interface{}) error {
+	responseJSON, err := b.client.Call(ctx, method, params)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Type       string          `json:"type"`
+		Result     json.RawMessage `json:"result"`
+		Error      string          `json:"error"`
+		Message    string          `json:"message"`
+		Stacktrace string          `json:"stacktrace"`
+	}
+	if err := json.Unmarshal(responseJSON, &response); err != nil {
+		return fmt.Errorf("unexpected response: %s", responseJSON)
+	}
+	if response.Type == "error" {
+		return &Error{Code: response.Error, Message: response.Message, Stacktrace: response.Stacktrace}
+	}
+	if result != nil {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return fmt.Errorf("unexpected response: %s", response.Result)
+		}
+	}
+	return nil
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (b *BiDi) Done() <-chan struct{} {
+	return b.client.Done()
+}
+
+// Close closes the connection. Subscriptions stop receiving events.
+func (b *BiDi) Close() error {
+	return b.client.Close()
+}
+
+// A BiDiSubscription receives BiDi events. Events are buffered until they are
+// received, so that slow consumers never block the connection.
+type BiDiSubscription struct {
+	bidi         *BiDi
+	id           string
+	events       []string
+	subscription *rpc.Subscription
+	once         sync.Once
+	done         chan struct{}
+}
+
+// Subscribe subscribes to the provided events (ex. "log.entryAdded") or
+// modules (ex. "network") for all browsing contexts.
+func (b *BiDi) Subscribe(ctx context.Context, events ...string) (*BiDiSubscription, error) {
+	if len(events) == 0 {
+		return nil, errors.New("no events provided")
+	}
+
+	subscription := b.client.Subscribe(events...)
+	request := struct {
+		Events []string `json:"events"`
+	}{events}
+	var result struct {
+		Subscription string `json:"subscription"`
+	}
+	if err := b.Execute(ctx, "session.subscribe", request, &result); err != nil {
+		subscription.Close()
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	for _, event := range events {
+		b.events[event]++
+	}
+	b.mutex.Unlock()
+
+	return &BiDiSubscription{
+		bidi:         b,
+		id:           result.Subscription,
+		events:       events,
+		subscription: subscription,
+		done:         make(chan struct{}),
+	}, nil
+}
+
+// Events returns a channel of events in the order that they were received.
+// The channel is closed after the subscription or the connection is closed.
+func (s *BiDiSubscription) Events() <-chan BiDiEvent {
+	return s.subscription.Events()
+}
+
+// Close unsubscribes from the events of the subscription. Events that have
+// not been received are discarded.
+func (s *BiDiSubscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+		s.subscription.Close()
+		err = s.bidi.unsubscribe(s)
+	})
+	return err
+}
+
+func (b *BiDi) unsubscribe(subscription *BiDiSubscription) error {
+	b.mutex.Lock()
+	var unused []string
+	for _, event := range subscription.events {
+		b.events[event]--
+		if b.events[event] == 0 {
+			delete(b.events, event)
+			unused = append(unused, event)
+		}
+	}
+	b.mutex.Unlock()
+
+	select {
+	case <-b.Done():
+		return nil
+	default:
+	}
+
+	// drivers that identify subscriptions may unsubscribe them individually
+	var request interface{}
+	if subscription.id != "" {
+		request = struct {
+			Subscriptions []string `json:"subscriptions"`
+		}{[]string{subscription.id}}
+	} else if len(unused) > 0 {
+		request = struct {
+			Events []string `json:"events"`
+		}{unused}
+	} else {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return b.Execute(ctx, "session.unsubscribe", request, nil)
+}
+
+// forward calls handle for each event until the subscription is closed, and
+// then calls finish.
+func (s *BiDiSubscription) forward(handle func(event BiDiEvent), finish func()) {
+	defer finish()
+	for {
+		select {
+		case event, ok := <-s.Events():
+			if !ok {
+				return
+			}
+			handle(event)
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"time"
+)
+
+// A BiDiSource identifies the browsing context that produced an event.
+type BiDiSource struct {
+	Realm   string `json:"realm"`
+	Context string `json:"context"`
+}
+
+// A LogEntry is a console message or JavaScript error reported by the
+// "log.entryAdded" event.
+type LogEntry struct {
+	// Type is "console" or "javascript".
+	Type string `json:"type"`
+
+	// Level is "debug", "info", "warn", or "error".
+	Level string `json:"level"`
+
+	Text      string     `json:"text"`
+	Timestamp int64      `json:"timestamp"`
+	Source    BiDiSource `json:"source"`
+
+	// Method is the console method (ex. "log") for console entries.
+	Method string `json:"method,omitempty"`
+}
+
+// Time returns the time that the entry was logged.
+func (e LogEntry) Time() time.Time {
+	return bidiTime(e.Timestamp)
+}
+
+// A BrowsingContextEvent is a "browsingContext" module event, such as
+// "browsingContext.load" or "browsingContext.userPromptOpened".
+type BrowsingContextEvent struct {
+	Method     string `json:"-"`
+	Context    string `json:"context"`
+	Parent     string `json:"parent,omitempty"`
+	URL        string `json:"url"`
+	Navigation string `json:"navigation,omitempty"`
+	Timestamp  int64  `json:"timestamp,omitempty"`
+
+	// Type and Message describe user prompts (ex. "alert").
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Time returns the time of the event, or the zero time if the event has no timestamp.
+func (e BrowsingContextEvent) Time() time.Time {
+	if e.Timestamp == 0 {
+		return time.Time{}
+	}
+	return bidiTime(e.Timestamp)
+}
+
+// A NetworkEvent is a "network" module event, such as
+// "network.beforeRequestSent" or "network.responseCompleted".
+type NetworkEvent struct {
+	Method        string           `json:"-"`
+	Context       string           `json:"context"`
+	Navigation    string           `json:"navigation,omitempty"`
+	RedirectCount int              `json:"redirectCount"`
+	Timestamp     int64            `json:"timestamp"`
+	Request       NetworkRequest   `json:"request"`
+	Response      *NetworkResponse `json:"response,omitempty"`
+
+	// ErrorText describes the failure for "network.fetchError" events.
+	ErrorText string `json:"errorText,omitempty"`
+}
+
+// Time returns the time of the event.
+func (e NetworkEvent) Time() time.Time {
+	return bidiTime(e.Timestamp)
+}
+
+type NetworkRequest struct {
+	ID      string          `json:"request"`
+	URL     string          `json:"url"`
+	Method  string          `json:"method"`
+	Headers []NetworkHeader `json:"headers"`
+}
+
+type NetworkResponse struct {
+	URL           string          `json:"url"`
+	Protocol      string          `json:"protocol"`
+	Status        int             `json:"status"`
+	StatusText    string          `json:"statusText"`
+	FromCache     bool            `json:"fromCache"`
+	MimeType      string          `json:"mimeType"`
+	Headers       []NetworkHeader `json:"headers"`
+	BytesReceived int64           `json:"bytesReceived"`
+}
+
+type NetworkHeader struct {
+	Name  string `json:"name"`
+	Value struct {
+		// Type is "string" or "base64".
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"value"`
+}
+
+// A LogSubscription receives log entries from a BiDi connection.
+type LogSubscription struct {
+	subscription *BiDiSubscription
+	entries      chan LogEntry
+}
+
+// SubscribeLogs subscribes to console messages and JavaScript errors.
+func (b *BiDi) SubscribeLogs(ctx context.Context) (*LogSubscription, error) {
+	subscription, err := b.Subscribe(ctx, "log.entryAdded")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(chan LogEntry)
+	go subscription.forward(func(event BiDiEvent) {
+		var entry LogEntry
+		if event.Decode(&entry) != nil {
+			return
+		}
+		select {
+		case entries <- entry:
+		case <-subscription.done:
+		}
+	}, func() { close(entries) })
+
+	return &LogSubscription{subscription, entries}, nil
+}
+
+// Entries returns a channel of log entries in the order that they were logged.
+// The channel is closed after the subscription or the connection is closed.
+func (s *LogSubscription) Entries() <-chan LogEntry {
+	return s.entries
+}
+
+// Close unsubscribes from log entries.
+func (s *LogSubscription) Close() error {
+	return s.subscription.Close()
+}
+
+// A BrowsingContextSubscription receives browsing context events from a BiDi connection.
+type BrowsingContextSubscription struct {
+	subscription *BiDiSubscription
+	events       chan BrowsingContextEvent
+}
+
+// SubscribeBrowsingContext subscribes to the provided browsing context events
+// (ex. "browsingContext.load"), or all browsing context events if none are provided.
+func (b *BiDi) SubscribeBrowsingContext(ctx context.Context, events ...string) (*BrowsingContextSubscription, error) {
+	if len(events) == 0 {
+		events = []string{"browsingContext"}
+	}
+	subscription, err := b.Subscribe(ctx, events...)
+	if err != nil {
+		return nil, err
+	}
+
+	contextEvents := make(chan BrowsingContextEvent)
+	go subscription.forward(func(event BiDiEvent) {
+		contextEvent := BrowsingContextEvent{Method: event.Method}
+		if event.Decode(&contextEvent) != nil {
+			return
+		}
+		select {
+		case contextEvents <- contextEvent:
+		case <-subscription.done:
+		}
+	}, func() { close(contextEvents) })
+
+	return &BrowsingContextSubscription{subscription, contextEvents}, nil
+}
+
+// Events returns a channel of events in the order that they occurred.
+// The channel is closed after the subscription or the connection is closed.
+func (s *BrowsingContextSubscription) Events() <-chan BrowsingContextEvent {
+	return s.events
+}
+
+// Close unsubscribes from the browsing context events.
+func (s *BrowsingContextSubscription) Close() error {
+	return s.subscription.Close()
+}
+
+// A NetworkSubscription receives network events from a BiDi connection.
+type NetworkSubscription struct {
+	subscription *BiDiSubscription
+	events       chan NetworkEvent
+}
+
+// SubscribeNetwork subscribes to the provided network events
+// (ex. "network.responseCompleted"), or all network events if none are provided.
+func (b *BiDi) SubscribeNetwork(ctx context.Context, events ...string) (*NetworkSubscription, error) {
+	if len(events) == 0 {
+		events = []string{"network"}
+	}
+	subscription, err := b.Subscribe(ctx, events...)
+	if err != nil {
+		return nil, err
+	}
+
+	networkEvents := make(chan NetworkEvent)
+	go subscription.forward(func(event BiDiEvent) {
+		networkEvent := NetworkEvent{Method: event.Method}
+		if event.Decode(&networkEvent) != nil {
+			return
+		}
+		select {
+		case networkEvents <- networkEvent:
+		case <-subscription.done:
+		}
+	}, func() { close(networkEvents) })
+
+	return &NetworkSubscription{subscription, networkEvents}, nil
+}
+
+// Events returns a channel of events in the order that they occurred.
+// The channel is closed after the subscription or the connection is closed.
+func (s *NetworkSubscription) Events() <-chan NetworkEvent {
+	return s.events
+}
+
+// Close unsubscribes from the network events.
+func (s *NetworkSubscription) Close() error {
+	return s.subscription.Close()
+}
+
+func bidiTime(timestamp int64) time.Time {
+	return time.Unix(0, timestamp*int64(time.Millisecond))
+}
@@ -0,0 +1,322 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/internal/websocket"
+)
+
+type bidiCommand struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// bidiServer emulates the WebDriver BiDi websocket of a browser
+type bidiServer struct {
+	*httptest.Server
+	mutex           sync.Mutex
+	commands        []bidiCommand
+	subscriptionIDs bool
+}
+
+func newBiDiServer() *bidiServer {
+	server := &bidiServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serve))
+	return server
+}
+
+func (s *bidiServer) URL() string {
+	return "ws" + strings.TrimPrefix(s.Server.URL, "http") + "/session/some-id"
+}
+
+func (s *bidiServer) Commands(method string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var params []string
+	for _, command := range s.commands {
+		if command.Method == method {
+			params = append(params, string(command.Params))
+		}
+	}
+	return params
+}
+
+func (s *bidiServer) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var command bidiCommand
+		json.Unmarshal(message, &command)
+
+		s.mutex.Lock()
+		s.commands = append(s.commands, command)
+		subscriptionIDs := s.subscriptionIDs
+		s.mutex.Unlock()
+
+		response := map[string]interface{}{"type": "success", "id": command.ID, "result": map[string]string{}}
+		switch command.Method {
+		case "session.subscribe":
+			if subscriptionIDs {
+				response["result"] = map[string]string{"subscription": "some-subscription"}
+			}
+		case "session.unsubscribe":
+		case "test.echo":
+			response["result"] = command.Params
+		case "test.emit":
+			var events []json.RawMessage
+			json.Unmarshal(command.Params, &events)
+			for _, event := range events {
+				conn.WriteMessage(event)
+			}
+		case "test.close":
+			return
+		default:
+			response = map[string]interface{}{
+				"type":       "error",
+				"id":         command.ID,
+				"error":      "unknown command",
+				"message":    "some message",
+				"stacktrace": "some stacktrace",
+			}
+		}
+		data, _ := json.Marshal(response)
+		conn.WriteMessage(data)
+	}
+}
+
+var _ = Describe("BiDi", func() {
+	var (
+		server  *bidiServer
+		session *Session
+		bidi    *BiDi
+		ctx     context.Context
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		server = newBiDiServer()
+		session = &Session{Capabilities: map[string]interface{}{"webSocketUrl": server.URL()}}
+		var err error
+		bidi, err = session.OpenBiDi(ctx)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		bidi.Close()
+		server.Close()
+	})
+
+	emit := func(events ...string) {
+		Expect(bidi.Execute(ctx, "test.emit", json.RawMessage("["+strings.Join(events, ",")+"]"), nil)).To(Succeed())
+	}
+
+	Describe("Session#BiDiURL", func() {
+		It("should return the webSocketUrl capability", func() {
+			Expect(session.BiDiURL()).To(Equal(server.URL()))
+			Expect((&Session{}).BiDiURL()).To(BeEmpty())
+		})
+	})
+
+	Describe("Session#OpenBiDi", func() {
+		Context("when the session was not opened with a webSocketUrl", func() {
+			It("should return an error", func() {
+				_, err := (&Session{Capabilities: map[string]interface{}{"webSocketUrl": true}}).OpenBiDi(ctx)
+				Expect(err).To(MatchError("BiDi is not enabled for this session, see the webSocketUrl capability"))
+			})
+		})
+	})
+
+	Describe("#Execute", func() {
+		It("should send the command and decode the result", func() {
+			var result map[string]string
+			Expect(bidi.Execute(ctx, "test.echo", map[string]string{"some": "value"}, &result)).To(Succeed())
+			Expect(result).To(Equal(map[string]string{"some": "value"}))
+		})
+
+		Context("when the command fails", func() {
+			It("should return an Error", func() {
+				err := bidi.Execute(ctx, "some.command", nil, nil)
+				Expect(err).To(Equal(&Error{Code: "unknown command", Message: "some message", Stacktrace: "some stacktrace"}))
+				Expect(IsUnsupported(err)).To(BeTrue())
+			})
+		})
+
+		Context("when the connection is closed", func() {
+			It("should return an error", func() {
+				bidi.Execute(ctx, "test.close", nil, nil)
+				Eventually(bidi.Done()).Should(BeClosed())
+				Expect(bidi.Execute(ctx, "test.echo", nil, nil)).To(MatchError("connection closed"))
+			})
+		})
+	})
+
+	Describe("#Subscribe", func() {
+		It("should subscribe to the events and receive them", func() {
+			subscription, err := bidi.Subscribe(ctx, "script.message", "browsingContext")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(server.Commands("session.subscribe")).To(ConsistOf(MatchJSON(`{"events": ["script.message", "browsingContext"]}`)))
+
+			emit(
+				`{"type": "event", "method": "script.message", "params": {"channel": "some-channel"}}`,
+				`{"type": "event", "method": "log.entryAdded", "params": {}}`,
+				`{"type": "event", "method": "browsingContext.load", "params": {}}`,
+			)
+			var event BiDiEvent
+			Eventually(subscription.Events()).Should(Receive(&event))
+			Expect(event.Method).To(Equal("script.message"))
+			var params struct{ Channel string }
+			Expect(event.Decode(&params)).To(Succeed())
+			Expect(params.Channel).To(Equal("some-channel"))
+			Eventually(subscription.Events()).Should(Receive(&event))
+			Expect(event.Method).To(Equal("browsingContext.load"))
+		})
+
+		Context("when no events are provided", func() {
+			It("should return an error", func() {
+				_, err := bidi.Subscribe(ctx)
+				Expect(err).To(MatchError("no events provided"))
+			})
+		})
+	})
+
+	Describe("BiDiSubscription#Close", func() {
+		It("should unsubscribe from events that are not used by other subscriptions", func() {
+			first, err := bidi.Subscribe(ctx, "log.entryAdded", "network")
+			Expect(err).NotTo(HaveOccurred())
+			second, err := bidi.Subscribe(ctx, "log.entryAdded")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(first.Close()).To(Succeed())
+			Expect(first.Close()).To(Succeed())
+			Expect(server.Commands("session.unsubscribe")).To(ConsistOf(MatchJSON(`{"events": ["network"]}`)))
+			Eventually(first.Events()).Should(BeClosed())
+
+			Expect(second.Close()).To(Succeed())
+			Expect(server.Commands("session.unsubscribe")).To(HaveLen(2))
+			Expect(server.Commands("session.unsubscribe")[1]).To(MatchJSON(`{"events": ["log.entryAdded"]}`))
+		})
+
+		It("should unsubscribe by ID when the driver identifies subscriptions", func() {
+			server.subscriptionIDs = true
+			subscription, err := bidi.Subscribe(ctx, "log.entryAdded")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subscription.Close()).To(Succeed())
+			Expect(server.Commands("session.unsubscribe")).To(ConsistOf(MatchJSON(`{"subscriptions": ["some-subscription"]}`)))
+		})
+	})
+
+	Describe("#SubscribeLogs", func() {
+		It("should receive log entries", func() {
+			subscription, err := bidi.SubscribeLogs(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			defer subscription.Close()
+			Expect(server.Commands("session.subscribe")).To(ConsistOf(MatchJSON(`{"events": ["log.entryAdded"]}`)))
+
+			emit(`{"type": "event", "method": "log.entryAdded", "params": {
+				"type": "console", "method": "error", "level": "error", "text": "some error",
+				"timestamp": 1500000000000, "source": {"realm": "some-realm", "context": "some-context"}
+			}}`)
+
+			var entry LogEntry
+			Eventually(subscription.Entries()).Should(Receive(&entry))
+			Expect(entry).To(Equal(LogEntry{
+				Type:      "console",
+				Level:     "error",
+				Text:      "some error",
+				Timestamp: 1500000000000,
+				Source:    BiDiSource{Realm: "some-realm", Context: "some-context"},
+				Method:    "error",
+			}))
+			Expect(entry.Time()).To(BeTemporally("==", time.Unix(1500000000, 0)))
+		})
+
+		It("should close the entries channel when the subscription is closed", func() {
+			subscription, err := bidi.SubscribeLogs(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subscription.Close()).To(Succeed())
+			Eventually(subscription.Entries()).Should(BeClosed())
+		})
+
+		Context("when the subscription fails", func() {
+			It("should return an error", func() {
+				bidi.Execute(ctx, "test.close", nil, nil)
+				Eventually(bidi.Done()).Should(BeClosed())
+				_, err := bidi.SubscribeLogs(ctx)
+				Expect(err).To(MatchError("connection closed"))
+			})
+		})
+	})
+
+	Describe("#SubscribeBrowsingContext", func() {
+		It("should receive browsing context events", func() {
+			subscription, err := bidi.SubscribeBrowsingContext(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			defer subscription.Close()
+			Expect(server.Commands("session.subscribe")).To(ConsistOf(MatchJSON(`{"events": ["browsingContext"]}`)))
+
+			emit(`{"type": "event", "method": "browsingContext.userPromptOpened", "params": {
+				"context": "some-context", "type": "alert", "message": "some message"
+			}}`)
+
+			var event BrowsingContextEvent
+			Eventually(subscription.Events()).Should(Receive(&event))
+			Expect(event).To(Equal(BrowsingContextEvent{
+				Method:  "browsingContext.userPromptOpened",
+				Context: "some-context",
+				Type:    "alert",
+				Message: "some message",
+			}))
+			Expect(event.Time().IsZero()).To(BeTrue())
+		})
+
+		It("should subscribe to the provided events", func() {
+			subscription, err := bidi.SubscribeBrowsingContext(ctx, "browsingContext.load")
+			Expect(err).NotTo(HaveOccurred())
+			defer subscription.Close()
+			Expect(server.Commands("session.subscribe")).To(ConsistOf(MatchJSON(`{"events": ["browsingContext.load"]}`)))
+		})
+	})
+
+	Describe("#SubscribeNetwork", func() {
+		It("should receive network events", func() {
+			subscription, err := bidi.SubscribeNetwork(ctx, "network.responseCompleted")
+			Expect(err).NotTo(HaveOccurred())
+			defer subscription.Close()
+
+			emit(`{"type": "event", "method": "network.responseCompleted", "params": {
+				"context": "some-context", "navigation": "some-navigation", "redirectCount": 0, "timestamp": 1500000000000,
+				"request": {"request": "some-request", "url": "http://example.com", "method": "GET", "headers": []},
+				"response": {"url": "http://example.com", "status": 404, "statusText": "Not Found", "mimeType": "text/html",
+					"headers": [{"name": "Content-Type", "value": {"type": "string", "value": "text/html"}}]}
+			}}`)
+
+			var event NetworkEvent
+			Eventually(subscription.Events()).Should(Receive(&event))
+			Expect(event.Method).To(Equal("network.responseCompleted"))
+			Expect(event.Request.ID).To(Equal("some-request"))
+			Expect(event.Request.Method).To(Equal("GET"))
+			Expect(event.Response.Status).To(Equal(404))
+			Expect(event.Response.Headers[0].Name).To(Equal("Content-Type"))
+			Expect(event.Response.Headers[0].Value.Value).To(Equal("text/html"))
+			Expect(event.Time()).To(BeTemporally("==", time.Unix(1500000000, 0)))
+		})
+	})
+})
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/sclevine/agouti/api/internal/bus"
+)
+
+// A Recorder is an http.RoundTripper that records WebDriver traffic to a
+// cassette. Use it as the Transport of the *http.Client passed to
+// OpenWithClient or NewWithClient (or the agouti.HTTPClient Option).
+//
+// Example:
+//    cassette, _ := os.Create("login.cassette")
+//    defer cassette.Close()
+//    client := &http.Client{Transport: api.NewRecorder(cassette, nil)}
+//    page, _ := agouti.NewPage(driverURL, agouti.HTTPClient(client))
+type Recorder = bus.Recorder
+
+// A Replayer is an http.RoundTripper that responds to WebDriver requests
+// using a recorded cassette, so that no WebDriver needs to be running. The
+// WebDriver URL is ignored, and requests that do not match the next recorded
+// interaction fail.
+//
+// Example:
+//    cassette, _ := os.Open("login.cassette")
+//    replayer, _ := api.NewReplayer(cassette)
+//    client := &http.Client{Transport: replayer}
+//    page, _ := agouti.NewPage("http://replay", agouti.HTTPClient(client))
+type Replayer = bus.Replayer
+
+// An Interaction is a single recorded WebDriver request and response.
+type Interaction = bus.Interaction
+
+// NewRecorder returns a Recorder that writes to w and sends requests using
+// transport, or http.DefaultTransport if transport is nil.
+func NewRecorder(w io.Writer, transport http.RoundTripper) *Recorder {
+	return bus.NewRecorder(w, transport)
+}
+
+// NewReplayer returns a Replayer for a cassette written by a Recorder.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	return bus.NewReplayer(r)
+}
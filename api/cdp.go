@@ -0,0 +1,40 @@
+package api
+
+import "errors"
+
+// ExecuteCDP sends a Chrome DevTools Protocol command to the browser using
+// ChromeDriver's goog/cdp/execute endpoint, and decodes the command result
+// into the provided result value.
+func (s *Session) ExecuteCDP(command string, params, result interface{}) error {
+	if params == nil {
+		params = struct{}{}
+	}
+	request := struct {
+		Command string      `json:"cmd"`
+		Params  interface{} `json:"params"`
+	}{command, params}
+
+	return s.Send("POST", "goog/cdp/execute", request, result)
+}
+
+// DebuggerAddress returns the host and port of the browser's DevTools debugger
+// (ex. "localhost:9222"), as reported by the goog:chromeOptions or
+// ms:edgeOptions debuggerAddress capability. If the capabilities of the
+// session are unknown, they are requested from the WebDriver.
+func (s *Session) DebuggerAddress() (string, error) {
+	capabilities := s.Capabilities
+	if capabilities == nil {
+		if err := s.Send("GET", "", nil, &capabilities); err != nil {
+			return "", err
+		}
+	}
+
+	for _, key := range []string{"goog:chromeOptions", "ms:edgeOptions"} {
+		if options, ok := capabilities[key].(map[string]interface{}); ok {
+			if address, ok := options["debuggerAddress"].(string); ok && address != "" {
+				return address, nil
+			}
+		}
+	}
+	return "", errors.New("debugger address is not available")
+}
@@ -0,0 +1,99 @@
+package api_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/api/internal/mocks"
+)
+
+var _ = Describe("CDP", func() {
+	var (
+		bus     *mocks.Bus
+		session *Session
+	)
+
+	BeforeEach(func() {
+		bus = &mocks.Bus{}
+		session = &Session{Bus: bus}
+	})
+
+	Describe("#ExecuteCDP", func() {
+		It("should successfully send a POST to the goog/cdp/execute endpoint", func() {
+			params := map[string]interface{}{"offline": true}
+			Expect(session.ExecuteCDP("Network.emulateNetworkConditions", params, nil)).To(Succeed())
+			Expect(bus.SendCall.Method).To(Equal("POST"))
+			Expect(bus.SendCall.Endpoint).To(Equal("goog/cdp/execute"))
+			Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"cmd": "Network.emulateNetworkConditions", "params": {"offline": true}}`))
+		})
+
+		It("should send empty params when none are provided", func() {
+			Expect(session.ExecuteCDP("Performance.enable", nil, nil)).To(Succeed())
+			Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"cmd": "Performance.enable", "params": {}}`))
+		})
+
+		It("should decode the command result", func() {
+			bus.SendCall.Result = `{"metrics": [{"name": "Nodes", "value": 10}]}`
+			var result struct {
+				Metrics []struct {
+					Name  string
+					Value float64
+				}
+			}
+			Expect(session.ExecuteCDP("Performance.getMetrics", nil, &result)).To(Succeed())
+			Expect(result.Metrics[0].Name).To(Equal("Nodes"))
+			Expect(result.Metrics[0].Value).To(Equal(10.0))
+		})
+
+		Context("when the bus indicates a failure", func() {
+			It("should return an error", func() {
+				bus.SendCall.Err = errors.New("some error")
+				Expect(session.ExecuteCDP("Performance.enable", nil, nil)).To(MatchError("some error"))
+			})
+		})
+	})
+
+	Describe("#DebuggerAddress", func() {
+		It("should return the debugger address from the session capabilities", func() {
+			session.Capabilities = map[string]interface{}{
+				"goog:chromeOptions": map[string]interface{}{"debuggerAddress": "localhost:9222"},
+			}
+			Expect(session.DebuggerAddress()).To(Equal("localhost:9222"))
+			Expect(bus.SendCall.Method).To(BeEmpty())
+		})
+
+		It("should support Edge capabilities", func() {
+			session.Capabilities = map[string]interface{}{
+				"ms:edgeOptions": map[string]interface{}{"debuggerAddress": "localhost:9333"},
+			}
+			Expect(session.DebuggerAddress()).To(Equal("localhost:9333"))
+		})
+
+		Context("when the session capabilities are unknown", func() {
+			It("should request the capabilities from the WebDriver", func() {
+				bus.SendCall.Result = `{"goog:chromeOptions": {"debuggerAddress": "localhost:9222"}}`
+				Expect(session.DebuggerAddress()).To(Equal("localhost:9222"))
+				Expect(bus.SendCall.Method).To(Equal("GET"))
+				Expect(bus.SendCall.Endpoint).To(Equal(""))
+			})
+
+			Context("when the bus indicates a failure", func() {
+				It("should return an error", func() {
+					bus.SendCall.Err = errors.New("some error")
+					_, err := session.DebuggerAddress()
+					Expect(err).To(MatchError("some error"))
+				})
+			})
+		})
+
+		Context("when the browser does not provide a debugger address", func() {
+			It("should return an error", func() {
+				session.Capabilities = map[string]interface{}{"browserName": "firefox"}
+				_, err := session.DebuggerAddress()
+				Expect(err).To(MatchError("debugger address is not available"))
+			})
+		})
+	})
+})
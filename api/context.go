@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+// A ContextBus is a Bus that can abort requests using a context.
+type ContextBus interface {
+	Bus
+	SendContext(ctx context.Context, method, endpoint string, body, result interface{}) error
+}
+
+type contextBus struct {
+	Bus
+	ctx context.Context
+}
+
+func (b *contextBus) Send(method, endpoint string, body, result interface{}) error {
+	if contextBus, ok := b.Bus.(ContextBus); ok {
+		return contextBus.SendContext(b.ctx, method, endpoint, body, result)
+	}
+	if err := b.ctx.Err(); err != nil {
+		return fmt.Errorf("request canceled: %w", err)
+	}
+	return b.Bus.Send(method, endpoint, body, result)
+}
+
+// SendContext replaces the context of the bus, so that the most recently
+// provided context is used when buses are nested.
+func (b *contextBus) SendContext(ctx context.Context, method, endpoint string, body, result interface{}) error {
+	return (&contextBus{b.Bus, ctx}).Send(method, endpoint, body, result)
+}
+
+// WithContext returns a copy of the session that sends all requests using the
+// provided context. When the context is canceled or exceeds its deadline,
+// in-flight requests are aborted and errors wrapping the context error
+// (ex. context.DeadlineExceeded) are returned. Elements retrieved using the
+// returned session share its context.
+func (s *Session) WithContext(ctx context.Context) *Session {
+	sessionBus := s.Bus
+	if existing, ok := sessionBus.(*contextBus); ok {
+		sessionBus = existing.Bus
+	}
+	return &Session{Bus: &contextBus{sessionBus, ctx}, W3C: s.W3C, Capabilities: s.Capabilities}
+}
@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/api/internal/mocks"
+)
+
+type contextBus struct {
+	mocks.Bus
+	ctx context.Context
+}
+
+func (b *contextBus) SendContext(ctx context.Context, method, endpoint string, body, result interface{}) error {
+	b.ctx = ctx
+	return b.Send(method, endpoint, body, result)
+}
+
+var _ = Describe("Context", func() {
+	Describe("Session#WithContext", func() {
+		var (
+			bus     *mocks.Bus
+			session *Session
+		)
+
+		BeforeEach(func() {
+			bus = &mocks.Bus{}
+			session = &Session{Bus: bus, W3C: true, Capabilities: map[string]interface{}{"browserName": "chrome"}}
+		})
+
+		It("should return a session with the same protocol", func() {
+			Expect(session.WithContext(context.Background()).W3C).To(BeTrue())
+		})
+
+		It("should return a session with the same capabilities", func() {
+			Expect(session.WithContext(context.Background()).Capabilities).To(HaveKeyWithValue("browserName", "chrome"))
+		})
+
+		It("should send requests using the underlying bus", func() {
+			bus.SendCall.Result = `"some title"`
+			Expect(session.WithContext(context.Background()).GetTitle()).To(Equal("some title"))
+			Expect(bus.SendCall.Endpoint).To(Equal("title"))
+		})
+
+		It("should provide the context to a bus that supports contexts", func() {
+			ctx := context.WithValue(context.Background(), "some", "value")
+			ctxBus := &contextBus{}
+			session = &Session{Bus: ctxBus}
+			Expect(session.WithContext(ctx).Delete()).To(Succeed())
+			Expect(ctxBus.ctx).To(Equal(ctx))
+			Expect(ctxBus.SendCall.Method).To(Equal("DELETE"))
+		})
+
+		It("should replace the context of a session that already has one", func() {
+			canceled, cancel := context.WithCancel(context.Background())
+			cancel()
+			Expect(session.WithContext(canceled).WithContext(context.Background()).Delete()).To(Succeed())
+		})
+
+		It("should share the context with retrieved elements", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			bus.SendCall.Result = `{"ELEMENT": "some-id"}`
+			element, err := session.WithContext(ctx).GetElement(Selector{"css selector", "#selector"})
+			Expect(err).NotTo(HaveOccurred())
+			cancel()
+			_, err = element.GetText()
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		})
+
+		Context("when the context is canceled", func() {
+			It("should return an error wrapping the context error without sending a request", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				err := session.WithContext(ctx).Delete()
+				Expect(err).To(MatchError("request canceled: context canceled"))
+				Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+				Expect(bus.SendCall.Method).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/base64"
 	"errors"
 	"path"
 	"strings"
@@ -34,6 +35,10 @@ func (e *Element) GetID() string {
 func (e *Element) GetElement(selector Selector) (*Element, error) {
 	var result elementResult
 
+	if e.Session.W3C {
+		selector = selector.w3c()
+	}
+
 	if err := e.Send("POST", "element", selector, &result); err != nil {
 		return nil, err
 	}
@@ -44,6 +49,10 @@ func (e *Element) GetElement(selector Selector) (*Element, error) {
 func (e *Element) GetElements(selector Selector) ([]*Element, error) {
 	var results []elementResult
 
+	if e.Session.W3C {
+		selector = selector.w3c()
+	}
+
 	if err := e.Send("POST", "elements", selector, &results); err != nil {
 		return nil, err
 	}
@@ -98,6 +107,15 @@ func (e *Element) Clear() error {
 
 func (e *Element) Value(text string) error {
 	splitText := strings.Split(text, "")
+
+	if e.Session.W3C {
+		request := struct {
+			Text  string   `json:"text"`
+			Value []string `json:"value"`
+		}{text, splitText}
+		return e.Send("POST", "value", request, nil)
+	}
+
 	request := struct {
 		Value []string `json:"value"`
 	}{splitText}
@@ -129,13 +147,36 @@ func (e *Element) IsEnabled() (bool, error) {
 }
 
 func (e *Element) Submit() error {
+	if e.Session.W3C {
+		arguments := []interface{}{e.w3cReference()}
+		return e.Session.Execute(submitScript, arguments, nil)
+	}
 	return e.Send("POST", "submit", nil, nil)
 }
 
+const submitScript = `var form = arguments[0];
+while (form.nodeName != "FORM" && form.parentNode) {
+  form = form.parentNode;
+}
+if (!form.ownerDocument) {
+  throw Error("Unable to find containing form element");
+}
+var event = form.ownerDocument.createEvent("Event");
+event.initEvent("submit", true, true);
+if (form.dispatchEvent(event)) {
+  HTMLFormElement.prototype.submit.call(form);
+}`
+
 func (e *Element) IsEqualTo(other *Element) (bool, error) {
 	if other == nil {
 		return false, errors.New("nil element is invalid")
 	}
+
+	// W3C element references are unique for each element
+	if e.Session.W3C {
+		return e.ID == other.ID, nil
+	}
+
 	var equal bool
 	if err := e.Send("GET", path.Join("equals", other.ID), nil, &equal); err != nil {
 		return false, err
@@ -144,6 +185,14 @@ func (e *Element) IsEqualTo(other *Element) (bool, error) {
 }
 
 func (e *Element) GetLocation() (x, y int, err error) {
+	if e.Session.W3C {
+		rect, err := e.getRect()
+		if err != nil {
+			return 0, 0, err
+		}
+		return round(rect.X), round(rect.Y), nil
+	}
+
 	var location struct {
 		X float64 `json:"x"`
 		Y float64 `json:"y"`
@@ -155,6 +204,14 @@ func (e *Element) GetLocation() (x, y int, err error) {
 }
 
 func (e *Element) GetSize() (width, height int, err error) {
+	if e.Session.W3C {
+		rect, err := e.getRect()
+		if err != nil {
+			return 0, 0, err
+		}
+		return round(rect.Width), round(rect.Height), nil
+	}
+
 	var size struct {
 		Width  float64 `json:"width"`
 		Height float64 `json:"height"`
@@ -165,6 +222,31 @@ func (e *Element) GetSize() (width, height int, err error) {
 	return round(size.Width), round(size.Height), nil
 }
 
+// GetScreenshot returns a PNG screenshot of the element, using the W3C element
+// screenshot endpoint. Not all WebDrivers support this endpoint.
+func (e *Element) GetScreenshot() ([]byte, error) {
+	var base64Image string
+	if err := e.Send("GET", "screenshot", nil, &base64Image); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(base64Image)
+}
+
+type elementRect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+func (e *Element) getRect() (elementRect, error) {
+	var rect elementRect
+	if err := e.Send("GET", "rect", nil, &rect); err != nil {
+		return elementRect{}, err
+	}
+	return rect, nil
+}
+
 func round(number float64) int {
 	return int(number + 0.5)
 }
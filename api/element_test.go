@@ -19,7 +19,7 @@ var _ = Describe("Element", func() {
 
 	BeforeEach(func() {
 		bus = &mocks.Bus{}
-		session = &Session{bus}
+		session = &Session{Bus: bus}
 		element = &Element{"some-id", session}
 	})
 
@@ -71,6 +71,18 @@ var _ = Describe("Element", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should translate JSON Wire selector strategies into CSS selectors", func() {
+				_, err := element.GetElement(Selector{"name", "some-name"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"using": "css selector", "value": "[name=\"some-name\"]"}`))
+			})
+		})
 	})
 
 	Describe("#GetElements", func() {
@@ -99,6 +111,18 @@ var _ = Describe("Element", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should translate JSON Wire selector strategies into CSS selectors", func() {
+				_, err := element.GetElements(Selector{"id", "some-id"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"using": "css selector", "value": "[id=\"some-id\"]"}`))
+			})
+		})
 	})
 
 	Describe("#GetText", func() {
@@ -241,6 +265,18 @@ var _ = Describe("Element", func() {
 				Expect(element.Value("text")).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should include the text in the request", func() {
+				Expect(element.Value("text")).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("element/some-id/value"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"text": "text", "value": ["t", "e", "x", "t"]}`))
+			})
+		})
 	})
 
 	Describe("#IsSelected", func() {
@@ -328,6 +364,20 @@ var _ = Describe("Element", func() {
 				Expect(element.Submit()).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should submit the containing form using JavaScript", func() {
+				Expect(element.Submit()).To(Succeed())
+				Expect(bus.SendCall.Method).To(Equal("POST"))
+				Expect(bus.SendCall.Endpoint).To(Equal("execute/sync"))
+				Expect(bus.SendCall.BodyJSON).To(ContainSubstring(`prototype.submit.call(form)`))
+				Expect(bus.SendCall.BodyJSON).To(ContainSubstring(`"args":[{"element-6066-11e4-a52e-4f735466cecf":"some-id"}]`))
+			})
+		})
 	})
 
 	Describe("#IsEqualTo", func() {
@@ -360,6 +410,18 @@ var _ = Describe("Element", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should compare the element IDs without sending a request", func() {
+				Expect(element.IsEqualTo(&Element{ID: "some-id"})).To(BeTrue())
+				Expect(element.IsEqualTo(&Element{ID: "some-other-id"})).To(BeFalse())
+				Expect(bus.SendCall.Method).To(BeEmpty())
+			})
+		})
 	})
 
 	Describe("#GetLocation", func() {
@@ -385,6 +447,30 @@ var _ = Describe("Element", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should return the rounded location from the rect endpoint", func() {
+				bus.SendCall.Result = `{"x": 100.7, "y": 200, "width": 10, "height": 20}`
+				x, y, err := element.GetLocation()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.Method).To(Equal("GET"))
+				Expect(bus.SendCall.Endpoint).To(Equal("element/some-id/rect"))
+				Expect(x).To(Equal(101))
+				Expect(y).To(Equal(200))
+			})
+
+			Context("when the bus indicates a failure", func() {
+				It("should return an error", func() {
+					bus.SendCall.Err = errors.New("some error")
+					_, _, err := element.GetLocation()
+					Expect(err).To(MatchError("some error"))
+				})
+			})
+		})
 	})
 
 	Describe("#GetSize", func() {
@@ -410,5 +496,52 @@ var _ = Describe("Element", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should return the rounded size from the rect endpoint", func() {
+				bus.SendCall.Result = `{"x": 1, "y": 2, "width": 100.7, "height": 200}`
+				width, height, err := element.GetSize()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.Endpoint).To(Equal("element/some-id/rect"))
+				Expect(width).To(Equal(101))
+				Expect(height).To(Equal(200))
+			})
+		})
+	})
+
+	Describe("#GetScreenshot", func() {
+		It("should successfully send a GET request to the element screenshot endpoint", func() {
+			_, err := element.GetScreenshot()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bus.SendCall.Method).To(Equal("GET"))
+			Expect(bus.SendCall.Endpoint).To(Equal("element/some-id/screenshot"))
+		})
+
+		It("should return the decoded image", func() {
+			bus.SendCall.Result = `"c29tZS1wbmc="`
+			image, err := element.GetScreenshot()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(image)).To(Equal("some-png"))
+		})
+
+		Context("when the image is not valid base64", func() {
+			It("should return an error", func() {
+				bus.SendCall.Result = `"..."`
+				_, err := element.GetScreenshot()
+				Expect(err).To(MatchError("illegal base64 data at input byte 0"))
+			})
+		})
+
+		Context("when the bus indicates a failure", func() {
+			It("should return an error", func() {
+				bus.SendCall.Err = errors.New("some error")
+				_, err := element.GetScreenshot()
+				Expect(err).To(MatchError("some error"))
+			})
+		})
 	})
 })
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/sclevine/agouti/api/internal/bus"
+)
+
+// Error is returned when the WebDriver responds to a request with an error.
+// It provides the HTTP status code, W3C error code, legacy JSON Wire status,
+// message, and remote stacktrace of the failure.
+//
+// Errors returned by the agouti package wrap any underlying Error, so they
+// may be inspected using errors.As or the helper functions below.
+type Error = bus.Error
+
+// ErrorCode returns the W3C error code of the Error wrapped by err, or an
+// empty string if err does not wrap an Error.
+func ErrorCode(err error) string {
+	var responseError *Error
+	if errors.As(err, &responseError) {
+		return responseError.Code
+	}
+	return ""
+}
+
+// IsNoSuchElement returns true if err indicates that an element could not be found.
+func IsNoSuchElement(err error) bool {
+	return ErrorCode(err) == "no such element"
+}
+
+// IsStale returns true if err indicates that an element is no longer attached to the DOM.
+func IsStale(err error) bool {
+	return ErrorCode(err) == "stale element reference"
+}
+
+// IsTimeout returns true if err indicates that a command or script timed out.
+func IsTimeout(err error) bool {
+	code := ErrorCode(err)
+	return code == "timeout" || code == "script timeout"
+}
+
+// IsUnexpectedAlert returns true if err indicates that a popup blocked the command.
+func IsUnexpectedAlert(err error) bool {
+	return ErrorCode(err) == "unexpected alert open"
+}
+
+// IsNoSuchAlert returns true if err indicates that no popup was open.
+func IsNoSuchAlert(err error) bool {
+	return ErrorCode(err) == "no such alert"
+}
+
+// IsNoSuchFrame returns true if err indicates that a frame could not be found.
+func IsNoSuchFrame(err error) bool {
+	return ErrorCode(err) == "no such frame"
+}
+
+// IsNoSuchWindow returns true if err indicates that a window could not be found.
+func IsNoSuchWindow(err error) bool {
+	return ErrorCode(err) == "no such window"
+}
+
+// IsNotInteractable returns true if err indicates that an element could not be
+// interacted with (ex. because it is hidden or disabled).
+func IsNotInteractable(err error) bool {
+	code := ErrorCode(err)
+	return code == "element not interactable" || code == "element click intercepted" || code == "invalid element state"
+}
+
+// IsInvalidSelector returns true if err indicates that a selector was invalid.
+func IsInvalidSelector(err error) bool {
+	return ErrorCode(err) == "invalid selector"
+}
+
+// IsJavaScriptError returns true if err indicates that a script failed.
+func IsJavaScriptError(err error) bool {
+	return ErrorCode(err) == "javascript error"
+}
+
+// IsInvalidSession returns true if err indicates that the session no longer exists.
+func IsInvalidSession(err error) bool {
+	return ErrorCode(err) == "invalid session id"
+}
+
+// IsSessionNotCreated returns true if err indicates that the WebDriver could
+// not open a new session (ex. because the browser is incompatible).
+func IsSessionNotCreated(err error) bool {
+	return ErrorCode(err) == "session not created"
+}
+
+// IsUnsupported returns true if err indicates that the WebDriver does not
+// support the command that was sent.
+func IsUnsupported(err error) bool {
+	var responseError *Error
+	if !errors.As(err, &responseError) {
+		return false
+	}
+	switch responseError.Code {
+	case "unknown command", "unknown method", "unsupported operation":
+		return true
+	case "":
+		return responseError.StatusCode == 404 || responseError.StatusCode == 405
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package api_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/api"
+)
+
+var _ = Describe("Errors", func() {
+	wrap := func(code string) error {
+		return fmt.Errorf("failed to do something: %w", &Error{Code: code, Message: "some message"})
+	}
+
+	Describe("ErrorCode", func() {
+		It("should return the code of a wrapped error", func() {
+			Expect(ErrorCode(wrap("no such element"))).To(Equal("no such element"))
+		})
+
+		It("should return an empty code for other errors", func() {
+			Expect(ErrorCode(errors.New("some error"))).To(BeEmpty())
+			Expect(ErrorCode(nil)).To(BeEmpty())
+		})
+	})
+
+	Describe("helpers", func() {
+		It("should match wrapped errors by code", func() {
+			Expect(IsNoSuchElement(wrap("no such element"))).To(BeTrue())
+			Expect(IsStale(wrap("stale element reference"))).To(BeTrue())
+			Expect(IsTimeout(wrap("timeout"))).To(BeTrue())
+			Expect(IsTimeout(wrap("script timeout"))).To(BeTrue())
+			Expect(IsUnexpectedAlert(wrap("unexpected alert open"))).To(BeTrue())
+			Expect(IsNoSuchAlert(wrap("no such alert"))).To(BeTrue())
+			Expect(IsNoSuchFrame(wrap("no such frame"))).To(BeTrue())
+			Expect(IsNoSuchWindow(wrap("no such window"))).To(BeTrue())
+			Expect(IsNotInteractable(wrap("element not interactable"))).To(BeTrue())
+			Expect(IsInvalidSelector(wrap("invalid selector"))).To(BeTrue())
+			Expect(IsJavaScriptError(wrap("javascript error"))).To(BeTrue())
+			Expect(IsInvalidSession(wrap("invalid session id"))).To(BeTrue())
+			Expect(IsSessionNotCreated(wrap("session not created"))).To(BeTrue())
+			Expect(IsUnsupported(wrap("unknown command"))).To(BeTrue())
+			Expect(IsUnsupported(wrap("unknown method"))).To(BeTrue())
+			Expect(IsUnsupported(wrap("unsupported operation"))).To(BeTrue())
+		})
+
+		It("should not match errors with other codes", func() {
+			Expect(IsNoSuchElement(wrap("stale element reference"))).To(BeFalse())
+			Expect(IsStale(errors.New("stale element reference"))).To(BeFalse())
+		})
+
+		It("should treat responses without a code as unsupported based on their status", func() {
+			Expect(IsUnsupported(&Error{StatusCode: 404})).To(BeTrue())
+			Expect(IsUnsupported(&Error{StatusCode: 405})).To(BeTrue())
+			Expect(IsUnsupported(&Error{StatusCode: 500})).To(BeFalse())
+			Expect(IsUnsupported(&Error{Code: "no such element", StatusCode: 404})).To(BeFalse())
+		})
+	})
+})
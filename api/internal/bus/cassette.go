@@ -0,0 +1,422 @@
+package bus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// the W3C web element identifier, see https://www.w3.org/TR/webdriver/#elements
+const w3cElementKey = "element-6066-11e4-a52e-4f735466cecf"
+
+// CassetteVersion is the version of the cassette format written by a Recorder.
+const CassetteVersion = 1
+
+// An Interaction is a single WebDriver request and its response.
+//
+// Cassettes are stored as JSON lines: a header line with the cassette version,
+// followed by one Interaction per line. Session IDs, element IDs, and window
+// handles are replaced with stable placeholders (ex. "session-1") so that
+// cassettes do not change between recordings of the same test. IDs are only
+// replaced in URL paths and in the JSON fields that contain them (ex.
+// "sessionId" and "ELEMENT"). Bodies that are not JSON are stored as text.
+type Interaction struct {
+	Method       string          `json:"method"`
+	Path         string          `json:"path"`
+	Request      json.RawMessage `json:"request,omitempty"`
+	RequestText  string          `json:"requestText,omitempty"`
+	StatusCode   int             `json:"status"`
+	Response     json.RawMessage `json:"response,omitempty"`
+	ResponseText string          `json:"responseText,omitempty"`
+}
+
+type cassetteHeader struct {
+	Version int `json:"version"`
+}
+
+// A Recorder is an http.RoundTripper that writes every WebDriver request and
+// response that passes through it to a cassette.
+type Recorder struct {
+	transport http.RoundTripper
+	mutex     sync.Mutex
+	encoder   *json.Encoder
+	started   bool
+	ids       *volatileIDs
+}
+
+// NewRecorder returns a Recorder that sends requests using the provided
+// transport, or http.DefaultTransport if transport is nil, and writes them
+// to the provided writer.
+func NewRecorder(w io.Writer, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{
+		transport: transport,
+		encoder:   json.NewEncoder(w),
+		ids:       newVolatileIDs(),
+	}
+}
+
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(&request.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+
+	response, err := r.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := readBody(&response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	path := request.URL.RequestURI()
+	r.ids.learnPath(path)
+	r.ids.learnResponse(request.Method, path, responseBody)
+	interaction := Interaction{
+		Method:     request.Method,
+		Path:       r.ids.normalizePath(path),
+		StatusCode: response.StatusCode,
+	}
+	interaction.Request, interaction.RequestText = r.ids.normalizeBody(path, requestBody)
+	interaction.Response, interaction.ResponseText = r.ids.normalizeBody(path, responseBody)
+
+	if !r.started {
+		if err := r.encoder.Encode(cassetteHeader{CassetteVersion}); err != nil {
+			return nil, fmt.Errorf("failed to record interaction: %w", err)
+		}
+		r.started = true
+	}
+	if err := r.encoder.Encode(interaction); err != nil {
+		return nil, fmt.Errorf("failed to record interaction: %w", err)
+	}
+	return response, nil
+}
+
+// A Replayer is an http.RoundTripper that responds to requests using the
+// interactions in a cassette, without connecting to a WebDriver.
+//
+// Requests must be made in the recorded order. A request matches the next
+// interaction if it has the same method and path (ignoring the host) and an
+// equivalent JSON body. Session IDs, element IDs, and window handles in the
+// request are compared using their placeholders. Any other request fails.
+type Replayer struct {
+	mutex        sync.Mutex
+	interactions []Interaction
+	next         int
+	ids          *volatileIDs
+}
+
+// NewReplayer reads a cassette written by a Recorder.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+
+	var header cassetteHeader
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		return nil, errors.New("failed to read cassette: cassette is empty")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, fmt.Errorf("invalid cassette header: %w", err)
+	}
+	if header.Version != CassetteVersion {
+		return nil, fmt.Errorf("unsupported cassette version: %d", header.Version)
+	}
+
+	replayer := &Replayer{ids: newVolatileIDs()}
+	replayer.ids.replay = true
+	for line := 2; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("invalid interaction on line %d: %w", line, err)
+		}
+		replayer.interactions = append(replayer.interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	return replayer, nil
+}
+
+// Remaining returns the number of interactions that have not been replayed.
+func (r *Replayer) Remaining() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.interactions) - r.next
+}
+
+func (r *Replayer) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(&request.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	path := request.URL.RequestURI()
+	r.ids.learnPath(path)
+	actual := Interaction{
+		Method: request.Method,
+		Path:   r.ids.normalizePath(path),
+	}
+	actual.Request, actual.RequestText = r.ids.normalizeBody(path, requestBody)
+
+	if r.next >= len(r.interactions) {
+		return nil, fmt.Errorf("unexpected request %s %s: cassette has no more interactions", actual.Method, actual.Path)
+	}
+	expected := r.interactions[r.next]
+	if !matches(expected, actual) {
+		return nil, fmt.Errorf("unexpected request %s %s %s: expected %s %s %s",
+			actual.Method, actual.Path, actual.body(), expected.Method, expected.Path, expected.body())
+	}
+	r.next++
+
+	r.ids.learnResponse(expected.Method, expected.Path, expected.Response)
+
+	responseBody := []byte(expected.Response)
+	if expected.ResponseText != "" {
+		responseBody = []byte(expected.ResponseText)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", expected.StatusCode, http.StatusText(expected.StatusCode)),
+		StatusCode:    expected.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(responseBody)),
+		ContentLength: int64(len(responseBody)),
+		Request:       request,
+	}, nil
+}
+
+func (i Interaction) body() string {
+	if i.RequestText != "" {
+		return i.RequestText
+	}
+	return string(i.Request)
+}
+
+func matches(expected, actual Interaction) bool {
+	if expected.Method != actual.Method || expected.Path != actual.Path || expected.RequestText != actual.RequestText {
+		return false
+	}
+	var expectedBody, actualBody interface{}
+	if len(expected.Request) > 0 {
+		if err := json.Unmarshal(expected.Request, &expectedBody); err != nil {
+			return false
+		}
+	}
+	if len(actual.Request) > 0 {
+		if err := json.Unmarshal(actual.Request, &actualBody); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(expectedBody, actualBody)
+}
+
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	contents, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	*body = ioutil.NopCloser(bytes.NewReader(contents))
+	return contents, err
+}
+
+// volatileIDs maps session IDs, element IDs, and window handles to placeholders.
+// When replaying, IDs in responses are already placeholders and map to themselves.
+type volatileIDs struct {
+	placeholders map[string]string
+	counts       map[string]int
+	replay       bool
+}
+
+func newVolatileIDs() *volatileIDs {
+	return &volatileIDs{placeholders: map[string]string{}, counts: map[string]int{}}
+}
+
+func (v *volatileIDs) learn(kind, id string) {
+	if id == "" {
+		return
+	}
+	if _, ok := v.placeholders[id]; ok {
+		return
+	}
+	v.counts[kind]++
+	v.placeholders[id] = fmt.Sprintf("%s-%d", kind, v.counts[kind])
+}
+
+func (v *volatileIDs) learnFromResponse(kind, id string) {
+	if v.replay && id != "" {
+		if _, ok := v.placeholders[id]; !ok {
+			v.counts[kind]++
+			v.placeholders[id] = id
+		}
+		return
+	}
+	v.learn(kind, id)
+}
+
+// learnPath learns the session ID from a request path.
+func (v *volatileIDs) learnPath(path string) {
+	segments := strings.Split(strings.SplitN(path, "?", 2)[0], "/")
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] == "session" {
+			v.learn("session", segments[i+1])
+			return
+		}
+	}
+}
+
+func (v *volatileIDs) learnResponse(method, path string, body []byte) {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		if sessionID, ok := object["sessionId"].(string); ok {
+			v.learnFromResponse("session", sessionID)
+		}
+		if inner, ok := object["value"].(map[string]interface{}); ok {
+			if sessionID, ok := inner["sessionId"].(string); ok {
+				v.learnFromResponse("session", sessionID)
+			}
+		}
+		if method == "GET" && isWindowEndpoint(path) {
+			switch handles := object["value"].(type) {
+			case string:
+				v.learnFromResponse("window", handles)
+			case []interface{}:
+				for _, handle := range handles {
+					if handle, ok := handle.(string); ok {
+						v.learnFromResponse("window", handle)
+					}
+				}
+			}
+		}
+	}
+	v.learnElements(value)
+}
+
+func (v *volatileIDs) learnElements(value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if id, ok := field.(string); ok && (key == "ELEMENT" || key == w3cElementKey) {
+				v.learnFromResponse("element", id)
+			}
+			v.learnElements(field)
+		}
+	case []interface{}:
+		for _, item := range value {
+			v.learnElements(item)
+		}
+	}
+}
+
+func isWindowEndpoint(path string) bool {
+	for _, suffix := range []string{"/window_handle", "/window_handles", "/window", "/window/handles"} {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *volatileIDs) normalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if placeholder, ok := v.placeholders[segment]; ok {
+			segments[i] = placeholder
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// idFields are the JSON fields that contain session or element IDs.
+var idFields = map[string]bool{"sessionId": true, "ELEMENT": true, w3cElementKey: true, "element": true}
+
+// windowFields are the JSON fields that contain window handles in requests
+// to and responses from window endpoints.
+var windowFields = map[string]bool{"handle": true, "name": true, "value": true}
+
+// normalizeBody replaces IDs in a JSON body sent to or received from the
+// provided path, or returns a body that is not JSON as text.
+func (v *volatileIDs) normalizeBody(path string, body []byte) (json.RawMessage, string) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, ""
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, string(body)
+	}
+	normalized, err := json.Marshal(v.normalizeValue(value, isWindowEndpoint(path)))
+	if err != nil {
+		return json.RawMessage(body), ""
+	}
+	return normalized, ""
+}
+
+// normalizeValue replaces IDs in the ID fields of a JSON value.
+func (v *volatileIDs) normalizeValue(value interface{}, window bool) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if idFields[key] || (window && windowFields[key]) {
+				field = v.normalizeID(field)
+			}
+			value[key] = v.normalizeValue(field, window)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = v.normalizeValue(item, window)
+		}
+	}
+	return value
+}
+
+// normalizeID replaces an ID, or each ID in a list of IDs (ex. window handles).
+func (v *volatileIDs) normalizeID(value interface{}) interface{} {
+	switch value := value.(type) {
+	case string:
+		if placeholder, ok := v.placeholders[value]; ok {
+			return placeholder
+		}
+	case []interface{}:
+		for i, item := range value {
+			if id, ok := item.(string); ok {
+				if placeholder, ok := v.placeholders[id]; ok {
+					value[i] = placeholder
+				}
+			}
+		}
+	}
+	return value
+}
@@ -0,0 +1,200 @@
+package bus_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/api/internal/bus"
+)
+
+var _ = Describe("Cassettes", func() {
+	var (
+		server   *httptest.Server
+		cassette *bytes.Buffer
+		requests []string
+	)
+
+	// drives a session like api.Session would
+	runSession := func(url string, client *http.Client) {
+		session, err := Connect(url, nil, client)
+		Expect(err).NotTo(HaveOccurred())
+		var element struct{ ELEMENT string }
+		Expect(session.Send("POST", "element", map[string]string{"using": "css selector", "value": "#some"}, &element)).To(Succeed())
+		Expect(session.Send("POST", "element/"+element.ELEMENT+"/click", nil, nil)).To(Succeed())
+		Expect(session.Send("POST", "moveto", map[string]string{"element": element.ELEMENT}, nil)).To(Succeed())
+		var text string
+		Expect(session.Send("GET", "title", nil, &text)).To(Succeed())
+		Expect(text).To(Equal("some title"))
+	}
+
+	BeforeEach(func() {
+		requests = nil
+		cassette = &bytes.Buffer{}
+		server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			body, _ := ioutil.ReadAll(request.Body)
+			requests = append(requests, request.Method+" "+request.URL.Path+" "+string(body))
+			switch request.URL.Path {
+			case "/session":
+				response.Write([]byte(`{"sessionId": "abc123", "value": {}}`))
+			case "/session/abc123/element":
+				response.Write([]byte(`{"value": {"ELEMENT": "xyz789"}}`))
+			case "/session/abc123/title":
+				response.Write([]byte(`{"value": "some title"}`))
+			default:
+				response.Write([]byte(`{"value": null}`))
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Recorder", func() {
+		It("should pass requests through to the transport", func() {
+			runSession(server.URL, &http.Client{Transport: NewRecorder(cassette, nil)})
+			Expect(requests).To(Equal([]string{
+				`POST /session {"desiredCapabilities":{},"capabilities":{"alwaysMatch":{}}}`,
+				`POST /session/abc123/element {"using":"css selector","value":"#some"}`,
+				"POST /session/abc123/element/xyz789/click ",
+				`POST /session/abc123/moveto {"element":"xyz789"}`,
+				"GET /session/abc123/title ",
+			}))
+		})
+
+		It("should write interactions with placeholders for volatile IDs", func() {
+			runSession(server.URL, &http.Client{Transport: NewRecorder(cassette, nil)})
+			Expect(strings.Split(strings.TrimSpace(cassette.String()), "\n")).To(Equal([]string{
+				`{"version":1}`,
+				`{"method":"POST","path":"/session","request":{"capabilities":{"alwaysMatch":{}},"desiredCapabilities":{}},"status":200,"response":{"sessionId":"session-1","value":{}}}`,
+				`{"method":"POST","path":"/session/session-1/element","request":{"using":"css selector","value":"#some"},"status":200,"response":{"value":{"ELEMENT":"element-1"}}}`,
+				`{"method":"POST","path":"/session/session-1/element/element-1/click","status":200,"response":{"value":null}}`,
+				`{"method":"POST","path":"/session/session-1/moveto","request":{"element":"element-1"},"status":200,"response":{"value":null}}`,
+				`{"method":"GET","path":"/session/session-1/title","status":200,"response":{"value":"some title"}}`,
+			}))
+		})
+
+		It("should only replace IDs in the fields that contain them", func() {
+			client := &http.Client{Transport: NewRecorder(cassette, nil)}
+			session, err := Connect(server.URL, nil, client)
+			Expect(err).NotTo(HaveOccurred())
+			var element struct{ ELEMENT string }
+			Expect(session.Send("POST", "element", map[string]string{"using": "css selector", "value": "#some"}, &element)).To(Succeed())
+			Expect(session.Send("POST", "execute", map[string]interface{}{"script": "abc123", "args": []string{"xyz789"}}, nil)).To(Succeed())
+			Expect(strings.Split(strings.TrimSpace(cassette.String()), "\n")[3]).To(Equal(
+				`{"method":"POST","path":"/session/session-1/execute","request":{"args":["xyz789"],"script":"abc123"},"status":200,"response":{"value":null}}`))
+		})
+
+		It("should replace window handles in requests to and responses from window endpoints", func() {
+			server.Config.Handler = http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				switch request.URL.Path {
+				case "/session":
+					response.Write([]byte(`{"sessionId": "abc123", "value": {}}`))
+				default:
+					response.Write([]byte(`{"value": ["some-handle", "other-handle"]}`))
+				}
+			})
+			session, err := Connect(server.URL, nil, &http.Client{Transport: NewRecorder(cassette, nil)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(session.Send("GET", "window_handles", nil, nil)).To(Succeed())
+			Expect(session.Send("POST", "window", map[string]string{"handle": "other-handle"}, nil)).To(Succeed())
+			lines := strings.Split(strings.TrimSpace(cassette.String()), "\n")
+			Expect(lines[2]).To(ContainSubstring(`"response":{"value":["window-1","window-2"]}`))
+			Expect(lines[3]).To(ContainSubstring(`"request":{"handle":"window-2"}`))
+		})
+
+		It("should store bodies that are not JSON as text", func() {
+			server.Config.Handler = http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				response.Write([]byte("some text"))
+			})
+			client := &http.Client{Transport: NewRecorder(cassette, nil)}
+			_, err := client.Get(server.URL + "/status")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.Split(strings.TrimSpace(cassette.String()), "\n")[1]).To(Equal(
+				`{"method":"GET","path":"/status","status":200,"responseText":"some text"}`))
+		})
+
+		It("should return an error when the cassette cannot be written", func() {
+			client := &http.Client{Transport: NewRecorder(failingWriter{}, nil)}
+			_, err := Connect(server.URL, nil, client)
+			Expect(err).To(MatchError(ContainSubstring("failed to record interaction: some write error")))
+		})
+	})
+
+	Describe("Replayer", func() {
+		BeforeEach(func() {
+			runSession(server.URL, &http.Client{Transport: NewRecorder(cassette, nil)})
+			requests = nil
+		})
+
+		It("should replay the recorded responses without a WebDriver", func() {
+			replayer, err := NewReplayer(cassette)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(replayer.Remaining()).To(Equal(5))
+			runSession("http://replay.invalid", &http.Client{Transport: replayer})
+			Expect(replayer.Remaining()).To(Equal(0))
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("should match sessions joined using a different session ID", func() {
+			replayer, err := NewReplayer(bytes.NewBufferString(`{"version":1}
+{"method":"GET","path":"/session/session-1/title","status":200,"response":{"value":"some title"}}`))
+			Expect(err).NotTo(HaveOccurred())
+			client := &Client{SessionURL: "http://replay.invalid/session/real-id", HTTPClient: &http.Client{Transport: replayer}}
+			var title string
+			Expect(client.Send("GET", "title", nil, &title)).To(Succeed())
+			Expect(title).To(Equal("some title"))
+		})
+
+		It("should replay responses that are JSON strings without decoding them", func() {
+			replayer, err := NewReplayer(bytes.NewBufferString(`{"version":1}
+{"method":"GET","path":"/status","status":200,"response":"some text"}
+{"method":"GET","path":"/status","status":200,"responseText":"some text"}`))
+			Expect(err).NotTo(HaveOccurred())
+			client := &http.Client{Transport: replayer}
+			for _, expected := range []string{`"some text"`, "some text"} {
+				response, err := client.Get("http://replay.invalid/status")
+				Expect(err).NotTo(HaveOccurred())
+				body, _ := ioutil.ReadAll(response.Body)
+				Expect(string(body)).To(Equal(expected))
+			}
+		})
+
+		It("should fail on unexpected requests", func() {
+			replayer, err := NewReplayer(cassette)
+			Expect(err).NotTo(HaveOccurred())
+			session, err := Connect("http://replay.invalid", nil, &http.Client{Transport: replayer})
+			Expect(err).NotTo(HaveOccurred())
+			err = session.Send("POST", "element", map[string]string{"using": "css selector", "value": "#other"}, nil)
+			Expect(err).To(MatchError(ContainSubstring(`unexpected request POST /session/session-1/element {"using":"css selector","value":"#other"}: expected POST /session/session-1/element {"using":"css selector","value":"#some"}`)))
+		})
+
+		It("should fail when the cassette has no more interactions", func() {
+			replayer, err := NewReplayer(bytes.NewBufferString(`{"version":1}`))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = Connect("http://replay.invalid", nil, &http.Client{Transport: replayer})
+			Expect(err).To(MatchError(ContainSubstring("unexpected request POST /session: cassette has no more interactions")))
+		})
+
+		It("should return an error for invalid cassettes", func() {
+			_, err := NewReplayer(bytes.NewBufferString(""))
+			Expect(err).To(MatchError("failed to read cassette: cassette is empty"))
+			_, err = NewReplayer(bytes.NewBufferString(`{"version":2}`))
+			Expect(err).To(MatchError("unsupported cassette version: 2"))
+			_, err = NewReplayer(bytes.NewBufferString("{\"version\":1}\n{"))
+			Expect(err).To(MatchError(ContainSubstring("invalid interaction on line 2")))
+		})
+	})
+})
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("some write error")
+}
@@ -2,6 +2,7 @@ package bus
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -12,16 +13,26 @@ import (
 type Client struct {
 	SessionURL string
 	HTTPClient *http.Client
+	W3C        bool
+
+	// Capabilities are the capabilities returned when the session was opened.
+	Capabilities map[string]interface{}
 }
 
 func (c *Client) Send(method, endpoint string, body interface{}, result interface{}) error {
+	return c.SendContext(context.Background(), method, endpoint, body, result)
+}
+
+// SendContext is like Send, but aborts the request when the provided
+// context is canceled or exceeds its deadline.
+func (c *Client) SendContext(ctx context.Context, method, endpoint string, body interface{}, result interface{}) error {
 	requestBody, err := bodyToJSON(body)
 	if err != nil {
 		return err
 	}
 
 	requestURL := strings.TrimSuffix(c.SessionURL+"/"+endpoint, "/")
-	responseBody, err := c.makeRequest(requestURL, method, requestBody)
+	responseBody, err := c.makeRequest(ctx, requestURL, method, requestBody)
 	if err != nil {
 		return err
 	}
@@ -42,15 +53,15 @@ func bodyToJSON(body interface{}) ([]byte, error) {
 	}
 	bodyJSON, err := json.Marshal(body)
 	if err != nil {
-		return nil, fmt.Errorf("invalid request body: %s", err)
+		return nil, fmt.Errorf("invalid request body: %w", err)
 	}
 	return bodyJSON, nil
 }
 
-func (c *Client) makeRequest(url, method string, body []byte) ([]byte, error) {
-	request, err := http.NewRequest(method, url, bytes.NewReader(body))
+func (c *Client) makeRequest(ctx context.Context, url, method string, body []byte) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("invalid request: %s", err)
+		return nil, fmt.Errorf("invalid request: %w", err)
 	}
 
 	if body != nil {
@@ -59,7 +70,10 @@ func (c *Client) makeRequest(url, method string, body []byte) ([]byte, error) {
 
 	response, err := c.HTTPClient.Do(request)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %s", err)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("request canceled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer response.Body.Close()
 
@@ -69,22 +83,8 @@ func (c *Client) makeRequest(url, method string, body []byte) ([]byte, error) {
 	}
 
 	if response.StatusCode < 200 || response.StatusCode > 299 {
-		return nil, parseResponseError(responseBody)
+		return nil, parseResponseError(response.StatusCode, responseBody)
 	}
 
 	return responseBody, nil
 }
-
-func parseResponseError(body []byte) error {
-	var errBody struct{ Value struct{ Message string } }
-	if err := json.Unmarshal(body, &errBody); err != nil {
-		return fmt.Errorf("request unsuccessful: %s", body)
-	}
-
-	var errMessage struct{ ErrorMessage string }
-	if err := json.Unmarshal([]byte(errBody.Value.Message), &errMessage); err != nil {
-		return fmt.Errorf("request unsuccessful: %s", errBody.Value.Message)
-	}
-
-	return fmt.Errorf("request unsuccessful: %s", errMessage.ErrorMessage)
-}
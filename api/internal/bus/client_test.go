@@ -1,6 +1,7 @@
 package bus_test
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -113,6 +114,24 @@ var _ = Describe("Session", func() {
 			})
 		})
 
+		Context("when the context is done before the request completes", func() {
+			It("should return an error wrapping the context error", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				err := client.SendContext(ctx, "GET", "some/endpoint", nil, nil)
+				Expect(err).To(MatchError("request canceled: context canceled"))
+				Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+				Expect(requestPath).To(BeEmpty())
+			})
+
+			It("should distinguish an exceeded deadline", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 0)
+				defer cancel()
+				err := client.SendContext(ctx, "GET", "some/endpoint", nil, nil)
+				Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			})
+		})
+
 		Context("when the server responds with a non-2xx status code", func() {
 			BeforeEach(func() {
 				responseStatus = 400
@@ -141,6 +160,34 @@ var _ = Describe("Session", func() {
 					Expect(err).To(MatchError("request unsuccessful: $$$"))
 				})
 			})
+
+			Context("when the server responds with a W3C error", func() {
+				It("should return an error with the status, code, message, and stacktrace", func() {
+					responseStatus = 404
+					responseBody = `{"value": {"error": "no such element", "message": "some message", "stacktrace": "some stacktrace"}}`
+					err := client.Send("GET", "some/endpoint", nil, nil)
+					Expect(err).To(MatchError("request unsuccessful: some message"))
+					var responseError *Error
+					Expect(errors.As(err, &responseError)).To(BeTrue())
+					Expect(responseError.StatusCode).To(Equal(404))
+					Expect(responseError.Code).To(Equal("no such element"))
+					Expect(responseError.Message).To(Equal("some message"))
+					Expect(responseError.Stacktrace).To(Equal("some stacktrace"))
+				})
+			})
+
+			Context("when the server responds with a JSON Wire error", func() {
+				It("should return an error with a code derived from the status", func() {
+					responseStatus = 500
+					responseBody = `{"status": 10, "value": {"message": "some message"}}`
+					err := client.Send("GET", "some/endpoint", nil, nil)
+					var responseError *Error
+					Expect(errors.As(err, &responseError)).To(BeTrue())
+					Expect(responseError.StatusCode).To(Equal(500))
+					Expect(responseError.Status).To(Equal(10))
+					Expect(responseError.Code).To(Equal("stale element reference"))
+				})
+			})
 		})
 
 		Context("when the request succeeds", func() {
@@ -2,15 +2,21 @@ package bus
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
 func Connect(url string, capabilities map[string]interface{}, httpClient *http.Client) (*Client, error) {
+	return ConnectContext(context.Background(), url, capabilities, httpClient)
+}
+
+func ConnectContext(ctx context.Context, url string, capabilities map[string]interface{}, httpClient *http.Client) (*Client, error) {
 	requestBody, err := capabilitiesToJSON(capabilities)
 	if err != nil {
 		return nil, err
@@ -20,22 +26,30 @@ func Connect(url string, capabilities map[string]interface{}, httpClient *http.C
 		httpClient = http.DefaultClient
 	}
 
-	sessionID, err := openSession(url, requestBody, httpClient)
+	session, err := openSession(ctx, url, requestBody, httpClient)
 	if err != nil {
 		return nil, err
 	}
 
-	sessionURL := fmt.Sprintf("%s/session/%s", url, sessionID)
-	return &Client{sessionURL, httpClient}, nil
+	sessionURL := fmt.Sprintf("%s/session/%s", url, session.id)
+	return &Client{
+		SessionURL:   sessionURL,
+		HTTPClient:   httpClient,
+		W3C:          session.w3c,
+		Capabilities: session.capabilities,
+	}, nil
 }
 
 func capabilitiesToJSON(capabilities map[string]interface{}) (io.Reader, error) {
 	if capabilities == nil {
 		capabilities = map[string]interface{}{}
 	}
+	// JSON Wire drivers read desiredCapabilities, while W3C drivers only read
+	// capabilities, so both are sent
 	desiredCapabilities := struct {
 		DesiredCapabilities map[string]interface{} `json:"desiredCapabilities"`
-	}{capabilities}
+		Capabilities        w3cCapabilities        `json:"capabilities"`
+	}{capabilities, w3cCapabilities{AlwaysMatch: filterW3C(capabilities)}}
 
 	capabiltiesJSON, err := json.Marshal(desiredCapabilities)
 	if err != nil {
@@ -44,43 +58,111 @@ func capabilitiesToJSON(capabilities map[string]interface{}) (io.Reader, error)
 	return bytes.NewReader(capabiltiesJSON), err
 }
 
-func openSession(url string, body io.Reader, httpClient *http.Client) (sessionID string, err error) {
-	request, err := http.NewRequest("POST", fmt.Sprintf("%s/session", url), body)
+type w3cCapabilities struct {
+	AlwaysMatch map[string]interface{} `json:"alwaysMatch"`
+}
+
+var w3cCapabilityNames = map[string]bool{
+	"browserName":               true,
+	"browserVersion":            true,
+	"platformName":              true,
+	"acceptInsecureCerts":       true,
+	"pageLoadStrategy":          true,
+	"proxy":                     true,
+	"setWindowRect":             true,
+	"timeouts":                  true,
+	"strictFileInteractability": true,
+	"unhandledPromptBehavior":   true,
+	"webSocketUrl":              true,
+}
+
+// legacyCapabilityNames maps legacy capabilities to their W3C equivalents
+var legacyCapabilityNames = map[string]string{
+	"chromeOptions":  "goog:chromeOptions",
+	"acceptSslCerts": "acceptInsecureCerts",
+}
+
+// filterW3C translates legacy capabilities with W3C equivalents and removes
+// other legacy capabilities, which W3C drivers reject
+func filterW3C(capabilities map[string]interface{}) map[string]interface{} {
+	filtered := map[string]interface{}{}
+	for name, value := range capabilities {
+		if w3cCapabilityNames[name] || strings.Contains(name, ":") {
+			filtered[name] = value
+		}
+	}
+	for legacyName, name := range legacyCapabilityNames {
+		if _, ok := filtered[name]; ok {
+			continue
+		}
+		if value, ok := capabilities[legacyName]; ok {
+			filtered[name] = value
+		}
+	}
+	return filtered
+}
+
+type openedSession struct {
+	id           string
+	w3c          bool
+	capabilities map[string]interface{}
+}
+
+func openSession(ctx context.Context, url string, body io.Reader, httpClient *http.Client) (*openedSession, error) {
+	request, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/session", url), body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	request.Header.Add("Content-Type", "application/json")
 
 	response, err := httpClient.Do(request)
 	if err != nil {
-		return "", err
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("request canceled: %w", ctx.Err())
+		}
+		return nil, err
 	}
 	defer response.Body.Close()
 
 	var sessionResponse struct {
 		SessionID string
-		// fallback for GeckoDriver
-		Value struct {
-			SessionID string
-		}
+		Status    int
+		// W3C drivers nest the session ID and capabilities in the response
+		// value, while JSON Wire drivers return the capabilities as the value
+		Value json.RawMessage
 	}
 	responseBody, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, parseResponseError(response.StatusCode, responseBody)
 	}
 
 	if err := json.Unmarshal(responseBody, &sessionResponse); err != nil {
-		return "", err
+		return nil, err
 	}
 
+	var value struct {
+		SessionID    string
+		Capabilities map[string]interface{}
+	}
+	json.Unmarshal(sessionResponse.Value, &value)
+
 	if sessionResponse.SessionID == "" {
-		// fallback for GeckoDriver
-		if sessionResponse.Value.SessionID != "" {
-			return sessionResponse.Value.SessionID, nil
+		if value.SessionID != "" {
+			return &openedSession{value.SessionID, true, value.Capabilities}, nil
+		}
+		// JSON Wire drivers may report failures with a non-zero status
+		if sessionResponse.Status != 0 {
+			return nil, parseResponseError(response.StatusCode, responseBody)
 		}
-		return "", errors.New("failed to retrieve a session ID")
+		return nil, errors.New("failed to retrieve a session ID")
 	}
 
-	return sessionResponse.SessionID, nil
+	var capabilities map[string]interface{}
+	json.Unmarshal(sessionResponse.Value, &capabilities)
+	return &openedSession{sessionResponse.SessionID, false, capabilities}, nil
 }
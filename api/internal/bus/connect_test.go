@@ -1,6 +1,7 @@
 package bus_test
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -66,14 +67,62 @@ var _ = Describe(".Connect", func() {
 	It("should make the request with the provided desired capabilities", func() {
 		_, err := Connect(server.URL, map[string]interface{}{"some": "json"}, nil)
 		Expect(err).NotTo(HaveOccurred())
-		Expect(requestBody).To(MatchJSON(`{"desiredCapabilities": {"some": "json"}}`))
+		Expect(requestBody).To(MatchJSON(`{"desiredCapabilities": {"some": "json"}, "capabilities": {"alwaysMatch": {}}}`))
+	})
+
+	It("should also request the W3C capabilities", func() {
+		capabilities := map[string]interface{}{"browserName": "firefox", "acceptInsecureCerts": true, "javascriptEnabled": true, "moz:firefoxOptions": map[string]interface{}{"args": []string{"-headless"}}}
+		_, err := Connect(server.URL, capabilities, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requestBody).To(MatchJSON(`{
+			"desiredCapabilities": {"browserName": "firefox", "acceptInsecureCerts": true, "javascriptEnabled": true, "moz:firefoxOptions": {"args": ["-headless"]}},
+			"capabilities": {"alwaysMatch": {"browserName": "firefox", "acceptInsecureCerts": true, "moz:firefoxOptions": {"args": ["-headless"]}}}
+		}`))
+	})
+
+	Context("when the capabilities request a BiDi websocket URL", func() {
+		It("should request webSocketUrl as a W3C capability", func() {
+			capabilities := map[string]interface{}{"browserName": "firefox", "webSocketUrl": true, "javascriptEnabled": true, "moz:firefoxOptions": map[string]interface{}{}}
+			_, err := Connect(server.URL, capabilities, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requestBody).To(MatchJSON(`{
+				"desiredCapabilities": {"browserName": "firefox", "webSocketUrl": true, "javascriptEnabled": true, "moz:firefoxOptions": {}},
+				"capabilities": {"alwaysMatch": {"browserName": "firefox", "webSocketUrl": true, "moz:firefoxOptions": {}}}
+			}`))
+		})
+
+		It("should translate legacy capabilities that have W3C equivalents", func() {
+			capabilities := map[string]interface{}{
+				"browserName":    "chrome",
+				"webSocketUrl":   true,
+				"acceptSslCerts": true,
+				"chromeOptions":  map[string]interface{}{"args": []string{"headless"}},
+			}
+			_, err := Connect(server.URL, capabilities, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requestBody).To(MatchJSON(`{
+				"desiredCapabilities": {"browserName": "chrome", "webSocketUrl": true, "acceptSslCerts": true, "chromeOptions": {"args": ["headless"]}},
+				"capabilities": {"alwaysMatch": {"browserName": "chrome", "webSocketUrl": true, "acceptInsecureCerts": true, "goog:chromeOptions": {"args": ["headless"]}}}
+			}`))
+		})
+
+		It("should prefer W3C capabilities to their legacy equivalents", func() {
+			capabilities := map[string]interface{}{
+				"webSocketUrl":        true,
+				"acceptSslCerts":      true,
+				"acceptInsecureCerts": false,
+			}
+			_, err := Connect(server.URL, capabilities, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requestBody).To(ContainSubstring(`"alwaysMatch":{"acceptInsecureCerts":false,"webSocketUrl":true}`))
+		})
 	})
 
 	Context("when the capabilities are nil", func() {
 		It("should make the request with empty capabilities", func() {
 			_, err := Connect(server.URL, nil, nil)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(requestBody).To(MatchJSON(`{"desiredCapabilities": {}}`))
+			Expect(requestBody).To(MatchJSON(`{"desiredCapabilities": {}, "capabilities": {"alwaysMatch": {}}}`))
 		})
 	})
 
@@ -124,6 +173,17 @@ var _ = Describe(".Connect", func() {
 		})
 	})
 
+	Context("when the context is done before the request completes", func() {
+		It("should return an error wrapping the context error", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := ConnectContext(ctx, server.URL, nil, nil)
+			Expect(err).To(MatchError("request canceled: context canceled"))
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(requestPath).To(BeEmpty())
+		})
+	})
+
 	Context("when the response contains invalid JSON", func() {
 		It("should return an error", func() {
 			responseBody = "$$$"
@@ -140,6 +200,32 @@ var _ = Describe(".Connect", func() {
 		})
 	})
 
+	Context("when the WebDriver responds with a W3C error", func() {
+		It("should return an error describing the failure", func() {
+			server.Config.Handler = http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				response.WriteHeader(500)
+				response.Write([]byte(`{"value": {"error": "session not created", "message": "some message"}}`))
+			})
+			_, err := Connect(server.URL, nil, nil)
+			Expect(err).To(MatchError("request unsuccessful: some message"))
+			var responseError *Error
+			Expect(errors.As(err, &responseError)).To(BeTrue())
+			Expect(responseError.StatusCode).To(Equal(500))
+			Expect(responseError.Code).To(Equal("session not created"))
+		})
+	})
+
+	Context("when the WebDriver responds with a JSON Wire error status", func() {
+		It("should return an error describing the failure", func() {
+			responseBody = `{"status": 33, "value": {"message": "some message"}}`
+			_, err := Connect(server.URL, nil, nil)
+			var responseError *Error
+			Expect(errors.As(err, &responseError)).To(BeTrue())
+			Expect(responseError.Code).To(Equal("session not created"))
+			Expect(responseError.Message).To(Equal("some message"))
+		})
+	})
+
 	Context("when the response has fallback session ID", func() {
 		It("can extract fallback sesssion ID", func() {
 			responseBody = `{"value": {"sessionId": "fallback-id"}}`
@@ -153,6 +239,27 @@ var _ = Describe(".Connect", func() {
 			client, err := Connect(server.URL, nil, nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(client.SessionURL).To(ContainSubstring("/session/primary-id"))
+			Expect(client.W3C).To(BeFalse())
+		})
+	})
+
+	Context("when the response is a W3C new session response", func() {
+		It("should return a client that uses the W3C protocol", func() {
+			responseBody = `{"value": {"sessionId": "some-id", "capabilities": {"browserName": "firefox"}}}`
+			client, err := Connect(server.URL, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.W3C).To(BeTrue())
+			Expect(client.Capabilities).To(Equal(map[string]interface{}{"browserName": "firefox"}))
+		})
+	})
+
+	Context("when the response is a JSON Wire new session response", func() {
+		It("should return a client that uses the JSON Wire protocol", func() {
+			responseBody = `{"sessionId": "some-id", "status": 0, "value": {"browserName": "phantomjs"}}`
+			client, err := Connect(server.URL, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.W3C).To(BeFalse())
+			Expect(client.Capabilities).To(Equal(map[string]interface{}{"browserName": "phantomjs"}))
 		})
 	})
 })
@@ -0,0 +1,85 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Error is a failure response from the WebDriver.
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Code is the W3C error code (ex. "no such element"). For JSON Wire
+	// responses, Code is derived from the numeric Status.
+	Code string
+
+	// Status is the numeric JSON Wire status, if present.
+	Status int
+
+	// Message is the error message provided by the WebDriver.
+	Message string
+
+	// Stacktrace is the remote stacktrace provided by the WebDriver, if present.
+	Stacktrace string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("request unsuccessful: %s", e.Message)
+}
+
+var legacyCodes = map[int]string{
+	6:  "invalid session id",
+	7:  "no such element",
+	8:  "no such frame",
+	9:  "unknown command",
+	10: "stale element reference",
+	11: "element not interactable",
+	12: "invalid element state",
+	13: "unknown error",
+	15: "element not selectable",
+	17: "javascript error",
+	19: "invalid selector",
+	21: "timeout",
+	23: "no such window",
+	24: "invalid cookie domain",
+	25: "unable to set cookie",
+	26: "unexpected alert open",
+	27: "no such alert",
+	28: "script timeout",
+	29: "invalid element coordinates",
+	32: "invalid selector",
+	33: "session not created",
+	34: "move target out of bounds",
+}
+
+func parseResponseError(statusCode int, body []byte) error {
+	responseError := &Error{StatusCode: statusCode, Message: string(body)}
+
+	var errBody struct {
+		Status int
+		Value  struct {
+			Error      string
+			Message    string
+			Stacktrace string
+		}
+	}
+	if err := json.Unmarshal(body, &errBody); err != nil {
+		return responseError
+	}
+
+	responseError.Status = errBody.Status
+	responseError.Code = errBody.Value.Error
+	if responseError.Code == "" {
+		responseError.Code = legacyCodes[errBody.Status]
+	}
+	responseError.Stacktrace = errBody.Value.Stacktrace
+	responseError.Message = errBody.Value.Message
+
+	var errMessage struct{ ErrorMessage string }
+	if err := json.Unmarshal([]byte(errBody.Value.Message), &errMessage); err == nil {
+		responseError.Message = errMessage.ErrorMessage
+	}
+
+	return responseError
+}
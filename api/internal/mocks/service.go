@@ -1,12 +1,32 @@
 package mocks
 
-import "time"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 type Service struct {
 	URLCall struct {
 		ReturnURL string
 	}
 
+	SetOutputCall struct {
+		Stdout io.Writer
+		Stderr io.Writer
+		LogDir string
+	}
+
+	SetAddressCall struct {
+		Host    string
+		MinPort int
+		MaxPort int
+	}
+
+	RecentOutputCall struct {
+		ReturnOutput string
+	}
+
 	StartCall struct {
 		Debug  bool
 		Called bool
@@ -18,16 +38,50 @@ type Service struct {
 		Err    error
 	}
 
+	DoneCall struct {
+		ReturnDone chan struct{}
+	}
+
+	ErrCall struct {
+		Err error
+	}
+
+	HealthyCall struct {
+		Context       context.Context
+		ReturnHealthy bool
+	}
+
 	WaitForBootCall struct {
 		Timeout time.Duration
 		Err     error
 	}
+
+	WaitForBootContextCall struct {
+		Context context.Context
+		Err     error
+	}
 }
 
 func (s *Service) URL() string {
 	return s.URLCall.ReturnURL
 }
 
+func (s *Service) SetOutput(stdout, stderr io.Writer, logDir string) {
+	s.SetOutputCall.Stdout = stdout
+	s.SetOutputCall.Stderr = stderr
+	s.SetOutputCall.LogDir = logDir
+}
+
+func (s *Service) SetAddress(host string, minPort, maxPort int) {
+	s.SetAddressCall.Host = host
+	s.SetAddressCall.MinPort = minPort
+	s.SetAddressCall.MaxPort = maxPort
+}
+
+func (s *Service) RecentOutput() string {
+	return s.RecentOutputCall.ReturnOutput
+}
+
 func (s *Service) Start(debug bool) error {
 	s.StartCall.Debug = debug
 	s.StartCall.Called = true
@@ -39,7 +93,28 @@ func (s *Service) Stop() error {
 	return s.StopCall.Err
 }
 
+func (s *Service) Done() <-chan struct{} {
+	if s.DoneCall.ReturnDone == nil {
+		return nil
+	}
+	return s.DoneCall.ReturnDone
+}
+
+func (s *Service) Err() error {
+	return s.ErrCall.Err
+}
+
+func (s *Service) Healthy(ctx context.Context) bool {
+	s.HealthyCall.Context = ctx
+	return s.HealthyCall.ReturnHealthy
+}
+
 func (s *Service) WaitForBoot(timeout time.Duration) error {
 	s.WaitForBootCall.Timeout = timeout
 	return s.WaitForBootCall.Err
 }
+
+func (s *Service) WaitForBootContext(ctx context.Context) error {
+	s.WaitForBootContextCall.Context = ctx
+	return s.WaitForBootContextCall.Err
+}
@@ -3,7 +3,10 @@ package service
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"text/template"
 )
 
@@ -40,3 +43,19 @@ func buildCommand(arguments []string, address addressInfo) (*exec.Cmd, error) {
 
 	return exec.Command(command[0], command[1:]...), nil
 }
+
+// lookPath returns an error if the binary of the command cannot be found,
+// as the error returned when starting the command does not name it clearly.
+func lookPath(command *exec.Cmd) error {
+	name := command.Args[0]
+	if filepath.Base(name) == name {
+		if _, err := exec.LookPath(name); err != nil {
+			return fmt.Errorf("%s not found in PATH", name)
+		}
+		return nil
+	}
+	if _, err := os.Stat(name); err != nil {
+		return fmt.Errorf("%s not found", name)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// Interrupt simulates the receipt of SIGINT by the process.
+func Interrupt() {
+	interrupts <- os.Interrupt
+}
+
+// UseProcessGroups sets whether commands are started in their own process
+// group, regardless of whether KillAllOnInterrupt was called.
+func UseProcessGroups(use bool) {
+	if use {
+		atomic.StoreInt32(&groups, 1)
+	} else {
+		atomic.StoreInt32(&groups, 0)
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// outputLines is the number of lines of output returned by RecentOutput.
+const outputLines = 20
+
+// SetOutput configures where the output of the command is written when the
+// service is next started. If logDir is not empty, the output is also written
+// to a new file in logDir.
+func (s *Service) SetOutput(stdout, stderr io.Writer, logDir string) {
+	s.Lock()
+	defer s.Unlock()
+	s.Stdout = stdout
+	s.Stderr = stderr
+	s.LogDir = logDir
+}
+
+// RecentOutput returns the last lines written by the command to stdout or
+// stderr, including output written before the service was stopped.
+func (s *Service) RecentOutput() string {
+	s.RLock()
+	defer s.RUnlock()
+	if s.output == nil {
+		return ""
+	}
+	return s.output.String()
+}
+
+func (s *Service) openOutput(debug bool, name, port string) (stdout, stderr io.Writer, err error) {
+	s.output = &tailBuffer{lines: outputLines}
+	stdoutWriters := []io.Writer{s.output}
+	stderrWriters := []io.Writer{s.output}
+
+	if debug {
+		stdoutWriters = append(stdoutWriters, os.Stdout)
+		stderrWriters = append(stderrWriters, os.Stderr)
+	}
+	if s.Stdout != nil {
+		stdoutWriters = append(stdoutWriters, s.Stdout)
+	}
+	if s.Stderr != nil {
+		stderrWriters = append(stderrWriters, s.Stderr)
+	}
+
+	if s.LogDir != "" {
+		if err := os.MkdirAll(s.LogDir, 0755); err != nil {
+			return nil, nil, err
+		}
+		filename := fmt.Sprintf("%s-%s.log", filepath.Base(name), port)
+		logFile, err := os.Create(filepath.Join(s.LogDir, filename))
+		if err != nil {
+			return nil, nil, err
+		}
+		s.logFile = logFile
+		stdoutWriters = append(stdoutWriters, logFile)
+		stderrWriters = append(stderrWriters, logFile)
+	}
+
+	mutex := &sync.Mutex{}
+	return &lockedWriter{io.MultiWriter(stdoutWriters...), mutex},
+		&lockedWriter{io.MultiWriter(stderrWriters...), mutex}, nil
+}
+
+func (s *Service) closeOutput() {
+	if s.logFile != nil {
+		s.logFile.Close()
+		s.logFile = nil
+	}
+}
+
+// lockedWriter serializes writes, as stdout and stderr are copied to shared
+// writers by separate goroutines.
+type lockedWriter struct {
+	writer io.Writer
+	mutex  *sync.Mutex
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.writer.Write(p)
+}
+
+// tailBuffer retains the last lines written to it.
+type tailBuffer struct {
+	lines int
+
+	mutex   sync.Mutex
+	written []string
+	partial bytes.Buffer
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.partial.Write(p)
+	for {
+		line, err := b.partial.ReadString('\n')
+		if err != nil {
+			// retain the incomplete line until it is completed
+			b.partial.Reset()
+			b.partial.WriteString(line)
+			break
+		}
+		b.written = append(b.written, strings.TrimRight(line, "\r\n"))
+		if len(b.written) > b.lines {
+			b.written = b.written[len(b.written)-b.lines:]
+		}
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	lines := b.written
+	if b.partial.Len() > 0 {
+		lines = append(lines[:len(lines):len(lines)], b.partial.String())
+		if len(lines) > b.lines {
+			lines = lines[1:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
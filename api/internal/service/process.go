@@ -0,0 +1,41 @@
+//go:build !windows
+
+package service
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startGroup starts the command in a new process group if KillAllOnInterrupt
+// was called, so that it may be signalled along with the processes that it
+// starts (ex. browsers).
+func startGroup(command *exec.Cmd) error {
+	if processGroups() {
+		command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
+	return command.Start()
+}
+
+func terminateGroup(command *exec.Cmd) error {
+	return signalGroup(command, syscall.SIGTERM)
+}
+
+func killGroup(command *exec.Cmd) error {
+	return signalGroup(command, syscall.SIGKILL)
+}
+
+// signalGroup signals the process group of the command, or only the command
+// if it was not started in its own process group.
+func signalGroup(command *exec.Cmd, signal syscall.Signal) error {
+	pid := command.Process.Pid
+	if command.SysProcAttr != nil && command.SysProcAttr.Setpgid {
+		pid = -pid
+	}
+	err := syscall.Kill(pid, signal)
+	if err == syscall.ESRCH {
+		// every process has exited
+		return nil
+	}
+	return err
+}
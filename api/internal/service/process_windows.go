@@ -0,0 +1,23 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+func startGroup(command *exec.Cmd) error {
+	return command.Start()
+}
+
+// terminateGroup kills the command, as Windows does not support SIGTERM.
+func terminateGroup(command *exec.Cmd) error {
+	return killGroup(command)
+}
+
+func killGroup(command *exec.Cmd) error {
+	if err := command.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// running contains the services that have been started but not stopped.
+var running = struct {
+	sync.Mutex
+	services map[*Service]bool
+}{services: map[*Service]bool{}}
+
+var (
+	interruptOnce sync.Once
+	interrupts    = make(chan os.Signal, 1)
+
+	// groups is set to 1 once commands should be started in their own
+	// process group.
+	groups int32
+)
+
+func register(s *Service) {
+	running.Lock()
+	defer running.Unlock()
+	running.services[s] = true
+}
+
+func unregister(s *Service) {
+	running.Lock()
+	defer running.Unlock()
+	delete(running.services, s)
+}
+
+// KillAll immediately kills the commands of all services that have been
+// started but not stopped, along with the processes that they started if they
+// were started in their own process group.
+func KillAll() {
+	running.Lock()
+	services := make([]*Service, 0, len(running.services))
+	for s := range running.services {
+		services = append(services, s)
+	}
+	running.Unlock()
+
+	for _, s := range services {
+		s.kill()
+	}
+}
+
+// KillAllOnInterrupt starts the commands of services that are started later
+// in their own process group, so that Stop also stops the processes that they
+// start, and calls KillAll when the process receives SIGINT or SIGTERM.
+//
+// Commands in their own process group do not receive signals sent to the
+// terminal's foreground process group (ex. Ctrl-C), so they are only started
+// in one when this handler is registered. The signal is not otherwise handled,
+// so that other handlers (ex. Ginkgo's) may exit the process. If there are no
+// other handlers, the process exits when it receives the signal again.
+func KillAllOnInterrupt() {
+	interruptOnce.Do(func() {
+		atomic.StoreInt32(&groups, 1)
+		signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-interrupts
+			signal.Stop(interrupts)
+			KillAll()
+		}()
+	})
+}
+
+func processGroups() bool {
+	return atomic.LoadInt32(&groups) == 1
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Remote is a service that is already running at RemoteURL (ex. a
+// Selenium Grid hub), so no process is started or stopped.
+type Remote struct {
+	sync.RWMutex
+	RemoteURL string
+
+	// Client, if not nil, returns the *http.Client used to request the
+	// status of the service. The default client is http.DefaultClient.
+	Client func() *http.Client
+
+	url string
+}
+
+func (r *Remote) URL() string {
+	r.RLock()
+	defer r.RUnlock()
+	return r.url
+}
+
+// SetOutput has no effect, as the output of a remote service is not available.
+func (r *Remote) SetOutput(stdout, stderr io.Writer, logDir string) {}
+
+// SetAddress has no effect, as the address of a remote service is its URL.
+func (r *Remote) SetAddress(host string, minPort, maxPort int) {}
+
+func (r *Remote) RecentOutput() string {
+	return ""
+}
+
+func (r *Remote) Start(debug bool) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.url != "" {
+		return errors.New("already running")
+	}
+	if r.RemoteURL == "" {
+		return errors.New("empty URL")
+	}
+	r.url = strings.TrimSuffix(r.RemoteURL, "/")
+	return nil
+}
+
+func (r *Remote) Stop() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.url == "" {
+		return errors.New("already stopped")
+	}
+	r.url = ""
+	return nil
+}
+
+// Done returns nil, as a remote service has no process to exit.
+func (r *Remote) Done() <-chan struct{} {
+	return nil
+}
+
+func (r *Remote) Err() error {
+	return nil
+}
+
+// Healthy returns true if the service is started and responds to status
+// requests.
+func (r *Remote) Healthy(ctx context.Context) bool {
+	url := r.URL()
+	return url != "" && checkStatus(ctx, r.client(), url)
+}
+
+func (r *Remote) WaitForBoot(timeout time.Duration) error {
+	return waitForBoot(timeout, r.WaitForBootContext)
+}
+
+// WaitForBootContext waits for the service to respond to status requests
+// until the provided context is canceled or exceeds its deadline.
+func (r *Remote) WaitForBootContext(ctx context.Context) error {
+	return waitForStatus(ctx, r.client(), r.URL())
+}
+
+func (r *Remote) client() *http.Client {
+	if r.Client != nil && r.Client() != nil {
+		return r.Client()
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,90 @@
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/api/internal/service"
+)
+
+var _ = Describe("Remote", func() {
+	var (
+		remote  *Remote
+		server  *httptest.Server
+		headers chan string
+	)
+
+	BeforeEach(func() {
+		headers = make(chan string, 100)
+		server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if request.URL.Path == "/wd/hub/status" {
+				headers <- request.Header.Get("Some-Header")
+				response.WriteHeader(200)
+			} else {
+				response.WriteHeader(404)
+			}
+		}))
+		remote = &Remote{RemoteURL: server.URL + "/wd/hub/"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("#Start and #Stop", func() {
+		It("should set and clear the URL without starting a process", func() {
+			Expect(remote.URL()).To(BeEmpty())
+			Expect(remote.Start(false)).To(Succeed())
+			Expect(remote.URL()).To(Equal(server.URL + "/wd/hub"))
+			Expect(remote.Start(false)).To(MatchError("already running"))
+			Expect(remote.Stop()).To(Succeed())
+			Expect(remote.URL()).To(BeEmpty())
+			Expect(remote.Stop()).To(MatchError("already stopped"))
+		})
+
+		Context("when the URL is empty", func() {
+			It("should return an error", func() {
+				remote.RemoteURL = ""
+				Expect(remote.Start(false)).To(MatchError("empty URL"))
+			})
+		})
+	})
+
+	Describe("#WaitForBoot", func() {
+		It("should wait for the remote service to respond to status requests using the provided client", func() {
+			remote.Client = func() *http.Client {
+				return &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+					request.Header.Set("Some-Header", "some value")
+					return http.DefaultTransport.RoundTrip(request)
+				})}
+			}
+			Expect(remote.Start(false)).To(Succeed())
+			Expect(remote.WaitForBoot(time.Second)).To(Succeed())
+			Expect(headers).To(Receive(Equal("some value")))
+		})
+
+		Context("when the remote service does not respond before the timeout", func() {
+			It("should return an error", func() {
+				remote.RemoteURL = server.URL + "/some/other/path"
+				Expect(remote.Start(false)).To(Succeed())
+				Expect(remote.WaitForBoot(100 * time.Millisecond)).To(MatchError("failed to start before timeout"))
+			})
+		})
+	})
+
+	Describe("#RecentOutput", func() {
+		It("should return an empty string", func() {
+			remote.SetOutput(nil, nil, "some/dir")
+			Expect(remote.RecentOutput()).To(BeEmpty())
+		})
+	})
+})
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (r roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return r(request)
+}
@@ -1,16 +1,17 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net"
 	"net/http"
 	"os"
 	"os/exec"
-	"runtime"
-	"strings"
+	"strconv"
 	"sync"
-	"syscall"
 	"time"
 )
 
@@ -18,10 +19,35 @@ type Service struct {
 	sync.RWMutex
 	URLTemplate string
 	CmdTemplate []string
-	url         string
-	command     *exec.Cmd
+
+	// Stdout and Stderr, if not nil, receive the output of the command.
+	// If LogDir is not empty, the output is also written to a file in LogDir.
+	Stdout io.Writer
+	Stderr io.Writer
+	LogDir string
+
+	// Host is the address that the command is started on, and MinPort and
+	// MaxPort restrict the port that the command is started on. By default,
+	// any free port on 127.0.0.1 is used.
+	Host    string
+	MinPort int
+	MaxPort int
+
+	// StopTimeout is how long Stop waits for the command to exit before it
+	// is killed. The default is 5 seconds.
+	StopTimeout time.Duration
+
+	url      string
+	command  *exec.Cmd
+	done     chan struct{}
+	exitErr  error
+	stopping bool
+	output   *tailBuffer
+	logFile  *os.File
 }
 
+const defaultStopTimeout = 5 * time.Second
+
 type addressInfo struct {
 	Address string
 	Host    string
@@ -29,36 +55,47 @@ type addressInfo struct {
 }
 
 func (s *Service) URL() string {
+	s.RLock()
+	defer s.RUnlock()
 	return s.url
 }
 
 func (s *Service) Start(debug bool) error {
+	s.Lock()
+	defer s.Unlock()
+
 	if s.command != nil {
 		return errors.New("already running")
 	}
 
-	address, err := freeAddress()
+	address, err := freeAddress(s.Host, s.MinPort, s.MaxPort)
 	if err != nil {
-		return fmt.Errorf("failed to locate a free port: %s", err)
+		return fmt.Errorf("failed to locate a free port: %w", err)
 	}
 
 	url, err := buildURL(s.URLTemplate, address)
 	if err != nil {
-		return fmt.Errorf("failed to parse URL: %s", err)
+		return fmt.Errorf("failed to parse URL: %w", err)
 	}
 
 	command, err := buildCommand(s.CmdTemplate, address)
 	if err != nil {
-		return fmt.Errorf("failed to parse command: %s", err)
+		return fmt.Errorf("failed to parse command: %w", err)
+	}
+	if err := lookPath(command); err != nil {
+		return err
 	}
 
-	if debug {
-		command.Stdout = os.Stdout
-		command.Stderr = os.Stderr
+	command.Stdout, command.Stderr, err = s.openOutput(debug, command.Path, address.Port)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
 	}
+	// the output of the command may be held open by processes that it starts
+	command.WaitDelay = time.Second
 
-	if err := command.Start(); err != nil {
-		err = fmt.Errorf("failed to run command: %s", err)
+	if err := startGroup(command); err != nil {
+		s.closeOutput()
+		err = fmt.Errorf("failed to run command: %w", err)
 		if debug {
 			os.Stderr.WriteString("ERROR: " + err.Error() + "\n")
 		}
@@ -67,79 +104,239 @@ func (s *Service) Start(debug bool) error {
 
 	s.command = command
 	s.url = url
+	s.done = make(chan struct{})
+	s.exitErr = nil
+	go s.monitor(command, s.done)
+	register(s)
 
 	return nil
 }
 
-func (s *Service) Stop() error {
-	if s.command == nil {
-		return errors.New("already stopped")
+// monitor waits for the command to exit, so that a command that exits before
+// it is stopped is detected.
+func (s *Service) monitor(command *exec.Cmd, done chan struct{}) {
+	err := command.Wait()
+
+	s.Lock()
+	if !s.stopping {
+		s.exitErr = exitError(err)
 	}
+	s.closeOutput()
+	s.Unlock()
+
+	close(done)
+}
 
-	var err error
-	if runtime.GOOS == "windows" {
-		err = s.command.Process.Kill()
-	} else {
-		err = s.command.Process.Signal(syscall.SIGTERM)
+func exitError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == -1 {
+		return fmt.Errorf("driver process exited: %w", exitErr)
+	}
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("driver process exited with status %d", exitErr.ExitCode())
 	}
 	if err != nil {
-		return fmt.Errorf("failed to stop command: %s", err)
+		return fmt.Errorf("driver process exited: %w", err)
 	}
+	return errors.New("driver process exited with status 0")
+}
 
-	s.command.Wait()
-	s.command = nil
+// Done returns a channel that is closed when the command exits, or nil if
+// the service is not running.
+func (s *Service) Done() <-chan struct{} {
+	s.RLock()
+	defer s.RUnlock()
+	if s.command == nil {
+		return nil
+	}
+	return s.done
+}
+
+// Err returns an error describing how the command exited if it exited before
+// the service was stopped, or nil otherwise.
+func (s *Service) Err() error {
+	s.RLock()
+	defer s.RUnlock()
+	return s.exitErr
+}
+
+// Healthy returns true if the command is running and the service responds
+// to status requests.
+func (s *Service) Healthy(ctx context.Context) bool {
+	s.RLock()
+	running := s.command != nil && s.exitErr == nil && !s.stopping
+	url := s.url
+	s.RUnlock()
+	return running && checkStatus(ctx, &http.Client{}, url)
+}
+
+// Stop terminates the command, along with the processes that it started if
+// it was started in its own process group (see KillAllOnInterrupt).
+// Processes that have not exited after StopTimeout are killed.
+func (s *Service) Stop() error {
 	s.Lock()
-	s.url = ""
+	if s.command == nil || s.stopping {
+		s.Unlock()
+		return errors.New("already stopped")
+	}
+	command, done := s.command, s.done
+	s.stopping = true
 	s.Unlock()
 
+	select {
+	case <-done:
+	default:
+		if err := terminateGroup(command); err != nil {
+			s.Lock()
+			s.stopping = false
+			s.Unlock()
+			return fmt.Errorf("failed to stop command: %w", err)
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(s.stopTimeout()):
+	}
+	// kill the command if it ignored SIGTERM, along with any processes that
+	// outlived it
+	killGroup(command)
+	<-done
+
+	unregister(s)
+	s.Lock()
+	defer s.Unlock()
+	s.command = nil
+	s.url = ""
+	s.stopping = false
 	return nil
 }
 
-func freeAddress() (addressInfo, error) {
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
+func (s *Service) stopTimeout() time.Duration {
+	if s.StopTimeout <= 0 {
+		return defaultStopTimeout
+	}
+	return s.StopTimeout
+}
+
+// kill immediately kills the command along with the processes that it started.
+func (s *Service) kill() {
+	s.RLock()
+	command := s.command
+	s.RUnlock()
+	if command != nil {
+		killGroup(command)
+	}
+}
+
+// freeAddress returns an address on host with a free port between minPort
+// and maxPort, or with any free port if no range is provided. The port is
+// free when it is returned, but it may be taken before the command binds to it.
+func freeAddress(host string, minPort, maxPort int) (addressInfo, error) {
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if minPort == 0 && maxPort == 0 {
+		return listenAddress(net.JoinHostPort(host, "0"))
+	}
+	if minPort < 1 || maxPort < minPort || maxPort > 65535 {
+		return addressInfo{}, fmt.Errorf("invalid port range: %d-%d", minPort, maxPort)
+	}
+
+	// start at a random port so that parallel processes are unlikely to collide
+	size := maxPort - minPort + 1
+	offset := rand.Intn(size)
+	for i := 0; i < size; i++ {
+		port := minPort + (offset+i)%size
+		address, err := listenAddress(net.JoinHostPort(host, strconv.Itoa(port)))
+		if err == nil {
+			return address, nil
+		}
+	}
+	return addressInfo{}, fmt.Errorf("no free port between %d and %d", minPort, maxPort)
+}
+
+func listenAddress(address string) (addressInfo, error) {
+	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		return addressInfo{}, err
 	}
 	defer listener.Close()
 
-	address := listener.Addr().String()
-	addressParts := strings.SplitN(address, ":", 2)
-	return addressInfo{address, addressParts[0], addressParts[1]}, nil
+	address = listener.Addr().String()
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return addressInfo{}, err
+	}
+	return addressInfo{address, host, port}, nil
 }
 
 func (s *Service) WaitForBoot(timeout time.Duration) error {
-	timeoutChan := time.After(timeout)
-	failedChan := make(chan struct{}, 1)
-	startedChan := make(chan struct{})
+	return waitForBoot(timeout, s.WaitForBootContext)
+}
+
+// WaitForBootContext waits for the service to respond to status requests
+// until the provided context is canceled or exceeds its deadline. An error is
+// returned immediately if the command exits, so that a service listening on
+// a port that the command failed to bind to is not mistaken for the command.
+func (s *Service) WaitForBootContext(ctx context.Context) error {
+	s.RLock()
+	url, done := s.url, s.done
+	s.RUnlock()
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	go func() {
-		up := s.checkStatus()
-		for !up {
-			select {
-			case <-failedChan:
-				return
-			default:
-				time.Sleep(500 * time.Millisecond)
-				up = s.checkStatus()
-			}
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
 		}
-		startedChan <- struct{}{}
 	}()
 
-	select {
-	case <-timeoutChan:
-		failedChan <- struct{}{}
-		return errors.New("failed to start before timeout")
-	case <-startedChan:
-		return nil
+	err := waitForStatus(ctx, &http.Client{}, url)
+	if exitErr := s.Err(); exitErr != nil {
+		return fmt.Errorf("failed to start: %w", exitErr)
 	}
+	return err
 }
 
-func (s *Service) checkStatus() bool {
-	client := &http.Client{}
+// SetAddress configures the Host, MinPort, and MaxPort that are used when the
+// service is next started.
+func (s *Service) SetAddress(host string, minPort, maxPort int) {
 	s.Lock()
-	request, _ := http.NewRequest("GET", fmt.Sprintf("%s/status", s.url), nil)
-	s.Unlock()
+	defer s.Unlock()
+	s.Host = host
+	s.MinPort = minPort
+	s.MaxPort = maxPort
+}
+
+func waitForBoot(timeout time.Duration, waitForBootContext func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := waitForBootContext(ctx); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return errors.New("failed to start before timeout")
+		}
+		return err
+	}
+	return nil
+}
+
+func waitForStatus(ctx context.Context, client *http.Client, url string) error {
+	for !checkStatus(ctx, client, url) {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to start: %w", ctx.Err())
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
+func checkStatus(ctx context.Context, client *http.Client, url string) bool {
+	request, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/status", url), nil)
 	response, err := client.Do(request)
 	if err != nil {
 		return false
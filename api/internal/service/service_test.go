@@ -1,25 +1,55 @@
 package service_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 	. "github.com/sclevine/agouti/api/internal/service"
 )
 
 var _ = Describe("Service", func() {
-	var service *Service
+	var (
+		service *Service
+		pidFile string
+	)
 
 	BeforeEach(func() {
 		service = &Service{
 			URLTemplate: "some-url",
 			CmdTemplate: []string{"true"},
 		}
+		pidFile = filepath.Join(os.TempDir(), fmt.Sprintf("service-pid-%d", GinkgoParallelNode()))
+		os.Remove(pidFile)
 	})
 
+	AfterEach(func() {
+		os.Remove(pidFile)
+	})
+
+	readPID := func() string {
+		var pid string
+		Eventually(func() string {
+			pidText, _ := ioutil.ReadFile(pidFile)
+			pid = strings.TrimSpace(string(pidText))
+			return pid
+		}).ShouldNot(BeEmpty())
+		return pid
+	}
+
 	Describe("#URL", func() {
 		Context("when the server is not running", func() {
 			It("should return an empty string", func() {
@@ -51,7 +81,14 @@ var _ = Describe("Service", func() {
 		Context("when the binary is not available in PATH", func() {
 			It("should return an error indicating the binary needs to be installed", func() {
 				service.CmdTemplate = []string{"not-in-path"}
-				Expect(service.Start(false)).To(MatchError("failed to run command: exec: \"not-in-path\": executable file not found in $PATH"))
+				Expect(service.Start(false)).To(MatchError("not-in-path not found in PATH"))
+			})
+		})
+
+		Context("when the binary is not available at the provided path", func() {
+			It("should return an error naming the path", func() {
+				service.CmdTemplate = []string{"/not/a/binary"}
+				Expect(service.Start(false)).To(MatchError("/not/a/binary not found"))
 			})
 		})
 
@@ -62,6 +99,43 @@ var _ = Describe("Service", func() {
 			})
 		})
 
+		Describe("the provided port range", func() {
+			var port int
+
+			BeforeEach(func() {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).NotTo(HaveOccurred())
+				port = listener.Addr().(*net.TCPAddr).Port
+				listener.Close()
+				service.URLTemplate = "{{.Address}}"
+			})
+
+			It("should start the command on a port in the range", func() {
+				defer service.Stop()
+				service.SetAddress("127.0.0.1", port, port)
+				Expect(service.Start(false)).To(Succeed())
+				Expect(service.URL()).To(Equal(fmt.Sprintf("127.0.0.1:%d", port)))
+			})
+
+			Context("when no port in the range is free", func() {
+				It("should return an error", func() {
+					listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+					Expect(err).NotTo(HaveOccurred())
+					defer listener.Close()
+					service.SetAddress("127.0.0.1", port, port)
+					err = service.Start(false)
+					Expect(err).To(MatchError(fmt.Sprintf("failed to locate a free port: no free port between %d and %d", port, port)))
+				})
+			})
+
+			Context("when the range is invalid", func() {
+				It("should return an error", func() {
+					service.SetAddress("", 5000, 4000)
+					Expect(service.Start(false)).To(MatchError("failed to locate a free port: invalid port range: 5000-4000"))
+				})
+			})
+		})
+
 		Describe("the provided templated URL", func() {
 			Context("when the template is invalid", func() {
 				It("should return an error", func() {
@@ -138,19 +212,251 @@ var _ = Describe("Service", func() {
 				Expect(err).To(MatchError("already stopped"))
 			})
 		})
+
+		Context("when commands are started in their own process group", func() {
+			BeforeEach(func() {
+				UseProcessGroups(true)
+			})
+
+			AfterEach(func() {
+				UseProcessGroups(false)
+			})
+
+			It("should stop the processes started by the command", func() {
+				service.CmdTemplate = []string{"sh", "-c", "sleep 30 & echo $! > " + pidFile + "; wait"}
+				Expect(service.Start(false)).To(Succeed())
+				pid := readPID()
+				Expect(running(pid)).To(BeTrue())
+
+				Expect(service.Stop()).To(Succeed())
+				Eventually(func() bool { return running(pid) }).Should(BeFalse())
+			})
+		})
+
+		Context("when the command does not exit before the stop timeout", func() {
+			It("should kill the command", func() {
+				service.CmdTemplate = []string{"sh", "-c", "trap '' TERM; sleep 30"}
+				service.StopTimeout = 200 * time.Millisecond
+				Expect(service.Start(false)).To(Succeed())
+				time.Sleep(50 * time.Millisecond)
+				start := time.Now()
+				Expect(service.Stop()).To(Succeed())
+				Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+				Expect(time.Since(start)).To(BeNumerically("<", 3*time.Second))
+			})
+		})
+
+		Context("when the service is started and stopped concurrently", func() {
+			It("should start and stop the command once each", func() {
+				service.CmdTemplate = []string{"sleep", "5"}
+				var started, stopped int32
+				var wg sync.WaitGroup
+				for i := 0; i < 4; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if service.Start(false) == nil {
+							atomic.AddInt32(&started, 1)
+						}
+						service.URL()
+					}()
+				}
+				wg.Wait()
+
+				for i := 0; i < 4; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if service.Stop() == nil {
+							atomic.AddInt32(&stopped, 1)
+						}
+						service.URL()
+					}()
+				}
+				wg.Wait()
+
+				Expect(started).To(BeEquivalentTo(1))
+				Expect(stopped).To(BeEquivalentTo(1))
+				Expect(service.URL()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("process groups", func() {
+		It("should start commands in the process group of the current process by default", func() {
+			service.CmdTemplate = []string{"sh", "-c", "echo $$ > " + pidFile + "; exec sleep 30"}
+			Expect(service.Start(false)).To(Succeed())
+			Expect(processGroup(readPID())).To(Equal(processGroup("self")))
+			Expect(service.Stop()).To(Succeed())
+		})
+	})
+
+	Describe("KillAllOnInterrupt", func() {
+		AfterEach(func() {
+			UseProcessGroups(false)
+		})
+
+		It("should start commands in their own process group and kill them when the process is interrupted", func() {
+			KillAllOnInterrupt()
+			service.CmdTemplate = []string{"sh", "-c", "echo $$ > " + pidFile + "; exec sleep 30"}
+			Expect(service.Start(false)).To(Succeed())
+			pid := readPID()
+			Expect(processGroup(pid)).To(Equal(pid))
+
+			Interrupt()
+			Eventually(service.Done()).Should(BeClosed())
+			Expect(service.Err()).To(MatchError("driver process exited: signal: killed"))
+			Expect(service.Stop()).To(Succeed())
+		})
+	})
+
+	Describe("KillAll", func() {
+		It("should kill the commands of services that are running", func() {
+			service.CmdTemplate = []string{"sleep", "30"}
+			Expect(service.Start(false)).To(Succeed())
+			stopped := &Service{URLTemplate: "some-url", CmdTemplate: []string{"sleep", "30"}}
+			Expect(stopped.Start(false)).To(Succeed())
+			stoppedDone := stopped.Done()
+			Expect(stopped.Stop()).To(Succeed())
+
+			KillAll()
+			Eventually(service.Done()).Should(BeClosed())
+			Expect(service.Err()).To(MatchError("driver process exited: signal: killed"))
+			Expect(stoppedDone).To(BeClosed())
+			Expect(service.Stop()).To(Succeed())
+		})
+	})
+
+	Describe("#Done and #Err", func() {
+		It("should report when the command exits before the service is stopped", func() {
+			service.CmdTemplate = []string{"sh", "-c", "exit 3"}
+			Expect(service.Done()).To(BeNil())
+			Expect(service.Start(false)).To(Succeed())
+			Eventually(service.Done()).Should(BeClosed())
+			Expect(service.Err()).To(MatchError("driver process exited with status 3"))
+			Expect(service.Stop()).To(Succeed())
+			Expect(service.Done()).To(BeNil())
+		})
+
+		It("should not report an error when the command is stopped", func() {
+			service.CmdTemplate = []string{"sleep", "5"}
+			Expect(service.Start(false)).To(Succeed())
+			done := service.Done()
+			Consistently(done, "50ms").ShouldNot(BeClosed())
+			Expect(service.Stop()).To(Succeed())
+			Expect(done).To(BeClosed())
+			Expect(service.Err()).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("#Healthy", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				response.WriteHeader(200)
+			}))
+			service.URLTemplate = server.URL
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should return true only while the command is running and the service responds", func() {
+			service.CmdTemplate = []string{"sleep", "5"}
+			Expect(service.Healthy(context.Background())).To(BeFalse())
+			Expect(service.Start(false)).To(Succeed())
+			Expect(service.Healthy(context.Background())).To(BeTrue())
+			server.Close()
+			Expect(service.Healthy(context.Background())).To(BeFalse())
+			Expect(service.Stop()).To(Succeed())
+		})
+
+		Context("when the command has exited", func() {
+			It("should return false", func() {
+				service.CmdTemplate = []string{"sh", "-c", "exit 1"}
+				defer service.Stop()
+				Expect(service.Start(false)).To(Succeed())
+				Eventually(service.Done()).Should(BeClosed())
+				Expect(service.Healthy(context.Background())).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("#SetOutput", func() {
+		It("should write the output of the command to the provided writers", func() {
+			stdout, stderr := gbytes.NewBuffer(), gbytes.NewBuffer()
+			service.SetOutput(stdout, stderr, "")
+			service.CmdTemplate = []string{"sh", "-c", "echo some-output; echo some-error >&2"}
+			defer service.Stop()
+			Expect(service.Start(false)).To(Succeed())
+			Eventually(stdout).Should(gbytes.Say("some-output"))
+			Eventually(stderr).Should(gbytes.Say("some-error"))
+		})
+
+		It("should write the output of the command to a file in the provided directory", func() {
+			logDir, err := ioutil.TempDir("", "service")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(logDir)
+
+			service.SetOutput(nil, nil, filepath.Join(logDir, "logs"))
+			service.CmdTemplate = []string{"sh", "-c", "echo some-output; sleep 5"}
+			Expect(service.Start(false)).To(Succeed())
+			Eventually(service.RecentOutput).Should(Equal("some-output"))
+			Expect(service.Stop()).To(Succeed())
+
+			logFiles, err := filepath.Glob(filepath.Join(logDir, "logs", "sh-*.log"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logFiles).To(HaveLen(1))
+			Expect(ioutil.ReadFile(logFiles[0])).To(Equal([]byte("some-output\n")))
+		})
+
+		Context("when the log directory cannot be created", func() {
+			It("should return an error wrapping the cause", func() {
+				logFile, err := ioutil.TempFile("", "service")
+				Expect(err).NotTo(HaveOccurred())
+				logFile.Close()
+				defer os.Remove(logFile.Name())
+
+				service.SetOutput(nil, nil, filepath.Join(logFile.Name(), "logs"))
+				err = service.Start(false)
+				Expect(err).To(MatchError(HavePrefix("failed to open log file: ")))
+				var pathErr *os.PathError
+				Expect(errors.As(err, &pathErr)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("#RecentOutput", func() {
+		It("should return the last lines of output written by the command", func() {
+			service.CmdTemplate = []string{"sh", "-c", "for i in $(seq 1 30); do echo line $i; done; printf partial"}
+			defer service.Stop()
+			Expect(service.Start(false)).To(Succeed())
+			Eventually(service.RecentOutput).Should(HaveSuffix("line 30\npartial"))
+			lines := strings.Split(service.RecentOutput(), "\n")
+			Expect(lines).To(HaveLen(20))
+			Expect(lines[0]).To(Equal("line 12"))
+		})
+
+		Context("when the service has not been started", func() {
+			It("should return an empty string", func() {
+				Expect(service.RecentOutput()).To(BeEmpty())
+			})
+		})
 	})
 
 	Describe("#WaitForBoot", func() {
 		var (
-			started bool
+			started int32
 			server  *httptest.Server
 		)
 
 		BeforeEach(func() {
-			started = false
+			atomic.StoreInt32(&started, 0)
 
 			server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
-				if started && request.URL.Path == "/status" {
+				if atomic.LoadInt32(&started) == 1 && request.URL.Path == "/status" {
 					response.WriteHeader(200)
 				} else {
 					response.WriteHeader(400)
@@ -158,6 +464,7 @@ var _ = Describe("Service", func() {
 			}))
 
 			service.URLTemplate = server.URL
+			service.CmdTemplate = []string{"sleep", "5"}
 		})
 
 		AfterEach(func() {
@@ -169,7 +476,7 @@ var _ = Describe("Service", func() {
 				defer service.Stop()
 				go func() {
 					time.Sleep(3000 * time.Millisecond)
-					started = true
+					atomic.StoreInt32(&started, 1)
 				}()
 				Expect(service.Start(false)).To(Succeed())
 				Expect(service.WaitForBoot(1500 * time.Millisecond)).To(MatchError("failed to start before timeout"))
@@ -181,11 +488,66 @@ var _ = Describe("Service", func() {
 				defer service.Stop()
 				go func() {
 					time.Sleep(200 * time.Millisecond)
-					started = true
+					atomic.StoreInt32(&started, 1)
 				}()
 				Expect(service.Start(false)).To(Succeed())
 				Expect(service.WaitForBoot(1500 * time.Millisecond)).To(Succeed())
 			})
 		})
+
+		Context("when the command exits before the service starts", func() {
+			It("should return an error without waiting for the timeout", func() {
+				atomic.StoreInt32(&started, 1)
+				service.CmdTemplate = []string{"sh", "-c", "exit 3"}
+				defer service.Stop()
+				Expect(service.Start(false)).To(Succeed())
+				Eventually(service.Done()).Should(BeClosed())
+				start := time.Now()
+				Expect(service.WaitForBoot(1500 * time.Millisecond)).To(MatchError("failed to start: driver process exited with status 3"))
+				Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			})
+		})
+
+		Context("when the provided context is canceled before the service starts", func() {
+			It("should return an error wrapping the context error", func() {
+				defer service.Stop()
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					time.Sleep(200 * time.Millisecond)
+					cancel()
+				}()
+				Expect(service.Start(false)).To(Succeed())
+				err := service.WaitForBootContext(ctx)
+				Expect(err).To(MatchError("failed to start: context canceled"))
+				Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			})
+		})
 	})
 })
+
+// processGroup returns the process group ID of the process with the provided
+// ID (or "self").
+func processGroup(pid string) string {
+	if _, err := os.Stat("/proc"); err != nil {
+		Skip("process status requires /proc")
+	}
+	stat, err := ioutil.ReadFile(filepath.Join("/proc", pid, "stat"))
+	Expect(err).NotTo(HaveOccurred())
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	Expect(len(fields)).To(BeNumerically(">", 2))
+	return fields[2]
+}
+
+// running returns true if the process with the provided ID is running and
+// is not a zombie, which may not be reaped in containers.
+func running(pid string) bool {
+	if _, err := os.Stat("/proc"); err != nil {
+		Skip("process status requires /proc")
+	}
+	stat, err := ioutil.ReadFile(filepath.Join("/proc", pid, "stat"))
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	return len(fields) > 0 && fields[0] != "Z" && fields[0] != "X"
+}
@@ -18,7 +18,7 @@ var _ = Describe("Bus", func() {
 
 	BeforeEach(func() {
 		bus = &mocks.Bus{}
-		apiSession = &api.Session{bus}
+		apiSession = &api.Session{Bus: bus}
 		session = &Session{apiSession}
 	})
 
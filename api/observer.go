@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Command describes a single request sent by a Session and its outcome.
+type Command struct {
+	Method   string
+	Endpoint string
+
+	// Body is the request body, or nil if the request has no body.
+	Body interface{}
+
+	// Response is the decoded value of the response, or nil if the response
+	// was not decoded (ex. because the command failed or has no result).
+	Response interface{}
+
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+func (c Command) String() string {
+	description := c.Method + " " + c.Endpoint
+	if c.Body != nil {
+		if body, err := json.Marshal(c.Body); err == nil {
+			description += " " + string(body)
+		}
+	}
+	description += fmt.Sprintf(" (%s)", c.Duration)
+	if c.Err != nil {
+		description += ": " + c.Err.Error()
+	}
+	return description
+}
+
+// An Observer is notified after each command sent by a Session. Observers
+// may be called concurrently by sessions used from multiple goroutines.
+type Observer interface {
+	ObserveCommand(command Command)
+}
+
+// ObserverFunc is an Observer that calls itself.
+type ObserverFunc func(command Command)
+
+func (f ObserverFunc) ObserveCommand(command Command) {
+	f(command)
+}
+
+type observingBus struct {
+	Bus
+	observer Observer
+}
+
+func (b *observingBus) Send(method, endpoint string, body, result interface{}) error {
+	return b.observe(method, endpoint, body, result, func() error {
+		return b.Bus.Send(method, endpoint, body, result)
+	})
+}
+
+func (b *observingBus) SendContext(ctx context.Context, method, endpoint string, body, result interface{}) error {
+	return b.observe(method, endpoint, body, result, func() error {
+		return (&contextBus{b.Bus, ctx}).Send(method, endpoint, body, result)
+	})
+}
+
+func (b *observingBus) observe(method, endpoint string, body, result interface{}, send func() error) error {
+	start := time.Now()
+	err := send()
+	command := Command{
+		Method:   method,
+		Endpoint: endpoint,
+		Body:     body,
+		Start:    start,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if err == nil {
+		command.Response = result
+	}
+	b.observer.ObserveCommand(command)
+	return err
+}
+
+// WithObserver returns a copy of the session that notifies the provided
+// observer after each command. Elements retrieved using the returned session
+// notify the observer as well.
+func (s *Session) WithObserver(observer Observer) *Session {
+	return &Session{Bus: &observingBus{s.Bus, observer}, W3C: s.W3C, Capabilities: s.Capabilities}
+}
+
+// LogObserver returns an Observer that writes a human-readable line for each
+// command to the provided writer. For example:
+//    POST element {"using":"css selector","value":"#login"} (12.3ms)
+func LogObserver(w io.Writer) Observer {
+	var mutex sync.Mutex
+	return ObserverFunc(func(command Command) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		fmt.Fprintln(w, command.Start.Format("15:04:05.000"), command)
+	})
+}
+
+// JSONObserver returns an Observer that writes a JSON object for each command
+// to the provided writer, one per line.
+func JSONObserver(w io.Writer) Observer {
+	var mutex sync.Mutex
+	encoder := json.NewEncoder(w)
+	return ObserverFunc(func(command Command) {
+		entry := struct {
+			Time       time.Time   `json:"time"`
+			Method     string      `json:"method"`
+			Endpoint   string      `json:"endpoint"`
+			Body       interface{} `json:"body,omitempty"`
+			Response   interface{} `json:"response,omitempty"`
+			DurationMS float64     `json:"durationMs"`
+			Error      string      `json:"error,omitempty"`
+		}{
+			Time:       command.Start,
+			Method:     command.Method,
+			Endpoint:   command.Endpoint,
+			Body:       command.Body,
+			Response:   command.Response,
+			DurationMS: float64(command.Duration) / float64(time.Millisecond),
+		}
+		if command.Err != nil {
+			entry.Error = command.Err.Error()
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err := encoder.Encode(entry); err != nil {
+			// bodies or responses that cannot be encoded are omitted
+			entry.Body, entry.Response = nil, nil
+			encoder.Encode(entry)
+		}
+	})
+}
+
+// A CommandSummary is an Observer that collects commands so that the slowest
+// commands may be reported (ex. after each test).
+type CommandSummary struct {
+	mutex    sync.Mutex
+	commands []Command
+}
+
+// NewCommandSummary returns an empty CommandSummary.
+func NewCommandSummary() *CommandSummary {
+	return &CommandSummary{}
+}
+
+func (s *CommandSummary) ObserveCommand(command Command) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.commands = append(s.commands, command)
+}
+
+// Count returns the number of commands observed.
+func (s *CommandSummary) Count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.commands)
+}
+
+// Total returns the total duration of all commands observed.
+func (s *CommandSummary) Total() time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var total time.Duration
+	for _, command := range s.commands {
+		total += command.Duration
+	}
+	return total
+}
+
+// Slowest returns up to n of the slowest commands observed, slowest first.
+func (s *CommandSummary) Slowest(n int) []Command {
+	s.mutex.Lock()
+	commands := append([]Command(nil), s.commands...)
+	s.mutex.Unlock()
+
+	sort.SliceStable(commands, func(i, j int) bool {
+		return commands[i].Duration > commands[j].Duration
+	})
+	if n < len(commands) {
+		commands = commands[:n]
+	}
+	return commands
+}
+
+// Reset discards all commands observed.
+func (s *CommandSummary) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.commands = nil
+}
+
+// String returns a report of the number and total duration of the commands
+// observed, followed by the ten slowest commands.
+func (s *CommandSummary) String() string {
+	report := []string{fmt.Sprintf("%d commands in %s", s.Count(), s.Total())}
+	for _, command := range s.Slowest(10) {
+		report = append(report, "  "+command.String())
+	}
+	return strings.Join(report, "\n")
+}
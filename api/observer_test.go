@@ -0,0 +1,152 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/api/internal/mocks"
+)
+
+var _ = Describe("Observer", func() {
+	var (
+		bus      *mocks.Bus
+		session  *Session
+		commands []Command
+	)
+
+	BeforeEach(func() {
+		bus = &mocks.Bus{}
+		commands = nil
+		session = (&Session{Bus: bus, W3C: true, Capabilities: map[string]interface{}{"browserName": "chrome"}}).WithObserver(ObserverFunc(func(command Command) {
+			commands = append(commands, command)
+		}))
+	})
+
+	Describe("Session#WithObserver", func() {
+		It("should return a session with the same protocol", func() {
+			Expect(session.W3C).To(BeTrue())
+		})
+
+		It("should return a session with the same capabilities", func() {
+			Expect(session.Capabilities).To(HaveKeyWithValue("browserName", "chrome"))
+		})
+
+		It("should notify the observer of each command and its response", func() {
+			bus.SendCall.Result = `"some title"`
+			Expect(session.GetTitle()).To(Equal("some title"))
+			Expect(commands).To(HaveLen(1))
+			Expect(commands[0].Method).To(Equal("GET"))
+			Expect(commands[0].Endpoint).To(Equal("title"))
+			Expect(commands[0].Body).To(BeNil())
+			Expect(*(commands[0].Response.(*string))).To(Equal("some title"))
+			Expect(commands[0].Start).NotTo(BeZero())
+			Expect(commands[0].Err).NotTo(HaveOccurred())
+		})
+
+		It("should notify the observer of commands sent by retrieved elements", func() {
+			bus.SendCall.Result = `{"ELEMENT": "some-id"}`
+			element, err := session.GetElement(Selector{"css selector", "#selector"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(element.Click()).To(Succeed())
+			Expect(commands).To(HaveLen(2))
+			Expect(commands[0].Body).To(Equal(Selector{"css selector", "#selector"}))
+			Expect(commands[1].Endpoint).To(Equal("element/some-id/click"))
+		})
+
+		It("should notify the observer of failed commands", func() {
+			bus.SendCall.Err = errors.New("some error")
+			_, err := session.GetTitle()
+			Expect(err).To(MatchError("some error"))
+			Expect(commands[0].Err).To(MatchError("some error"))
+			Expect(commands[0].Response).To(BeNil())
+		})
+
+		It("should observe commands sent with a context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := session.WithContext(ctx).Delete()
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(commands).To(HaveLen(1))
+			Expect(errors.Is(commands[0].Err, context.Canceled)).To(BeTrue())
+		})
+	})
+
+	Describe("#LogObserver", func() {
+		It("should write a human-readable line for each command", func() {
+			output := &bytes.Buffer{}
+			LogObserver(output).ObserveCommand(Command{
+				Method:   "POST",
+				Endpoint: "element",
+				Body:     Selector{"css selector", "#login"},
+				Start:    time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC),
+				Duration: 8 * time.Second,
+				Err:      errors.New("some error"),
+			})
+			Expect(output.String()).To(Equal(`10:30:00.000 POST element {"using":"css selector","value":"#login"} (8s): some error` + "\n"))
+		})
+	})
+
+	Describe("#JSONObserver", func() {
+		It("should write a JSON object for each command", func() {
+			output := &bytes.Buffer{}
+			response := "some title"
+			observer := JSONObserver(output)
+			observer.ObserveCommand(Command{Method: "GET", Endpoint: "title", Response: &response, Duration: 1500 * time.Microsecond})
+			observer.ObserveCommand(Command{Method: "DELETE", Endpoint: "", Err: errors.New("some error")})
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+			var entry map[string]interface{}
+			Expect(json.Unmarshal([]byte(lines[0]), &entry)).To(Succeed())
+			Expect(entry).To(HaveKeyWithValue("method", "GET"))
+			Expect(entry).To(HaveKeyWithValue("endpoint", "title"))
+			Expect(entry).To(HaveKeyWithValue("response", "some title"))
+			Expect(entry).To(HaveKeyWithValue("durationMs", 1.5))
+			Expect(entry).NotTo(HaveKey("error"))
+			Expect(json.Unmarshal([]byte(lines[1]), &entry)).To(Succeed())
+			Expect(entry).To(HaveKeyWithValue("error", "some error"))
+		})
+	})
+
+	Describe("CommandSummary", func() {
+		var summary *CommandSummary
+
+		BeforeEach(func() {
+			summary = NewCommandSummary()
+			summary.ObserveCommand(Command{Method: "GET", Endpoint: "title", Duration: time.Second})
+			summary.ObserveCommand(Command{Method: "POST", Endpoint: "element/some-id/click", Duration: 8 * time.Second})
+			summary.ObserveCommand(Command{Method: "GET", Endpoint: "url", Duration: 2 * time.Second})
+		})
+
+		It("should report the slowest commands", func() {
+			Expect(summary.Count()).To(Equal(3))
+			Expect(summary.Total()).To(Equal(11 * time.Second))
+			slowest := summary.Slowest(2)
+			Expect(slowest).To(HaveLen(2))
+			Expect(slowest[0].Endpoint).To(Equal("element/some-id/click"))
+			Expect(slowest[1].Endpoint).To(Equal("url"))
+			Expect(summary.Slowest(10)).To(HaveLen(3))
+		})
+
+		It("should format a report", func() {
+			Expect(summary.String()).To(Equal(strings.Join([]string{
+				"3 commands in 11s",
+				"  POST element/some-id/click (8s)",
+				"  GET url (2s)",
+				"  GET title (1s)",
+			}, "\n")))
+		})
+
+		It("should discard commands when reset", func() {
+			summary.Reset()
+			Expect(summary.Count()).To(BeZero())
+		})
+	})
+})
@@ -1,16 +1,27 @@
 package api
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
+	"math"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/sclevine/agouti/api/internal/bus"
 )
 
 type Session struct {
 	Bus
+
+	// W3C is true when the session communicates using the W3C WebDriver
+	// protocol rather than the JSON Wire Protocol.
+	W3C bool
+
+	// Capabilities are the capabilities returned by the WebDriver when the
+	// session was opened. They are nil for sessions created with New.
+	Capabilities map[string]interface{}
 }
 
 type Bus interface {
@@ -25,8 +36,8 @@ func NewWithClient(sessionURL string, client *http.Client) *Session {
 	if client == nil {
 		client = http.DefaultClient
 	}
-	busClient := &bus.Client{sessionURL, client}
-	return &Session{busClient}
+	busClient := &bus.Client{SessionURL: sessionURL, HTTPClient: client}
+	return &Session{Bus: busClient}
 }
 
 func Open(url string, capabilities map[string]interface{}) (*Session, error) {
@@ -34,11 +45,17 @@ func Open(url string, capabilities map[string]interface{}) (*Session, error) {
 }
 
 func OpenWithClient(url string, capabilities map[string]interface{}, client *http.Client) (*Session, error) {
-	busClient, err := bus.Connect(url, capabilities, client)
+	return OpenContext(context.Background(), url, capabilities, client)
+}
+
+// OpenContext is like OpenWithClient, but aborts opening the session when the
+// provided context is canceled or exceeds its deadline.
+func OpenContext(ctx context.Context, url string, capabilities map[string]interface{}, client *http.Client) (*Session, error) {
+	busClient, err := bus.ConnectContext(ctx, url, capabilities, client)
 	if err != nil {
 		return nil, err
 	}
-	return &Session{busClient}, nil
+	return &Session{Bus: busClient, W3C: busClient.W3C, Capabilities: busClient.Capabilities}, nil
 }
 
 func (s *Session) Delete() error {
@@ -48,6 +65,10 @@ func (s *Session) Delete() error {
 func (s *Session) GetElement(selector Selector) (*Element, error) {
 	var result elementResult
 
+	if s.W3C {
+		selector = selector.w3c()
+	}
+
 	if err := s.Send("POST", "element", selector, &result); err != nil {
 		return nil, err
 	}
@@ -58,6 +79,10 @@ func (s *Session) GetElement(selector Selector) (*Element, error) {
 func (s *Session) GetElements(selector Selector) ([]*Element, error) {
 	var results []elementResult
 
+	if s.W3C {
+		selector = selector.w3c()
+	}
+
 	if err := s.Send("POST", "elements", selector, &results); err != nil {
 		return nil, err
 	}
@@ -73,7 +98,12 @@ func (s *Session) GetElements(selector Selector) ([]*Element, error) {
 func (s *Session) GetActiveElement() (*Element, error) {
 	var result elementResult
 
-	if err := s.Send("POST", "element/active", nil, &result); err != nil {
+	method := "POST"
+	if s.W3C {
+		method = "GET"
+	}
+
+	if err := s.Send(method, "element/active", nil, &result); err != nil {
 		return nil, err
 	}
 
@@ -81,16 +111,26 @@ func (s *Session) GetActiveElement() (*Element, error) {
 }
 
 func (s *Session) GetWindow() (*Window, error) {
+	endpoint := "window_handle"
+	if s.W3C {
+		endpoint = "window"
+	}
+
 	var windowID string
-	if err := s.Send("GET", "window_handle", nil, &windowID); err != nil {
+	if err := s.Send("GET", endpoint, nil, &windowID); err != nil {
 		return nil, err
 	}
 	return &Window{windowID, s}, nil
 }
 
 func (s *Session) GetWindows() ([]*Window, error) {
+	endpoint := "window_handles"
+	if s.W3C {
+		endpoint = "window/handles"
+	}
+
 	var windowsID []string
-	if err := s.Send("GET", "window_handles", nil, &windowsID); err != nil {
+	if err := s.Send("GET", endpoint, nil, &windowsID); err != nil {
 		return nil, err
 	}
 
@@ -106,17 +146,25 @@ func (s *Session) SetWindow(window *Window) error {
 		return errors.New("nil window is invalid")
 	}
 
-	request := struct {
-		Name string `json:"name"`
-	}{window.ID}
-
-	return s.Send("POST", "window", request, nil)
+	return s.switchToWindow(window.ID)
 }
 
 func (s *Session) SetWindowByName(name string) error {
+	return s.switchToWindow(name)
+}
+
+func (s *Session) switchToWindow(nameOrHandle string) error {
+	if s.W3C {
+		request := struct {
+			Handle string `json:"handle"`
+		}{nameOrHandle}
+
+		return s.Send("POST", "window", request, nil)
+	}
+
 	request := struct {
 		Name string `json:"name"`
-	}{name}
+	}{nameOrHandle}
 
 	return s.Send("POST", "window", request, nil)
 }
@@ -201,6 +249,10 @@ func (s *Session) GetSource() (string, error) {
 }
 
 func (s *Session) MoveTo(region *Element, offset Offset) error {
+	if s.W3C {
+		return s.w3cMoveTo(region, offset)
+	}
+
 	request := map[string]interface{}{}
 
 	if region != nil {
@@ -220,10 +272,44 @@ func (s *Session) MoveTo(region *Element, offset Offset) error {
 	return s.Send("POST", "moveto", request, nil)
 }
 
+// JSON Wire offsets are relative to the top-left corner of the region, while
+// W3C offsets are relative to its center. When no offset is present, both
+// protocols move to the center of the region.
+func (s *Session) w3cMoveTo(region *Element, offset Offset) error {
+	var x, y int
+	if offset != nil {
+		x, y = offset.position()
+	}
+
+	actions := NewActions()
+	if region == nil {
+		actions.Pointer("mouse", MousePointer).Move(PointerOrigin, x, y, 0)
+		return s.PerformActions(actions)
+	}
+
+	if offset != nil {
+		width, height, err := region.GetSize()
+		if err != nil {
+			return err
+		}
+		if _, present := offset.x(); present {
+			x -= width / 2
+		}
+		if _, present := offset.y(); present {
+			y -= height / 2
+		}
+	}
+
+	actions.Pointer("mouse", MousePointer).Move(region, x, y, 0)
+	return s.PerformActions(actions)
+}
+
 func (s *Session) Frame(frame *Element) error {
 	var elementID interface{}
 
-	if frame != nil {
+	if frame != nil && s.W3C {
+		elementID = frame.w3cReference()
+	} else if frame != nil {
 		elementID = struct {
 			Element string `json:"ELEMENT"`
 		}{frame.ID}
@@ -250,7 +336,12 @@ func (s *Session) Execute(body string, arguments []interface{}, result interface
 		Args   []interface{} `json:"args"`
 	}{body, arguments}
 
-	if err := s.Send("POST", "execute", request, result); err != nil {
+	endpoint := "execute"
+	if s.W3C {
+		endpoint = "execute/sync"
+	}
+
+	if err := s.Send("POST", endpoint, request, result); err != nil {
 		return err
 	}
 
@@ -270,35 +361,60 @@ func (s *Session) Refresh() error {
 }
 
 func (s *Session) GetAlertText() (string, error) {
+	endpoint := "alert_text"
+	if s.W3C {
+		endpoint = "alert/text"
+	}
+
 	var text string
-	if err := s.Send("GET", "alert_text", nil, &text); err != nil {
+	if err := s.Send("GET", endpoint, nil, &text); err != nil {
 		return "", err
 	}
 	return text, nil
 }
 
 func (s *Session) SetAlertText(text string) error {
+	endpoint := "alert_text"
+	if s.W3C {
+		endpoint = "alert/text"
+	}
+
 	request := struct {
 		Text string `json:"text"`
 	}{text}
-	return s.Send("POST", "alert_text", request, nil)
+	return s.Send("POST", endpoint, request, nil)
 }
 
 func (s *Session) AcceptAlert() error {
+	if s.W3C {
+		return s.Send("POST", "alert/accept", nil, nil)
+	}
 	return s.Send("POST", "accept_alert", nil, nil)
 }
 
 func (s *Session) DismissAlert() error {
+	if s.W3C {
+		return s.Send("POST", "alert/dismiss", nil, nil)
+	}
 	return s.Send("POST", "dismiss_alert", nil, nil)
 }
 
+// W3C drivers that support logs (such as ChromeDriver) expose them as
+// Selenium extension endpoints.
+func (s *Session) logEndpoint(endpoint string) string {
+	if s.W3C {
+		return "se/" + endpoint
+	}
+	return endpoint
+}
+
 func (s *Session) NewLogs(logType string) ([]Log, error) {
 	request := struct {
 		Type string `json:"type"`
 	}{logType}
 
 	var logs []Log
-	if err := s.Send("POST", "log", request, &logs); err != nil {
+	if err := s.Send("POST", s.logEndpoint("log"), request, &logs); err != nil {
 		return nil, err
 	}
 	return logs, nil
@@ -306,17 +422,28 @@ func (s *Session) NewLogs(logType string) ([]Log, error) {
 
 func (s *Session) GetLogTypes() ([]string, error) {
 	var types []string
-	if err := s.Send("GET", "log/types", nil, &types); err != nil {
+	if err := s.Send("GET", s.logEndpoint("log/types"), nil, &types); err != nil {
 		return nil, err
 	}
 	return types, nil
 }
 
 func (s *Session) DoubleClick() error {
+	if s.W3C {
+		actions := NewActions()
+		actions.Pointer("mouse", MousePointer).Down(LeftButton).Up(LeftButton).Down(LeftButton).Up(LeftButton)
+		return s.PerformActions(actions)
+	}
 	return s.Send("POST", "doubleclick", nil, nil)
 }
 
 func (s *Session) Click(button Button) error {
+	if s.W3C {
+		actions := NewActions()
+		actions.Pointer("mouse", MousePointer).Down(button).Up(button)
+		return s.PerformActions(actions)
+	}
+
 	request := struct {
 		Button Button `json:"button"`
 	}{button}
@@ -324,6 +451,12 @@ func (s *Session) Click(button Button) error {
 }
 
 func (s *Session) ButtonDown(button Button) error {
+	if s.W3C {
+		actions := NewActions()
+		actions.Pointer("mouse", MousePointer).Down(button)
+		return s.PerformActions(actions)
+	}
+
 	request := struct {
 		Button Button `json:"button"`
 	}{button}
@@ -331,6 +464,12 @@ func (s *Session) ButtonDown(button Button) error {
 }
 
 func (s *Session) ButtonUp(button Button) error {
+	if s.W3C {
+		actions := NewActions()
+		actions.Pointer("mouse", MousePointer).Up(button)
+		return s.PerformActions(actions)
+	}
+
 	request := struct {
 		Button Button `json:"button"`
 	}{button}
@@ -338,6 +477,12 @@ func (s *Session) ButtonUp(button Button) error {
 }
 
 func (s *Session) TouchDown(x, y int) error {
+	if s.W3C {
+		actions := NewActions()
+		actions.Pointer("finger", TouchPointer).Move(ViewportOrigin, x, y, 0).Down(LeftButton)
+		return s.PerformActions(actions)
+	}
+
 	request := struct {
 		X int `json:"x"`
 		Y int `json:"y"`
@@ -346,6 +491,12 @@ func (s *Session) TouchDown(x, y int) error {
 }
 
 func (s *Session) TouchUp(x, y int) error {
+	if s.W3C {
+		actions := NewActions()
+		actions.Pointer("finger", TouchPointer).Move(ViewportOrigin, x, y, 0).Up(LeftButton)
+		return s.PerformActions(actions)
+	}
+
 	request := struct {
 		X int `json:"x"`
 		Y int `json:"y"`
@@ -354,6 +505,12 @@ func (s *Session) TouchUp(x, y int) error {
 }
 
 func (s *Session) TouchMove(x, y int) error {
+	if s.W3C {
+		actions := NewActions()
+		actions.Pointer("finger", TouchPointer).Move(ViewportOrigin, x, y, 0)
+		return s.PerformActions(actions)
+	}
+
 	request := struct {
 		X int `json:"x"`
 		Y int `json:"y"`
@@ -366,6 +523,12 @@ func (s *Session) TouchClick(element *Element) error {
 		return errors.New("nil element is invalid")
 	}
 
+	if s.W3C {
+		actions := NewActions()
+		actions.Pointer("finger", TouchPointer).Move(element, 0, 0, 0).Down(LeftButton).Up(LeftButton)
+		return s.PerformActions(actions)
+	}
+
 	request := struct {
 		Element string `json:"element"`
 	}{element.ID}
@@ -377,6 +540,14 @@ func (s *Session) TouchDoubleClick(element *Element) error {
 		return errors.New("nil element is invalid")
 	}
 
+	if s.W3C {
+		actions := NewActions()
+		actions.Pointer("finger", TouchPointer).Move(element, 0, 0, 0).
+			Down(LeftButton).Up(LeftButton).
+			Down(LeftButton).Up(LeftButton)
+		return s.PerformActions(actions)
+	}
+
 	request := struct {
 		Element string `json:"element"`
 	}{element.ID}
@@ -388,6 +559,13 @@ func (s *Session) TouchLongClick(element *Element) error {
 		return errors.New("nil element is invalid")
 	}
 
+	if s.W3C {
+		actions := NewActions()
+		actions.Pointer("finger", TouchPointer).Move(element, 0, 0, 0).
+			Down(LeftButton).Pause(longClickDuration).Up(LeftButton)
+		return s.PerformActions(actions)
+	}
+
 	request := struct {
 		Element string `json:"element"`
 	}{element.ID}
@@ -403,6 +581,22 @@ func (s *Session) TouchFlick(element *Element, offset Offset, speed Speed) error
 		return errors.New("element must be provided if offset is provided and vice versa")
 	}
 
+	if s.W3C {
+		if element == nil {
+			return errors.New("flick without an element is not supported by W3C WebDriver")
+		}
+		xOffset, yOffset := offset.position()
+		distance := math.Hypot(float64(xOffset), float64(yOffset))
+		var duration time.Duration
+		if speed.scalar() > 0 {
+			duration = time.Duration(distance / float64(speed.scalar()) * float64(time.Second))
+		}
+		actions := NewActions()
+		actions.Pointer("finger", TouchPointer).Move(element, 0, 0, 0).Down(LeftButton).
+			Move(PointerOrigin, xOffset, yOffset, duration).Up(LeftButton)
+		return s.PerformActions(actions)
+	}
+
 	var request interface{}
 	if element == nil {
 		xSpeed, ySpeed := speed.vector()
@@ -433,6 +627,18 @@ func (s *Session) TouchScroll(element *Element, offset Offset) error {
 	}
 
 	xOffset, yOffset := offset.position()
+
+	if s.W3C {
+		var origin Origin = PointerOrigin
+		if element.ID != "" {
+			origin = element
+		}
+		actions := NewActions()
+		actions.Pointer("finger", TouchPointer).Move(origin, 0, 0, 0).Down(LeftButton).
+			Move(PointerOrigin, xOffset, yOffset, 0).Up(LeftButton)
+		return s.PerformActions(actions)
+	}
+
 	request := struct {
 		Element string `json:"element,omitempty"`
 		XOffset int    `json:"xoffset"`
@@ -442,6 +648,12 @@ func (s *Session) TouchScroll(element *Element, offset Offset) error {
 }
 
 func (s *Session) Keys(text string) error {
+	if s.W3C {
+		actions := NewActions()
+		actions.Key("keyboard").Type(text)
+		return s.PerformActions(actions)
+	}
+
 	splitText := strings.Split(text, "")
 	request := struct {
 		Value []string `json:"value"`
@@ -449,15 +661,39 @@ func (s *Session) Keys(text string) error {
 	return s.Send("POST", "keys", request, nil)
 }
 
+func (s *Session) PerformActions(actions *Actions) error {
+	if actions == nil {
+		return errors.New("nil actions are invalid")
+	}
+	return s.Send("POST", "actions", actions, nil)
+}
+
+func (s *Session) ReleaseActions() error {
+	return s.Send("DELETE", "actions", nil, nil)
+}
+
 func (s *Session) DeleteLocalStorage() error {
+	if s.W3C {
+		return errors.New("local storage is not supported by W3C WebDriver")
+	}
 	return s.Send("DELETE", "local_storage", nil, nil)
 }
 
 func (s *Session) DeleteSessionStorage() error {
+	if s.W3C {
+		return errors.New("session storage is not supported by W3C WebDriver")
+	}
 	return s.Send("DELETE", "session_storage", nil, nil)
 }
 
 func (s *Session) SetImplicitWait(timeout int) error {
+	if s.W3C {
+		request := struct {
+			Implicit int `json:"implicit"`
+		}{timeout}
+		return s.Send("POST", "timeouts", request, nil)
+	}
+
 	request := struct {
 		MS int `json:"ms"`
 	}{timeout}
@@ -465,6 +701,13 @@ func (s *Session) SetImplicitWait(timeout int) error {
 }
 
 func (s *Session) SetPageLoad(timeout int) error {
+	if s.W3C {
+		request := struct {
+			PageLoad int `json:"pageLoad"`
+		}{timeout}
+		return s.Send("POST", "timeouts", request, nil)
+	}
+
 	request := struct {
 		MS   int    `json:"ms"`
 		Type string `json:"type"`
@@ -473,6 +716,13 @@ func (s *Session) SetPageLoad(timeout int) error {
 }
 
 func (s *Session) SetScriptTimeout(timeout int) error {
+	if s.W3C {
+		request := struct {
+			Script int `json:"script"`
+		}{timeout}
+		return s.Send("POST", "timeouts", request, nil)
+	}
+
 	request := struct {
 		MS int `json:"ms"`
 	}{timeout}
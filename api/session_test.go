@@ -18,7 +18,7 @@ var _ = Describe("Session", func() {
 
 	BeforeEach(func() {
 		bus = &mocks.Bus{}
-		session = &Session{bus}
+		session = &Session{Bus: bus}
 	})
 
 	Describe("#Delete", func() {
@@ -60,6 +60,25 @@ var _ = Describe("Session", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should translate JSON Wire selector strategies into CSS selectors", func() {
+				_, err := session.GetElement(Selector{"id", `some"id`})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"using": "css selector", "value": "[id=\"some\\\"id\"]"}`))
+			})
+
+			It("should return an element with the W3C element ID", func() {
+				bus.SendCall.Result = `{"element-6066-11e4-a52e-4f735466cecf": "some-id"}`
+				element, err := session.GetElement(Selector{"xpath", "//some"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(element.ID).To(Equal("some-id"))
+			})
+		})
 	})
 
 	Describe("#GetElements", func() {
@@ -88,6 +107,30 @@ var _ = Describe("Session", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should translate JSON Wire selector strategies into CSS selectors", func() {
+				_, err := session.GetElements(Selector{"class name", "some-class"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"using": "css selector", "value": ".some-class"}`))
+			})
+
+			It("should escape class names that are not valid CSS identifiers", func() {
+				_, err := session.GetElements(Selector{"class name", "1md:w-1/2"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"using": "css selector", "value": ".\\31 md\\:w-1\\/2"}`))
+			})
+
+			It("should not translate W3C selector strategies", func() {
+				_, err := session.GetElements(Selector{"link text", "some text"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"using": "link text", "value": "some text"}`))
+			})
+		})
 	})
 
 	Describe("#GetActiveElement", func() {
@@ -113,6 +156,19 @@ var _ = Describe("Session", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a GET to the element/active endpoint", func() {
+				_, err := session.GetActiveElement()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.Method).To(Equal("GET"))
+				Expect(bus.SendCall.Endpoint).To(Equal("element/active"))
+			})
+		})
 	})
 
 	Describe("#GetWindow", func() {
@@ -138,6 +194,21 @@ var _ = Describe("Session", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a GET to the window endpoint", func() {
+				bus.SendCall.Result = `"some-id"`
+				window, err := session.GetWindow()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.Method).To(Equal("GET"))
+				Expect(bus.SendCall.Endpoint).To(Equal("window"))
+				Expect(window.ID).To(Equal("some-id"))
+			})
+		})
 	})
 
 	Describe("#GetWindows", func() {
@@ -165,6 +236,19 @@ var _ = Describe("Session", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a GET to the window/handles endpoint", func() {
+				_, err := session.GetWindows()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.Method).To(Equal("GET"))
+				Expect(bus.SendCall.Endpoint).To(Equal("window/handles"))
+			})
+		})
 	})
 
 	Describe("#SetWindow", func() {
@@ -188,6 +272,19 @@ var _ = Describe("Session", func() {
 				Expect(session.SetWindow(&Window{})).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a POST to the window endpoint with the window handle", func() {
+				Expect(session.SetWindow(&Window{ID: "some-id"})).To(Succeed())
+				Expect(bus.SendCall.Method).To(Equal("POST"))
+				Expect(bus.SendCall.Endpoint).To(Equal("window"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"handle": "some-id"}`))
+			})
+		})
 	})
 
 	Describe("#SetWindowByName", func() {
@@ -204,6 +301,18 @@ var _ = Describe("Session", func() {
 				Expect(session.SetWindowByName("")).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a POST to the window endpoint with the name as a handle", func() {
+				Expect(session.SetWindowByName("some name")).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("window"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"handle": "some name"}`))
+			})
+		})
 	})
 
 	Describe("#DeleteWindow", func() {
@@ -467,6 +576,71 @@ var _ = Describe("Session", func() {
 				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"xoffset": 300, "yoffset": 400}`))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a POST to the actions endpoint", func() {
+				Expect(session.MoveTo(nil, XYOffset{X: 300, Y: 400})).To(Succeed())
+				Expect(bus.SendCall.Method).To(Equal("POST"))
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "pointer",
+					"id": "mouse",
+					"parameters": {"pointerType": "mouse"},
+					"actions": [{"type": "pointerMove", "origin": "pointer", "x": 300, "y": 400, "duration": 0}]
+				}]}`))
+			})
+
+			Context("when an element is provided without an offset", func() {
+				It("should move to the center of the element", func() {
+					element := &Element{ID: "some-id", Session: session}
+					Expect(session.MoveTo(element, nil)).To(Succeed())
+					Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+						"type": "pointer",
+						"id": "mouse",
+						"parameters": {"pointerType": "mouse"},
+						"actions": [{
+							"type": "pointerMove",
+							"origin": {"element-6066-11e4-a52e-4f735466cecf": "some-id"},
+							"x": 0,
+							"y": 0,
+							"duration": 0
+						}]
+					}]}`))
+				})
+			})
+
+			Context("when an element is provided with an offset", func() {
+				It("should move relative to the top-left corner of the element", func() {
+					bus.SendCall.Result = `{"x": 1, "y": 2, "width": 100, "height": 60}`
+					element := &Element{ID: "some-id", Session: session}
+					Expect(session.MoveTo(element, XYOffset{X: 10, Y: 20})).To(Succeed())
+					Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+						"type": "pointer",
+						"id": "mouse",
+						"parameters": {"pointerType": "mouse"},
+						"actions": [{
+							"type": "pointerMove",
+							"origin": {"element-6066-11e4-a52e-4f735466cecf": "some-id"},
+							"x": -40,
+							"y": -10,
+							"duration": 0
+						}]
+					}]}`))
+				})
+
+				Context("when retrieving the element size fails", func() {
+					It("should return an error", func() {
+						bus.SendCall.Err = errors.New("some error")
+						element := &Element{ID: "some-id", Session: session}
+						Expect(session.MoveTo(element, XOffset(10))).To(MatchError("some error"))
+					})
+				})
+			})
+		})
 	})
 
 	Describe("#Frame", func() {
@@ -492,6 +666,17 @@ var _ = Describe("Session", func() {
 				Expect(session.Frame(nil)).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should encode the frame as a W3C element reference", func() {
+				Expect(session.Frame(&Element{ID: "some-id"})).To(Succeed())
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"id": {"element-6066-11e4-a52e-4f735466cecf": "some-id"}}`))
+			})
+		})
 	})
 
 	Describe("#FrameParent", func() {
@@ -538,6 +723,18 @@ var _ = Describe("Session", func() {
 				Expect(session.Execute("", nil, nil)).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a POST to the execute/sync endpoint", func() {
+				Expect(session.Execute("some javascript code", nil, nil)).To(Succeed())
+				Expect(bus.SendCall.Method).To(Equal("POST"))
+				Expect(bus.SendCall.Endpoint).To(Equal("execute/sync"))
+			})
+		})
 	})
 
 	Describe("#Forward", func() {
@@ -607,6 +804,19 @@ var _ = Describe("Session", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a GET to the alert/text endpoint", func() {
+				bus.SendCall.Result = `"some text"`
+				Expect(session.GetAlertText()).To(Equal("some text"))
+				Expect(bus.SendCall.Method).To(Equal("GET"))
+				Expect(bus.SendCall.Endpoint).To(Equal("alert/text"))
+			})
+		})
 	})
 
 	Describe("#SetAlertText", func() {
@@ -623,6 +833,19 @@ var _ = Describe("Session", func() {
 				Expect(session.SetAlertText("some text")).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a POST to the alert/text endpoint", func() {
+				Expect(session.SetAlertText("some text")).To(Succeed())
+				Expect(bus.SendCall.Method).To(Equal("POST"))
+				Expect(bus.SendCall.Endpoint).To(Equal("alert/text"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"text": "some text"}`))
+			})
+		})
 	})
 
 	Describe("#AcceptAlert", func() {
@@ -638,6 +861,18 @@ var _ = Describe("Session", func() {
 				Expect(session.AcceptAlert()).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a POST to the alert/accept endpoint", func() {
+				Expect(session.AcceptAlert()).To(Succeed())
+				Expect(bus.SendCall.Method).To(Equal("POST"))
+				Expect(bus.SendCall.Endpoint).To(Equal("alert/accept"))
+			})
+		})
 	})
 
 	Describe("#DismissAlert", func() {
@@ -653,6 +888,18 @@ var _ = Describe("Session", func() {
 				Expect(session.DismissAlert()).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a POST to the alert/dismiss endpoint", func() {
+				Expect(session.DismissAlert()).To(Succeed())
+				Expect(bus.SendCall.Method).To(Equal("POST"))
+				Expect(bus.SendCall.Endpoint).To(Equal("alert/dismiss"))
+			})
+		})
 	})
 
 	Describe("#NewLogs", func() {
@@ -686,6 +933,20 @@ var _ = Describe("Session", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a POST to the se/log endpoint", func() {
+				_, err := session.NewLogs("browser")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.Method).To(Equal("POST"))
+				Expect(bus.SendCall.Endpoint).To(Equal("se/log"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"type": "browser"}`))
+			})
+		})
 	})
 
 	Describe("#GetLogTypes", func() {
@@ -710,6 +971,19 @@ var _ = Describe("Session", func() {
 				Expect(err).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a GET to the se/log/types endpoint", func() {
+				_, err := session.GetLogTypes()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bus.SendCall.Method).To(Equal("GET"))
+				Expect(bus.SendCall.Endpoint).To(Equal("se/log/types"))
+			})
+		})
 	})
 
 	Describe("#DoubleClick", func() {
@@ -725,6 +999,28 @@ var _ = Describe("Session", func() {
 				Expect(session.DoubleClick()).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send two clicks to the actions endpoint", func() {
+				Expect(session.DoubleClick()).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "pointer",
+					"id": "mouse",
+					"parameters": {"pointerType": "mouse"},
+					"actions": [
+						{"type": "pointerDown", "button": 0},
+						{"type": "pointerUp", "button": 0},
+						{"type": "pointerDown", "button": 0},
+						{"type": "pointerUp", "button": 0}
+					]
+				}]}`))
+			})
+		})
 	})
 
 	Describe("#Click", func() {
@@ -741,6 +1037,23 @@ var _ = Describe("Session", func() {
 				Expect(session.Click(RightButton)).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a click with the provided button to the actions endpoint", func() {
+				Expect(session.Click(RightButton)).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "pointer",
+					"id": "mouse",
+					"parameters": {"pointerType": "mouse"},
+					"actions": [{"type": "pointerDown", "button": 2}, {"type": "pointerUp", "button": 2}]
+				}]}`))
+			})
+		})
 	})
 
 	Describe("#ButtonDown", func() {
@@ -757,6 +1070,23 @@ var _ = Describe("Session", func() {
 				Expect(session.ButtonDown(RightButton)).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a pointer down action to the actions endpoint", func() {
+				Expect(session.ButtonDown(MiddleButton)).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "pointer",
+					"id": "mouse",
+					"parameters": {"pointerType": "mouse"},
+					"actions": [{"type": "pointerDown", "button": 1}]
+				}]}`))
+			})
+		})
 	})
 
 	Describe("#ButtonUp", func() {
@@ -773,6 +1103,23 @@ var _ = Describe("Session", func() {
 				Expect(session.ButtonUp(RightButton)).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a pointer up action to the actions endpoint", func() {
+				Expect(session.ButtonUp(MiddleButton)).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "pointer",
+					"id": "mouse",
+					"parameters": {"pointerType": "mouse"},
+					"actions": [{"type": "pointerUp", "button": 1}]
+				}]}`))
+			})
+		})
 	})
 
 	Describe("#TouchDown", func() {
@@ -789,6 +1136,26 @@ var _ = Describe("Session", func() {
 				Expect(session.TouchDown(100, 200)).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a touch pointer down action to the actions endpoint", func() {
+				Expect(session.TouchDown(100, 200)).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "pointer",
+					"id": "finger",
+					"parameters": {"pointerType": "touch"},
+					"actions": [
+						{"type": "pointerMove", "origin": "viewport", "x": 100, "y": 200, "duration": 0},
+						{"type": "pointerDown", "button": 0}
+					]
+				}]}`))
+			})
+		})
 	})
 
 	Describe("#TouchUp", func() {
@@ -843,6 +1210,27 @@ var _ = Describe("Session", func() {
 				Expect(session.TouchClick(nil)).To(MatchError("nil element is invalid"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send a touch tap on the element to the actions endpoint", func() {
+				Expect(session.TouchClick(&Element{ID: "some-id"})).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "pointer",
+					"id": "finger",
+					"parameters": {"pointerType": "touch"},
+					"actions": [
+						{"type": "pointerMove", "origin": {"element-6066-11e4-a52e-4f735466cecf": "some-id"}, "x": 0, "y": 0, "duration": 0},
+						{"type": "pointerDown", "button": 0},
+						{"type": "pointerUp", "button": 0}
+					]
+				}]}`))
+			})
+		})
 	})
 
 	Describe("#TouchDoubleClick", func() {
@@ -887,6 +1275,28 @@ var _ = Describe("Session", func() {
 				Expect(session.TouchLongClick(nil)).To(MatchError("nil element is invalid"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should hold the touch pointer down on the element", func() {
+				Expect(session.TouchLongClick(&Element{ID: "some-id"})).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "pointer",
+					"id": "finger",
+					"parameters": {"pointerType": "touch"},
+					"actions": [
+						{"type": "pointerMove", "origin": {"element-6066-11e4-a52e-4f735466cecf": "some-id"}, "x": 0, "y": 0, "duration": 0},
+						{"type": "pointerDown", "button": 0},
+						{"type": "pause", "duration": 1000},
+						{"type": "pointerUp", "button": 0}
+					]
+				}]}`))
+			})
+		})
 	})
 
 	Describe("#TouchFlick", func() {
@@ -974,6 +1384,35 @@ var _ = Describe("Session", func() {
 				Expect(session.TouchFlick(nil, nil, ScalarSpeed(0))).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should drag the touch pointer by the offset at the provided speed", func() {
+				Expect(session.TouchFlick(&Element{ID: "some-id"}, XYOffset{X: 300, Y: 400}, ScalarSpeed(1000))).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "pointer",
+					"id": "finger",
+					"parameters": {"pointerType": "touch"},
+					"actions": [
+						{"type": "pointerMove", "origin": {"element-6066-11e4-a52e-4f735466cecf": "some-id"}, "x": 0, "y": 0, "duration": 0},
+						{"type": "pointerDown", "button": 0},
+						{"type": "pointerMove", "origin": "pointer", "x": 300, "y": 400, "duration": 500},
+						{"type": "pointerUp", "button": 0}
+					]
+				}]}`))
+			})
+
+			Context("when no element is provided", func() {
+				It("should return an error", func() {
+					err := session.TouchFlick(nil, nil, VectorSpeed{X: 1, Y: 1})
+					Expect(err).To(MatchError("flick without an element is not supported by W3C WebDriver"))
+				})
+			})
+		})
 	})
 
 	Describe("#TouchScroll", func() {
@@ -1018,6 +1457,28 @@ var _ = Describe("Session", func() {
 				Expect(session.TouchScroll(nil, offset)).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should drag the touch pointer by the offset from the element", func() {
+				Expect(session.TouchScroll(&Element{ID: "some-id"}, XYOffset{X: 100, Y: 200})).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "pointer",
+					"id": "finger",
+					"parameters": {"pointerType": "touch"},
+					"actions": [
+						{"type": "pointerMove", "origin": {"element-6066-11e4-a52e-4f735466cecf": "some-id"}, "x": 0, "y": 0, "duration": 0},
+						{"type": "pointerDown", "button": 0},
+						{"type": "pointerMove", "origin": "pointer", "x": 100, "y": 200, "duration": 0},
+						{"type": "pointerUp", "button": 0}
+					]
+				}]}`))
+			})
+		})
 	})
 
 	Describe("#Keys", func() {
@@ -1034,6 +1495,70 @@ var _ = Describe("Session", func() {
 				Expect(session.Keys("text")).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should successfully send key presses to the actions endpoint", func() {
+				Expect(session.Keys("ab")).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+					"type": "key",
+					"id": "keyboard",
+					"actions": [
+						{"type": "keyDown", "value": "a"},
+						{"type": "keyUp", "value": "a"},
+						{"type": "keyDown", "value": "b"},
+						{"type": "keyUp", "value": "b"}
+					]
+				}]}`))
+			})
+		})
+	})
+
+	Describe("#PerformActions", func() {
+		It("should successfully send a POST request to the actions endpoint", func() {
+			actions := NewActions()
+			actions.Key("keyboard").Down("a")
+			Expect(session.PerformActions(actions)).To(Succeed())
+			Expect(bus.SendCall.Method).To(Equal("POST"))
+			Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+			Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"actions": [{
+				"type": "key",
+				"id": "keyboard",
+				"actions": [{"type": "keyDown", "value": "a"}]
+			}]}`))
+		})
+
+		Context("when the actions are nil", func() {
+			It("should return an error", func() {
+				Expect(session.PerformActions(nil)).To(MatchError("nil actions are invalid"))
+			})
+		})
+
+		Context("when the bus indicates a failure", func() {
+			It("should return an error", func() {
+				bus.SendCall.Err = errors.New("some error")
+				Expect(session.PerformActions(NewActions())).To(MatchError("some error"))
+			})
+		})
+	})
+
+	Describe("#ReleaseActions", func() {
+		It("should successfully send a DELETE request to the actions endpoint", func() {
+			Expect(session.ReleaseActions()).To(Succeed())
+			Expect(bus.SendCall.Method).To(Equal("DELETE"))
+			Expect(bus.SendCall.Endpoint).To(Equal("actions"))
+		})
+
+		Context("when the bus indicates a failure", func() {
+			It("should return an error", func() {
+				bus.SendCall.Err = errors.New("some error")
+				Expect(session.ReleaseActions()).To(MatchError("some error"))
+			})
+		})
 	})
 
 	Describe("#DeleteLocalStorage", func() {
@@ -1049,6 +1574,17 @@ var _ = Describe("Session", func() {
 				Expect(session.DeleteLocalStorage()).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should return an error without sending a request", func() {
+				Expect(session.DeleteLocalStorage()).To(MatchError("local storage is not supported by W3C WebDriver"))
+				Expect(bus.SendCall.Method).To(BeEmpty())
+			})
+		})
 	})
 
 	Describe("#DeleteSessionStorage", func() {
@@ -1064,5 +1600,70 @@ var _ = Describe("Session", func() {
 				Expect(session.DeleteSessionStorage()).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				session.W3C = true
+			})
+
+			It("should return an error without sending a request", func() {
+				Expect(session.DeleteSessionStorage()).To(MatchError("session storage is not supported by W3C WebDriver"))
+				Expect(bus.SendCall.Method).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("#SetImplicitWait", func() {
+		It("should successfully send a POST to the timeouts/implicit_wait endpoint", func() {
+			Expect(session.SetImplicitWait(100)).To(Succeed())
+			Expect(bus.SendCall.Method).To(Equal("POST"))
+			Expect(bus.SendCall.Endpoint).To(Equal("timeouts/implicit_wait"))
+			Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"ms": 100}`))
+		})
+
+		Context("when the session uses the W3C protocol", func() {
+			It("should successfully send a POST to the timeouts endpoint", func() {
+				session.W3C = true
+				Expect(session.SetImplicitWait(100)).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("timeouts"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"implicit": 100}`))
+			})
+		})
+	})
+
+	Describe("#SetPageLoad", func() {
+		It("should successfully send a POST to the timeouts endpoint", func() {
+			Expect(session.SetPageLoad(100)).To(Succeed())
+			Expect(bus.SendCall.Method).To(Equal("POST"))
+			Expect(bus.SendCall.Endpoint).To(Equal("timeouts"))
+			Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"ms": 100, "type": "page load"}`))
+		})
+
+		Context("when the session uses the W3C protocol", func() {
+			It("should send the W3C page load timeout", func() {
+				session.W3C = true
+				Expect(session.SetPageLoad(100)).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("timeouts"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"pageLoad": 100}`))
+			})
+		})
+	})
+
+	Describe("#SetScriptTimeout", func() {
+		It("should successfully send a POST to the timeouts/async_script endpoint", func() {
+			Expect(session.SetScriptTimeout(100)).To(Succeed())
+			Expect(bus.SendCall.Method).To(Equal("POST"))
+			Expect(bus.SendCall.Endpoint).To(Equal("timeouts/async_script"))
+			Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"ms": 100}`))
+		})
+
+		Context("when the session uses the W3C protocol", func() {
+			It("should send the W3C script timeout", func() {
+				session.W3C = true
+				Expect(session.SetScriptTimeout(100)).To(Succeed())
+				Expect(bus.SendCall.Endpoint).To(Equal("timeouts"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"script": 100}`))
+			})
+		})
 	})
 })
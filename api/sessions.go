@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SessionInfo describes an active session opened by a WebDriver.
+type SessionInfo struct {
+	Session      *Session
+	Capabilities map[string]interface{}
+	Created      time.Time
+
+	// LastUsed is the time that the most recent command was sent or
+	// completed by the session, or any copy of it (ex. made using
+	// WithContext).
+	LastUsed time.Time
+}
+
+type trackedSession struct {
+	session *Session
+	created time.Time
+
+	// done is closed when the WebDriver process that the session was opened
+	// on exits.
+	done <-chan struct{}
+
+	mutex    sync.Mutex
+	lastUsed time.Time
+	inFlight int
+	exitErr  error
+}
+
+func (t *trackedSession) info() SessionInfo {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return SessionInfo{
+		Session:      t.session,
+		Capabilities: t.session.Capabilities,
+		Created:      t.created,
+		LastUsed:     t.lastUsed,
+	}
+}
+
+func (t *trackedSession) begin() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.inFlight++
+	t.lastUsed = time.Now()
+}
+
+func (t *trackedSession) end() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.inFlight--
+	t.lastUsed = time.Now()
+}
+
+// idle returns true if the session has no commands in flight and has not
+// been used for at least the provided duration.
+func (t *trackedSession) idle(duration time.Duration) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.inFlight == 0 && time.Since(t.lastUsed) >= duration
+}
+
+func (t *trackedSession) exited(exitErr error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.exitErr = exitErr
+}
+
+func (t *trackedSession) exitError() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.exitErr
+}
+
+// trackingBus records the use of a session opened by a WebDriver, and
+// forgets the session when it is deleted.
+type trackingBus struct {
+	Bus
+	webDriver *WebDriver
+	tracked   *trackedSession
+}
+
+func (b *trackingBus) Send(method, endpoint string, body, result interface{}) error {
+	b.tracked.begin()
+	return b.track(method, endpoint, b.Bus.Send(method, endpoint, body, result))
+}
+
+func (b *trackingBus) SendContext(ctx context.Context, method, endpoint string, body, result interface{}) error {
+	b.tracked.begin()
+	return b.track(method, endpoint, (&contextBus{b.Bus, ctx}).Send(method, endpoint, body, result))
+}
+
+func (b *trackingBus) track(method, endpoint string, err error) error {
+	b.tracked.end()
+	if err == nil && method == "DELETE" && endpoint == "" {
+		b.webDriver.forget(b.tracked)
+	}
+	if err != nil {
+		if exitErr := b.tracked.exitError(); exitErr != nil {
+			return fmt.Errorf("%w: %w", exitErr, err)
+		}
+	}
+	return err
+}
+
+func (w *WebDriver) track(session *Session) *Session {
+	now := time.Now()
+	tracked := &trackedSession{created: now, lastUsed: now, done: w.service.Done()}
+	tracked.session = &Session{
+		Bus:          &trackingBus{session.Bus, w, tracked},
+		W3C:          session.W3C,
+		Capabilities: session.Capabilities,
+	}
+
+	w.sessionsMutex.Lock()
+	defer w.sessionsMutex.Unlock()
+	w.sessions = append(w.sessions, tracked)
+	return tracked.session
+}
+
+func (w *WebDriver) forget(tracked *trackedSession) {
+	w.sessionsMutex.Lock()
+	defer w.sessionsMutex.Unlock()
+	for i, existing := range w.sessions {
+		if existing == tracked {
+			w.sessions = append(w.sessions[:i], w.sessions[i+1:]...)
+			return
+		}
+	}
+}
+
+// Sessions returns the sessions opened by the WebDriver that have not been
+// deleted, in the order that they were opened.
+func (w *WebDriver) Sessions() []SessionInfo {
+	w.sessionsMutex.Lock()
+	defer w.sessionsMutex.Unlock()
+	var sessions []SessionInfo
+	for _, tracked := range w.sessions {
+		sessions = append(sessions, tracked.info())
+	}
+	return sessions
+}
+
+// DeleteIdleSessions deletes the sessions opened by the WebDriver that have
+// not been used for at least the provided duration, and returns the number
+// of sessions that were deleted. Sessions that are waiting for the response
+// to a command are never deleted.
+func (w *WebDriver) DeleteIdleSessions(idle time.Duration) int {
+	w.sessionsMutex.Lock()
+	sessions := append([]*trackedSession(nil), w.sessions...)
+	w.sessionsMutex.Unlock()
+
+	deleted := 0
+	for _, tracked := range sessions {
+		if tracked.idle(idle) && tracked.session.Delete() == nil {
+			deleted++
+		}
+	}
+	return deleted
+}
+
+func (w *WebDriver) startReaper() {
+	if w.IdleTimeout <= 0 {
+		return
+	}
+
+	w.watch(func(stop <-chan struct{}) {
+		ticker := time.NewTicker(w.IdleTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.DeleteIdleSessions(w.IdleTimeout)
+			case <-stop:
+				return
+			}
+		}
+	})
+}
+
+// watch runs the provided function in the background until the WebDriver
+// is stopped.
+func (w *WebDriver) watch(watcher func(stop <-chan struct{})) {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	w.sessionsMutex.Lock()
+	w.stopWatchers = append(w.stopWatchers, func() {
+		close(stop)
+		<-stopped
+	})
+	w.sessionsMutex.Unlock()
+
+	go func() {
+		defer close(stopped)
+		watcher(stop)
+	}()
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+
+	"github.com/sclevine/agouti/api/internal/service"
+)
+
+// KillDrivers immediately kills the processes of all WebDriver instances that
+// were started but not stopped, along with the processes that they started
+// (ex. browsers) if KillDriversOnInterrupt was called before they started. It
+// is intended for cleaning up after a test run that is interrupted, as
+// sessions are not deleted.
+func KillDrivers() {
+	service.KillAll()
+}
+
+// KillDriversOnInterrupt starts WebDriver processes that are started later in
+// their own process group, so that Stop also stops the processes that they
+// start, and calls KillDrivers when the process receives SIGINT or SIGTERM.
+// See agouti.KillDriversOnInterrupt.
+func KillDriversOnInterrupt() {
+	service.KillAllOnInterrupt()
+}
+
+// Healthy returns true if the WebDriver process is running and responds to
+// status requests within the Timeout.
+func (w *WebDriver) Healthy() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), w.Timeout)
+	defer cancel()
+	return w.service.Healthy(ctx)
+}
+
+// Done returns a channel that is closed when the WebDriver process exits, or
+// nil if the WebDriver is not started or does not manage a process. If the
+// process is restarted (see RestartOnCrash), Done returns a new channel.
+func (w *WebDriver) Done() <-chan struct{} {
+	return w.service.Done()
+}
+
+// Err returns an error describing how the WebDriver process exited if it
+// exited while the WebDriver was started, or nil otherwise.
+func (w *WebDriver) Err() error {
+	return w.service.Err()
+}
+
+// startSupervisor watches for the WebDriver process to exit, so that the
+// sessions opened on it return errors that describe the exit, and so that
+// the process may be restarted.
+func (w *WebDriver) startSupervisor() {
+	w.watch(func(stop <-chan struct{}) {
+		for {
+			done := w.service.Done()
+			if done == nil {
+				return
+			}
+
+			select {
+			case <-done:
+			case <-stop:
+				return
+			}
+
+			exitErr := w.service.Err()
+			if exitErr == nil {
+				return
+			}
+			w.exited(done, exitErr)
+
+			if !w.RestartOnCrash || w.restart() != nil {
+				return
+			}
+		}
+	})
+}
+
+func (w *WebDriver) exited(done <-chan struct{}, exitErr error) {
+	w.sessionsMutex.Lock()
+	defer w.sessionsMutex.Unlock()
+	var sessions []*trackedSession
+	for _, tracked := range w.sessions {
+		if tracked.done == done {
+			tracked.exited(exitErr)
+		} else {
+			sessions = append(sessions, tracked)
+		}
+	}
+	w.sessions = sessions
+}
+
+func (w *WebDriver) restart() error {
+	w.service.Stop()
+	return w.launch(func() error {
+		return w.service.WaitForBoot(w.Timeout)
+	})
+}
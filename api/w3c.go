@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	w3cElementKey = "element-6066-11e4-a52e-4f735466cecf"
+
+	// longClickDuration is how long a W3C long click holds the pointer down
+	longClickDuration = time.Second
+)
+
+func (e *Element) w3cReference() map[string]string {
+	return map[string]string{w3cElementKey: e.ID}
+}
+
+// W3C drivers only support the css selector, link text, partial link text,
+// tag name, and xpath strategies. Other JSON Wire strategies are translated
+// into equivalent CSS selectors.
+func (s Selector) w3c() Selector {
+	switch s.Using {
+	case "id":
+		return Selector{"css selector", fmt.Sprintf("[id=%s]", cssString(s.Value))}
+	case "name":
+		return Selector{"css selector", fmt.Sprintf("[name=%s]", cssString(s.Value))}
+	case "class name":
+		return Selector{"css selector", "." + cssIdentifier(s.Value)}
+	}
+	return s
+}
+
+func cssString(value string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + escaper.Replace(value) + `"`
+}
+
+// cssIdentifier escapes the value for use as a CSS identifier (ex. a class
+// name), as specified by CSS.escape.
+func cssIdentifier(value string) string {
+	if value == "-" {
+		return `\-`
+	}
+	var escaped strings.Builder
+	for i, char := range value {
+		switch {
+		case char == 0:
+			escaped.WriteRune('\uFFFD')
+		case char < 0x20 || char == 0x7F,
+			char >= '0' && char <= '9' && (i == 0 || i == 1 && value[0] == '-'):
+			fmt.Fprintf(&escaped, `\%x `, char)
+		case char >= 0x80, char == '-', char == '_',
+			char >= '0' && char <= '9', char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z':
+			escaped.WriteRune(char)
+		default:
+			escaped.WriteRune('\\')
+			escaped.WriteRune(char)
+		}
+	}
+	return escaped.String()
+}
@@ -1,8 +1,12 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sclevine/agouti/api/internal/service"
@@ -12,15 +16,55 @@ type WebDriver struct {
 	Timeout    time.Duration
 	Debug      bool
 	HTTPClient *http.Client
-	service    driverService
-	sessions   []*Session
+
+	// IdleTimeout, if positive, is how long a session may go unused before it
+	// is deleted by the WebDriver. Idle sessions are checked for while the
+	// WebDriver is started.
+	IdleTimeout time.Duration
+
+	// Stdout and Stderr, if not nil, receive the output of the WebDriver
+	// process. If LogDir is not empty, the output is also written to a file
+	// in LogDir. The most recent output is included in errors returned when
+	// the process fails to boot or a session fails to open.
+	Stdout io.Writer
+	Stderr io.Writer
+	LogDir string
+
+	// StartRetries is how many times the WebDriver process is started again,
+	// on a new port, if it exits before it boots or fails to bind to its port.
+	StartRetries int
+
+	// Host is the address that the WebDriver process is started on, and
+	// MinPort and MaxPort restrict the port that it is started on. By default,
+	// any free port on 127.0.0.1 is used.
+	Host    string
+	MinPort int
+	MaxPort int
+
+	// RestartOnCrash specifies that the WebDriver process is restarted if it
+	// exits while the WebDriver is started. Sessions opened before the process
+	// exited cannot be used after it is restarted.
+	RestartOnCrash bool
+
+	service driverService
+
+	sessionsMutex sync.Mutex
+	sessions      []*trackedSession
+	stopWatchers  []func()
 }
 
 type driverService interface {
 	URL() string
+	SetOutput(stdout, stderr io.Writer, logDir string)
+	SetAddress(host string, minPort, maxPort int)
+	RecentOutput() string
 	Start(debug bool) error
 	Stop() error
+	Done() <-chan struct{}
+	Err() error
+	Healthy(ctx context.Context) bool
 	WaitForBoot(timeout time.Duration) error
+	WaitForBootContext(ctx context.Context) error
 }
 
 func NewWebDriver(url string, command []string) *WebDriver {
@@ -30,9 +74,23 @@ func NewWebDriver(url string, command []string) *WebDriver {
 	}
 
 	return &WebDriver{
-		Timeout: 10 * time.Second,
-		service: driverService,
+		Timeout:      10 * time.Second,
+		StartRetries: 2,
+		service:      driverService,
+	}
+}
+
+// NewRemoteWebDriver returns a WebDriver for a WebDriver service that is
+// already running at the provided URL (ex. a Selenium Grid hub), so no
+// process is started or stopped. Start waits for the service to respond to
+// status requests, and Stop deletes the sessions opened by the WebDriver.
+func NewRemoteWebDriver(url string) *WebDriver {
+	webDriver := &WebDriver{Timeout: 10 * time.Second}
+	webDriver.service = &service.Remote{
+		RemoteURL: url,
+		Client:    func() *http.Client { return webDriver.HTTPClient },
 	}
+	return webDriver
 }
 
 func (w *WebDriver) URL() string {
@@ -40,40 +98,116 @@ func (w *WebDriver) URL() string {
 }
 
 func (w *WebDriver) Open(desiredCapabilites map[string]interface{}) (*Session, error) {
+	return w.OpenContext(context.Background(), desiredCapabilites)
+}
+
+// OpenContext is like Open, but aborts opening the session when the provided
+// context is canceled or exceeds its deadline.
+func (w *WebDriver) OpenContext(ctx context.Context, desiredCapabilites map[string]interface{}) (*Session, error) {
+	if err := w.service.Err(); err != nil {
+		return nil, w.withOutput(err)
+	}
 	url := w.service.URL()
 	if url == "" {
 		return nil, fmt.Errorf("service not started")
 	}
 
-	session, err := OpenWithClient(url, desiredCapabilites, w.HTTPClient)
+	session, err := OpenContext(ctx, url, desiredCapabilites, w.HTTPClient)
 	if err != nil {
-		return nil, err
+		return nil, w.withOutput(err)
 	}
 
-	w.sessions = append(w.sessions, session)
-	return session, nil
+	return w.track(session), nil
 }
 
 func (w *WebDriver) Start() error {
-	if err := w.service.Start(w.Debug); err != nil {
-		return fmt.Errorf("failed to start service: %s", err)
-	}
+	return w.start(func() error {
+		return w.service.WaitForBoot(w.Timeout)
+	})
+}
 
-	if err := w.service.WaitForBoot(w.Timeout); err != nil {
-		w.service.Stop()
+// StartContext is like Start, but stops waiting for the service to boot when
+// the provided context is canceled or exceeds its deadline. The Timeout still
+// applies if it is shorter than the deadline of the context.
+func (w *WebDriver) StartContext(ctx context.Context) error {
+	return w.start(func() error {
+		ctx, cancel := context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+		return w.service.WaitForBootContext(ctx)
+	})
+}
+
+func (w *WebDriver) start(waitForBoot func() error) error {
+	if err := w.launch(waitForBoot); err != nil {
 		return err
 	}
 
+	w.startReaper()
+	w.startSupervisor()
 	return nil
 }
 
+// launch starts the service and waits for it to boot. The service is started
+// again on a new port if it exits before it boots (ex. because another process
+// bound to its port first), up to StartRetries times.
+func (w *WebDriver) launch(waitForBoot func() error) error {
+	for attempt := 0; ; attempt++ {
+		w.service.SetOutput(w.Stdout, w.Stderr, w.LogDir)
+		w.service.SetAddress(w.Host, w.MinPort, w.MaxPort)
+		if err := w.service.Start(w.Debug); err != nil {
+			return fmt.Errorf("failed to start service: %w", err)
+		}
+
+		err := waitForBoot()
+		if err == nil {
+			return nil
+		}
+		err = w.withOutput(err)
+		retry := attempt < w.StartRetries && w.bindFailed()
+		w.service.Stop()
+		if !retry {
+			return err
+		}
+	}
+}
+
+func (w *WebDriver) bindFailed() bool {
+	output := strings.ToLower(w.service.RecentOutput())
+	return w.service.Err() != nil || strings.Contains(output, "address already in use")
+}
+
+// withOutput adds the most recent output of the WebDriver process to err.
+func (w *WebDriver) withOutput(err error) error {
+	output := w.service.RecentOutput()
+	if output == "" {
+		return err
+	}
+	return fmt.Errorf("%w\nWebDriver output:\n%s", err, output)
+}
+
+// Stop deletes the sessions opened by the WebDriver and stops the service.
+// Sessions that were already deleted are ignored.
 func (w *WebDriver) Stop() error {
-	for _, session := range w.sessions {
-		session.Delete()
+	w.sessionsMutex.Lock()
+	stopWatchers := w.stopWatchers
+	w.stopWatchers = nil
+	w.sessionsMutex.Unlock()
+
+	for _, stopWatcher := range stopWatchers {
+		stopWatcher()
+	}
+
+	w.sessionsMutex.Lock()
+	sessions := w.sessions
+	w.sessions = nil
+	w.sessionsMutex.Unlock()
+
+	for _, tracked := range sessions {
+		tracked.session.Delete()
 	}
 
 	if err := w.service.Stop(); err != nil {
-		return fmt.Errorf("failed to stop service: %s", err)
+		return fmt.Errorf("failed to stop service: %w", err)
 	}
 
 	return nil
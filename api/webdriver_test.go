@@ -1,10 +1,17 @@
 package api_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -57,11 +64,24 @@ var _ = Describe("WebDriver", func() {
 		It("should successfully return a session with the desired capabilities", func() {
 			session, err := webDriver.Open(map[string]interface{}{"some": "capability"})
 			Expect(err).NotTo(HaveOccurred())
-			Expect(requestBody).To(Equal(`{"desiredCapabilities":{"some":"capability"}}`))
+			Expect(requestBody).To(Equal(`{"desiredCapabilities":{"some":"capability"},"capabilities":{"alwaysMatch":{}}}`))
 			responseBody = `{"value": "some title"}`
 			Expect(session.GetTitle()).To(Equal("some title"))
 		})
 
+		It("should return a JSON Wire session when the WebDriver responds with a JSON Wire session", func() {
+			session, err := webDriver.Open(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(session.W3C).To(BeFalse())
+		})
+
+		It("should return a W3C session when the WebDriver responds with a W3C session", func() {
+			responseBody = `{"value": {"sessionId": "some-id", "capabilities": {}}}`
+			session, err := webDriver.Open(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(session.W3C).To(BeTrue())
+		})
+
 		Context("when the WebDriver is stopped", func() {
 			It("should delete the opened session stored by the WebDriver", func() {
 				_, err := webDriver.Open(nil)
@@ -73,6 +93,34 @@ var _ = Describe("WebDriver", func() {
 			})
 		})
 
+		Context("when sessions are opened concurrently", func() {
+			It("should delete each opened session once when the WebDriver is stopped", func() {
+				var deleted int32
+				server.Config.Handler = http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+					if request.Method == "DELETE" {
+						atomic.AddInt32(&deleted, 1)
+					}
+					response.Write([]byte(`{"sessionId": "some-id"}`))
+				})
+
+				var wg sync.WaitGroup
+				for i := 0; i < 8; i++ {
+					wg.Add(1)
+					go func() {
+						defer GinkgoRecover()
+						defer wg.Done()
+						_, err := webDriver.Open(nil)
+						Expect(err).NotTo(HaveOccurred())
+					}()
+				}
+				wg.Wait()
+
+				Expect(webDriver.Stop()).To(Succeed())
+				Expect(webDriver.Stop()).To(Succeed())
+				Expect(atomic.LoadInt32(&deleted)).To(BeEquivalentTo(8))
+			})
+		})
+
 		Context("when the WebDriver is not running", func() {
 			It("should return an error", func() {
 				service.URLCall.ReturnURL = ""
@@ -87,6 +135,13 @@ var _ = Describe("WebDriver", func() {
 				_, err := webDriver.Open(nil)
 				Expect(err).To(MatchError("failed to retrieve a session ID"))
 			})
+
+			It("should include the recent output of the WebDriver service in the error", func() {
+				responseBody = `{"sessionId": ""}`
+				service.RecentOutputCall.ReturnOutput = "some output"
+				_, err := webDriver.Open(nil)
+				Expect(err).To(MatchError("failed to retrieve a session ID\nWebDriver output:\nsome output"))
+			})
 		})
 
 		Context("when a custom HTTP client is set", func() {
@@ -103,6 +158,251 @@ var _ = Describe("WebDriver", func() {
 		})
 	})
 
+	Describe("#Sessions", func() {
+		var (
+			server  *httptest.Server
+			mutex   sync.Mutex
+			deleted []string
+		)
+
+		BeforeEach(func() {
+			deleted = nil
+			server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				if request.Method == "DELETE" {
+					mutex.Lock()
+					deleted = append(deleted, request.URL.Path)
+					mutex.Unlock()
+				}
+				response.Write([]byte(`{"value": {"sessionId": "some-id", "capabilities": {"browserName": "some-browser"}}}`))
+			}))
+			service.URLCall.ReturnURL = server.URL
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		deletedSessions := func() []string {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return append([]string(nil), deleted...)
+		}
+
+		It("should return the sessions that have not been deleted", func() {
+			start := time.Now()
+			first, err := webDriver.Open(nil)
+			Expect(err).NotTo(HaveOccurred())
+			second, err := webDriver.Open(nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			sessions := webDriver.Sessions()
+			Expect(sessions).To(HaveLen(2))
+			Expect(sessions[0].Session).To(BeIdenticalTo(first))
+			Expect(sessions[0].Capabilities).To(Equal(map[string]interface{}{"browserName": "some-browser"}))
+			Expect(sessions[0].Created).To(BeTemporally("~", start, time.Second))
+			Expect(sessions[1].Session).To(BeIdenticalTo(second))
+
+			Expect(first.Delete()).To(Succeed())
+			sessions = webDriver.Sessions()
+			Expect(sessions).To(HaveLen(1))
+			Expect(sessions[0].Session).To(BeIdenticalTo(second))
+		})
+
+		It("should record when each session was last used by any copy of the session", func() {
+			session, err := webDriver.Open(nil)
+			Expect(err).NotTo(HaveOccurred())
+			created := webDriver.Sessions()[0].LastUsed
+
+			time.Sleep(10 * time.Millisecond)
+			Expect(session.WithContext(context.Background()).Send("GET", "url", nil, nil)).To(Succeed())
+			Expect(webDriver.Sessions()[0].LastUsed).To(BeTemporally(">", created))
+		})
+
+		It("should not delete sessions that were already deleted when the WebDriver is stopped", func() {
+			first, _ := webDriver.Open(nil)
+			webDriver.Open(nil)
+			Expect(first.WithContext(context.Background()).Delete()).To(Succeed())
+			Expect(webDriver.Stop()).To(Succeed())
+			Expect(deletedSessions()).To(HaveLen(2))
+			Expect(webDriver.Sessions()).To(BeEmpty())
+		})
+
+		Describe("#DeleteIdleSessions", func() {
+			It("should delete sessions that have not been used for the provided duration", func() {
+				idle, _ := webDriver.Open(nil)
+				time.Sleep(50 * time.Millisecond)
+				active, _ := webDriver.Open(nil)
+
+				Expect(webDriver.DeleteIdleSessions(40 * time.Millisecond)).To(Equal(1))
+				Expect(deletedSessions()).To(Equal([]string{"/session/some-id"}))
+				sessions := webDriver.Sessions()
+				Expect(sessions).To(HaveLen(1))
+				Expect(sessions[0].Session).To(BeIdenticalTo(active))
+				Expect(sessions[0].Session).NotTo(BeIdenticalTo(idle))
+			})
+
+			It("should not delete sessions that are waiting for a command to complete", func() {
+				release := make(chan struct{})
+				server.Config.Handler = http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+					if request.URL.Path == "/session/some-id/slow" {
+						<-release
+					}
+					response.Write([]byte(`{"value": {"sessionId": "some-id"}}`))
+				})
+				session, _ := webDriver.Open(nil)
+
+				sent := make(chan error)
+				go func() {
+					sent <- session.Send("GET", "slow", nil, nil)
+				}()
+				time.Sleep(50 * time.Millisecond)
+
+				Expect(webDriver.DeleteIdleSessions(40 * time.Millisecond)).To(Equal(0))
+				Expect(webDriver.Sessions()).To(HaveLen(1))
+				close(release)
+				Expect(<-sent).To(Succeed())
+				Expect(webDriver.DeleteIdleSessions(40 * time.Millisecond)).To(Equal(0))
+			})
+		})
+
+		Context("when an idle timeout is set", func() {
+			It("should delete idle sessions while the WebDriver is started", func() {
+				webDriver.IdleTimeout = 20 * time.Millisecond
+				Expect(webDriver.Start()).To(Succeed())
+				webDriver.Open(nil)
+				Eventually(webDriver.Sessions).Should(BeEmpty())
+				Expect(deletedSessions()).To(HaveLen(1))
+				Expect(webDriver.Stop()).To(Succeed())
+			})
+		})
+	})
+
+	Describe("supervision of the WebDriver process", func() {
+		var (
+			server  *httptest.Server
+			logDir  string
+			started func() int
+			booted  func() bool
+		)
+
+		BeforeEach(func() {
+			booted = func() bool { return true }
+			server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				switch {
+				case request.URL.Path == "/status" && !booted():
+					response.WriteHeader(500)
+				case request.URL.Path == "/status", request.URL.Path == "/session":
+					response.Write([]byte(`{"sessionId": "some-id"}`))
+				default:
+					response.WriteHeader(500)
+					response.Write([]byte(`{"value": {"message": "some error"}}`))
+				}
+			}))
+			var err error
+			logDir, err = ioutil.TempDir("", "webdriver")
+			Expect(err).NotTo(HaveOccurred())
+			started = func() int {
+				starts, _ := ioutil.ReadFile(filepath.Join(logDir, "starts"))
+				return strings.Count(string(starts), "started")
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.RemoveAll(logDir)
+		})
+
+		newWebDriver := func(script string) *WebDriver {
+			script = "echo started >> " + filepath.Join(logDir, "starts") + "; " + script
+			webDriver := NewWebDriver(server.URL, []string{"sh", "-c", script})
+			webDriver.Timeout = 2 * time.Second
+			return webDriver
+		}
+
+		It("should describe how the process exited to sessions that were opened on it", func() {
+			webDriver = newWebDriver("sleep 0.5; exit 1")
+			Expect(webDriver.Start()).To(Succeed())
+			Expect(webDriver.Healthy()).To(BeTrue())
+			session, err := webDriver.Open(nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(webDriver.Done(), "2s").Should(BeClosed())
+			Expect(webDriver.Err()).To(MatchError("driver process exited with status 1"))
+			Expect(webDriver.Healthy()).To(BeFalse())
+			Eventually(webDriver.Sessions).Should(BeEmpty())
+
+			_, err = session.GetURL()
+			Expect(err).To(MatchError("driver process exited with status 1: request unsuccessful: some error"))
+			_, err = webDriver.Open(nil)
+			Expect(err).To(MatchError("driver process exited with status 1"))
+			Expect(webDriver.Stop()).To(Succeed())
+			Expect(started()).To(Equal(1))
+		})
+
+		It("should start the process again on a new port when it fails to bind to its port", func() {
+			marker := filepath.Join(logDir, "marker")
+			ready := filepath.Join(logDir, "ready")
+			booted = func() bool {
+				_, err := os.Stat(ready)
+				return err == nil
+			}
+			webDriver = newWebDriver("if [ -f " + marker + " ]; then touch " + ready + "; sleep 5; else touch " + marker +
+				"; echo 'bind: address already in use' >&2; exit 1; fi")
+			Expect(webDriver.Start()).To(Succeed())
+			Expect(started()).To(Equal(2))
+			Expect(webDriver.Stop()).To(Succeed())
+		})
+
+		Context("when the process fails to start more than the allowed number of times", func() {
+			It("should return an error that includes the output of the process", func() {
+				booted = func() bool { return false }
+				webDriver = newWebDriver("echo 'bind: address already in use' >&2; exit 1")
+				webDriver.StartRetries = 1
+				Expect(webDriver.Start()).To(MatchError("failed to start: driver process exited with status 1\nWebDriver output:\nbind: address already in use"))
+				Expect(started()).To(Equal(2))
+			})
+		})
+
+		Context("when the WebDriver restarts on crash", func() {
+			It("should restart the process when it exits", func() {
+				webDriver = newWebDriver("sleep 0.2; exit 1")
+				webDriver.RestartOnCrash = true
+				Expect(webDriver.Start()).To(Succeed())
+				Eventually(started, "2s").Should(BeNumerically(">=", 3))
+				Expect(webDriver.Stop()).To(Succeed())
+				stopped := started()
+				Consistently(started, "500ms").Should(Equal(stopped))
+			})
+		})
+	})
+
+	Describe("NewRemoteWebDriver", func() {
+		It("should open sessions on a running WebDriver after it responds to status requests", func() {
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				paths = append(paths, request.Method+" "+request.URL.Path)
+				response.Write([]byte(`{"sessionId": "some-id"}`))
+			}))
+			defer server.Close()
+
+			remoteWebDriver := NewRemoteWebDriver(server.URL + "/wd/hub")
+			_, err := remoteWebDriver.Open(nil)
+			Expect(err).To(MatchError("service not started"))
+
+			Expect(remoteWebDriver.Start()).To(Succeed())
+			Expect(remoteWebDriver.URL()).To(Equal(server.URL + "/wd/hub"))
+			_, err = remoteWebDriver.Open(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remoteWebDriver.Stop()).To(Succeed())
+			Expect(remoteWebDriver.URL()).To(BeEmpty())
+			Expect(paths).To(Equal([]string{
+				"GET /wd/hub/status",
+				"POST /wd/hub/session",
+				"DELETE /wd/hub/session/some-id",
+			}))
+		})
+	})
+
 	Describe("#Start", func() {
 		It("should successfully start the WebDriver service", func() {
 			Expect(webDriver.Start()).To(Succeed())
@@ -117,6 +417,27 @@ var _ = Describe("WebDriver", func() {
 			Expect(service.StartCall.Debug).To(BeTrue())
 		})
 
+		It("should configure the address of the WebDriver service", func() {
+			webDriver.Host = "0.0.0.0"
+			webDriver.MinPort = 4444
+			webDriver.MaxPort = 4450
+			Expect(webDriver.Start()).To(Succeed())
+			Expect(service.SetAddressCall.Host).To(Equal("0.0.0.0"))
+			Expect(service.SetAddressCall.MinPort).To(Equal(4444))
+			Expect(service.SetAddressCall.MaxPort).To(Equal(4450))
+		})
+
+		It("should configure the output of the WebDriver service", func() {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			webDriver.Stdout = stdout
+			webDriver.Stderr = stderr
+			webDriver.LogDir = "some/dir"
+			Expect(webDriver.Start()).To(Succeed())
+			Expect(service.SetOutputCall.Stdout).To(BeIdenticalTo(stdout))
+			Expect(service.SetOutputCall.Stderr).To(BeIdenticalTo(stderr))
+			Expect(service.SetOutputCall.LogDir).To(Equal("some/dir"))
+		})
+
 		Context("when the WebDriver service cannot be started", func() {
 			It("should return an error", func() {
 				service.StartCall.Err = errors.New("some error")
@@ -132,6 +453,32 @@ var _ = Describe("WebDriver", func() {
 				Expect(err).To(MatchError("some error"))
 				Expect(service.StopCall.Called).To(BeTrue())
 			})
+
+			It("should include the recent output of the WebDriver service in the error", func() {
+				service.WaitForBootCall.Err = errors.New("some error")
+				service.RecentOutputCall.ReturnOutput = "some output\nsome more output"
+				err := webDriver.Start()
+				Expect(err).To(MatchError("some error\nWebDriver output:\nsome output\nsome more output"))
+				Expect(errors.Unwrap(err)).To(MatchError("some error"))
+			})
+		})
+
+		Context("when a context is provided", func() {
+			It("should wait for the service to boot using the context bounded by the timeout", func() {
+				ctx := context.WithValue(context.Background(), "some", "value")
+				Expect(webDriver.StartContext(ctx)).To(Succeed())
+				Expect(service.StartCall.Called).To(BeTrue())
+				Expect(service.WaitForBootContextCall.Context.Value("some")).To(Equal("value"))
+				deadline, ok := service.WaitForBootContextCall.Context.Deadline()
+				Expect(ok).To(BeTrue())
+				Expect(deadline).To(BeTemporally("~", time.Now().Add(2*time.Second), time.Second))
+			})
+
+			It("should return an error and stop the service when the service fails to boot", func() {
+				service.WaitForBootContextCall.Err = context.DeadlineExceeded
+				Expect(webDriver.StartContext(context.Background())).To(MatchError(context.DeadlineExceeded))
+				Expect(service.StopCall.Called).To(BeTrue())
+			})
 		})
 	})
 
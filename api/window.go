@@ -17,5 +17,10 @@ func (w *Window) SetSize(width, height int) error {
 		Height int `json:"height"`
 	}{width, height}
 
+	// W3C drivers may only resize the current window
+	if w.Session.W3C {
+		return w.Session.Send("POST", "window/rect", request, nil)
+	}
+
 	return w.Send("POST", "size", request, nil)
 }
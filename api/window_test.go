@@ -17,7 +17,7 @@ var _ = Describe("Window", func() {
 
 	BeforeEach(func() {
 		bus = &mocks.Bus{}
-		window = &Window{"some-id", &Session{bus}}
+		window = &Window{"some-id", &Session{Bus: bus}}
 	})
 
 	Describe("#Send", func() {
@@ -58,5 +58,18 @@ var _ = Describe("Window", func() {
 				Expect(window.SetSize(640, 480)).To(MatchError("some error"))
 			})
 		})
+
+		Context("when the session uses the W3C protocol", func() {
+			BeforeEach(func() {
+				window.Session.W3C = true
+			})
+
+			It("should successfully send a POST request to the window/rect endpoint", func() {
+				Expect(window.SetSize(640, 480)).To(Succeed())
+				Expect(bus.SendCall.Method).To(Equal("POST"))
+				Expect(bus.SendCall.Endpoint).To(Equal("window/rect"))
+				Expect(bus.SendCall.BodyJSON).To(MatchJSON(`{"width":640,"height":480}`))
+			})
+		})
 	})
 })
@@ -34,28 +34,28 @@ func newDevice(session mobileSession, page *agouti.Page) *Device {
 
 func (d *Device) LaunchApp() error {
 	if err := d.session.LaunchApp(); err != nil {
-		return fmt.Errorf("failed to launch app: %s", err)
+		return fmt.Errorf("failed to launch app: %w", err)
 	}
 	return nil
 }
 
 func (d *Device) CloseApp() error {
 	if err := d.session.CloseApp(); err != nil {
-		return fmt.Errorf("failed to close app: %s", err)
+		return fmt.Errorf("failed to close app: %w", err)
 	}
 	return nil
 }
 
 func (d *Device) InstallApp(appPath string) error {
 	if err := d.session.InstallApp(appPath); err != nil {
-		return fmt.Errorf("failed to install app: %s", err)
+		return fmt.Errorf("failed to install app: %w", err)
 	}
 	return nil
 }
 
 func (d *Device) Reset() error {
 	if err := d.session.Reset(); err != nil {
-		return fmt.Errorf("failed to reset app: %s", err)
+		return fmt.Errorf("failed to reset app: %w", err)
 	}
 	return nil
 }
@@ -72,7 +72,7 @@ func (d *Device) ReplaceElementValue(element *agouti.Selection, newValue string)
 
 	for _, el := range elements {
 		if err := d.session.ReplaceValue(el.GetID(), newValue); err != nil {
-			return fmt.Errorf("failed to replace element value: %s", err)
+			return fmt.Errorf("failed to replace element value: %w", err)
 		}
 	}
 
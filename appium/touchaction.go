@@ -164,7 +164,7 @@ func (t *TouchAction) Perform() error {
 		if action.elements != nil {
 			selectedElement, err := action.elements.GetExactlyOne()
 			if err != nil {
-				return fmt.Errorf("failed to retrieve element for selection %q: %s", action.Elements(), err)
+				return fmt.Errorf("failed to retrieve element for selection %q: %w", action.Elements(), err)
 			}
 			action.Options.Element = selectedElement.(*api.Element).ID
 		}
@@ -173,7 +173,7 @@ func (t *TouchAction) Perform() error {
 	}
 
 	if err := t.session.PerformTouch(actions); err != nil {
-		return fmt.Errorf("error performing touch actions '%s': %s", t, err)
+		return fmt.Errorf("error performing touch actions '%s': %w", t, err)
 	}
 	return nil
 }
@@ -23,7 +23,7 @@ func (w *WebDriver) NewDevice(options ...Option) (*Device, error) {
 	newOptions := config{}.merge(options)
 	page, err := w.driver.NewPage(newOptions.agoutiOptions...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to WebDriver: %s", err)
+		return nil, fmt.Errorf("failed to connect to WebDriver: %w", err)
 	}
 	mobileSession := &mobile.Session{page.Session()}
 
@@ -0,0 +1,48 @@
+package agouti
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sclevine/agouti/cdp"
+)
+
+// ExecuteCDP sends a Chrome DevTools Protocol command to the browser through
+// the WebDriver, and decodes the command result into the provided result value.
+// This requires ChromeDriver or another WebDriver that supports the
+// goog/cdp/execute endpoint. For example:
+//    conditions := map[string]interface{}{
+//        "offline": false, "latency": 200,
+//        "downloadThroughput": 50000, "uploadThroughput": 20000,
+//    }
+//    page.ExecuteCDP("Network.enable", nil, nil)
+//    page.ExecuteCDP("Network.emulateNetworkConditions", conditions, nil)
+func (p *Page) ExecuteCDP(command string, params, result interface{}) error {
+	if err := p.session.ExecuteCDP(command, params, result); err != nil {
+		return fmt.Errorf("failed to execute %s: %w", command, err)
+	}
+	return nil
+}
+
+// CDP connects to the DevTools debugger of the browser and returns a client
+// for the current window of the page. Unlike ExecuteCDP, the client may be
+// used to subscribe to DevTools events. The browser must report its debugger
+// address (see api.Session.DebuggerAddress), and the client must be closed
+// when it is no longer needed.
+func (p *Page) CDP() (*cdp.Client, error) {
+	address, err := p.session.DebuggerAddress()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve debugger address: %w", err)
+	}
+
+	window, err := p.session.GetWindow()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve window: %w", err)
+	}
+
+	client, err := cdp.Connect(context.Background(), address, window.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to debugger: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,116 @@
+// Package cdp provides a client for the Chrome DevTools Protocol
+// (https://chromedevtools.github.io/devtools-protocol/), which communicates
+// with a Chromium-based browser over its debugger websocket.
+//
+// A client for the current window of a Page may be retrieved using Page.CDP.
+// For example:
+//    client, err := page.CDP()
+//    ...
+//    defer client.Close()
+//    console := client.Subscribe("Runtime.consoleAPICalled")
+//    defer console.Close()
+//    err = client.Execute(ctx, "Runtime.enable", nil, nil)
+//    ...
+//    for event := range console.Events() {
+//        ...
+//    }
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sclevine/agouti/internal/rpc"
+	"github.com/sclevine/agouti/internal/websocket"
+)
+
+// ErrClosed is returned when a command is executed on a closed Client.
+var ErrClosed = rpc.ErrClosed
+
+// An Event is a DevTools event, such as "Network.requestWillBeSent".
+// Its Decode method decodes the event parameters into the provided value.
+type Event = rpc.Event
+
+// A Subscription receives DevTools events from a Client. Events are buffered
+// until they are received, so that slow consumers never block the Client.
+type Subscription = rpc.Subscription
+
+// A Client executes DevTools commands and receives DevTools events over a
+// single debugger websocket. A Client is safe for concurrent use.
+type Client struct {
+	client *rpc.Client
+}
+
+// Error is returned when the browser responds to a command with an error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Data != "" {
+		return fmt.Sprintf("%s (%d): %s", e.Message, e.Code, e.Data)
+	}
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// Dial connects to the provided debugger websocket URL
+// (ex. "ws://localhost:9222/devtools/page/<target ID>").
+func Dial(ctx context.Context, websocketURL string) (*Client, error) {
+	conn, err := websocket.Dial(ctx, websocketURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{rpc.New(conn)}, nil
+}
+
+// Execute sends a command with the provided parameters and waits for the
+// browser to respond. If result is not nil, the command result is decoded
+// into it. Execute returns an *Error if the command fails.
+func (c *Client) Execute(ctx context.Context, method string, params, result interface{}) error {
+	responseJSON, err := c.client.Call(ctx, method, params)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *Error          `json:"error"`
+	}
+	if err := json.Unmarshal(responseJSON, &response); err != nil {
+		return fmt.Errorf("unexpected response: %s", responseJSON)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if result != nil {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return fmt.Errorf("unexpected response: %s", response.Result)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a Subscription that receives events with the provided
+// method (ex. "Network.responseReceived"), all events in the provided domain
+// (ex. "Network"), or all events if the method is empty. Most events are only
+// sent after their domain is enabled (ex. by executing "Network.enable").
+func (c *Client) Subscribe(method string) *Subscription {
+	if method == "" {
+		return c.client.Subscribe()
+	}
+	return c.client.Subscribe(method)
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.client.Done()
+}
+
+// Close closes the connection. Subscriptions stop receiving events, and
+// commands in progress return ErrClosed.
+func (c *Client) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,13 @@
+package cdp_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCDP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CDP Suite")
+}
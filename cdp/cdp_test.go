@@ -0,0 +1,259 @@
+package cdp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/agouti/cdp"
+	"github.com/sclevine/agouti/internal/websocket"
+)
+
+type request struct {
+	ID     int64                  `json:"id"`
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// newDebugger returns a server that emulates a browser's DevTools debugger
+func newDebugger() *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json/list" {
+			wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/devtools/page/"
+			json.NewEncoder(w).Encode([]map[string]string{
+				{"id": "WORKER", "type": "service_worker", "webSocketDebuggerUrl": wsURL + "WORKER"},
+				{"id": "ABCDEF", "type": "page", "title": "some title", "url": "http://example.com", "webSocketDebuggerUrl": wsURL + "ABCDEF"},
+				{"id": "123456", "type": "page", "webSocketDebuggerUrl": wsURL + "123456"},
+			})
+			return
+		}
+
+		conn, err := websocket.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req request
+			json.Unmarshal(message, &req)
+
+			var response interface{}
+			switch req.Method {
+			case "Target.getTarget":
+				response = map[string]interface{}{"id": req.ID, "result": map[string]string{"path": r.URL.Path}}
+			case "Echo":
+				response = map[string]interface{}{"id": req.ID, "result": req.Params}
+			case "Emit":
+				for _, method := range []string{"Some.event", "Other.event", "Some.event"} {
+					conn.WriteMessage([]byte(`{"method": "` + method + `", "params": {"value": "` + method + `"}}`))
+				}
+				response = map[string]interface{}{"id": req.ID, "result": map[string]string{}}
+			case "Hang":
+				continue
+			case "Close":
+				return
+			default:
+				response = map[string]interface{}{"id": req.ID, "error": map[string]interface{}{
+					"code": -32601, "message": "'" + req.Method + "' wasn't found",
+				}}
+			}
+			data, _ := json.Marshal(response)
+			conn.WriteMessage(data)
+		}
+	}))
+	return server
+}
+
+var _ = Describe("CDP", func() {
+	var (
+		debugger *httptest.Server
+		address  string
+		client   *cdp.Client
+	)
+
+	BeforeEach(func() {
+		debugger = newDebugger()
+		address = strings.TrimPrefix(debugger.URL, "http://")
+		var err error
+		client, err = cdp.Connect(context.Background(), address, "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		client.Close()
+		debugger.Close()
+	})
+
+	Describe(".Targets", func() {
+		It("should return the targets of the browser", func() {
+			targets, err := cdp.Targets(context.Background(), address)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).To(HaveLen(3))
+			Expect(targets[1]).To(Equal(cdp.Target{
+				ID:                   "ABCDEF",
+				Type:                 "page",
+				Title:                "some title",
+				URL:                  "http://example.com",
+				WebSocketDebuggerURL: "ws://" + address + "/devtools/page/ABCDEF",
+			}))
+		})
+	})
+
+	Describe(".Connect", func() {
+		var path struct{ Path string }
+
+		It("should connect to the first page target by default", func() {
+			Expect(client.Execute(context.Background(), "Target.getTarget", nil, &path)).To(Succeed())
+			Expect(path.Path).To(Equal("/devtools/page/ABCDEF"))
+		})
+
+		It("should connect to the page target with the provided ID or window handle", func() {
+			for _, id := range []string{"123456", "CDwindow-123456"} {
+				other, err := cdp.Connect(context.Background(), address, id)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(other.Execute(context.Background(), "Target.getTarget", nil, &path)).To(Succeed())
+				Expect(path.Path).To(Equal("/devtools/page/123456"))
+				other.Close()
+			}
+		})
+
+		Context("when no matching page target exists", func() {
+			It("should return an error", func() {
+				_, err := cdp.Connect(context.Background(), address, "WORKER")
+				Expect(err).To(MatchError("no page target with ID WORKER found at " + address))
+			})
+		})
+
+		Context("when the debugger cannot be reached", func() {
+			It("should return an error", func() {
+				_, err := cdp.Connect(context.Background(), "127.0.0.1:0", "")
+				Expect(err.Error()).To(HavePrefix("failed to retrieve targets: "))
+			})
+		})
+	})
+
+	Describe("#Execute", func() {
+		It("should send the command and decode the result", func() {
+			var result map[string]interface{}
+			Expect(client.Execute(context.Background(), "Echo", map[string]int{"some": 1}, &result)).To(Succeed())
+			Expect(result).To(Equal(map[string]interface{}{"some": 1.0}))
+		})
+
+		It("should support concurrent commands", func() {
+			done := make(chan int, 10)
+			for i := 0; i < 10; i++ {
+				go func(i int) {
+					defer GinkgoRecover()
+					var result struct{ Value int }
+					Expect(client.Execute(context.Background(), "Echo", map[string]int{"value": i}, &result)).To(Succeed())
+					done <- result.Value
+				}(i)
+			}
+			var values []int
+			for i := 0; i < 10; i++ {
+				values = append(values, <-done)
+			}
+			Expect(values).To(ConsistOf(0, 1, 2, 3, 4, 5, 6, 7, 8, 9))
+		})
+
+		Context("when the command fails", func() {
+			It("should return an Error", func() {
+				err := client.Execute(context.Background(), "Some.unknownMethod", nil, nil)
+				Expect(err).To(Equal(&cdp.Error{Code: -32601, Message: "'Some.unknownMethod' wasn't found"}))
+				Expect(err).To(MatchError("'Some.unknownMethod' wasn't found (-32601)"))
+			})
+		})
+
+		Context("when the context is canceled", func() {
+			It("should return an error wrapping the context error", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				err := client.Execute(ctx, "Hang", nil, nil)
+				Expect(err).To(MatchError(context.DeadlineExceeded))
+			})
+		})
+
+		Context("when the connection is closed", func() {
+			It("should return ErrClosed", func() {
+				Expect(client.Execute(context.Background(), "Close", nil, nil)).To(Equal(cdp.ErrClosed))
+				Eventually(client.Done()).Should(BeClosed())
+				Expect(client.Execute(context.Background(), "Echo", nil, nil)).To(Equal(cdp.ErrClosed))
+			})
+		})
+	})
+
+	Describe("#Subscribe", func() {
+		It("should receive events with the provided method in order", func() {
+			subscription := client.Subscribe("Some.event")
+			defer subscription.Close()
+			all := client.Subscribe("")
+			defer all.Close()
+
+			Expect(client.Execute(context.Background(), "Emit", nil, nil)).To(Succeed())
+
+			var event cdp.Event
+			Eventually(subscription.Events()).Should(Receive(&event))
+			Expect(event.Method).To(Equal("Some.event"))
+			var params struct{ Value string }
+			Expect(event.Decode(&params)).To(Succeed())
+			Expect(params.Value).To(Equal("Some.event"))
+			Eventually(subscription.Events()).Should(Receive(&event))
+			Expect(event.Method).To(Equal("Some.event"))
+			Consistently(subscription.Events()).ShouldNot(Receive())
+
+			for _, method := range []string{"Some.event", "Other.event", "Some.event"} {
+				Eventually(all.Events()).Should(Receive(&event))
+				Expect(event.Method).To(Equal(method))
+			}
+		})
+
+		It("should close the events channel when the subscription is closed", func() {
+			subscription := client.Subscribe("Some.event")
+			subscription.Close()
+			Eventually(subscription.Events()).Should(BeClosed())
+			Expect(client.Execute(context.Background(), "Emit", nil, nil)).To(Succeed())
+		})
+
+		It("should deliver buffered events and close the events channel when the client is closed", func() {
+			subscription := client.Subscribe("Some.event")
+			Expect(client.Execute(context.Background(), "Emit", nil, nil)).To(Succeed())
+			Expect(client.Close()).To(Succeed())
+
+			Eventually(subscription.Events()).Should(Receive())
+			Eventually(subscription.Events()).Should(Receive())
+			Eventually(subscription.Events()).Should(BeClosed())
+		})
+	})
+
+	Describe("#Subscribe with a domain", func() {
+		It("should receive all events in the domain", func() {
+			subscription := client.Subscribe("Other")
+			defer subscription.Close()
+			Expect(client.Execute(context.Background(), "Emit", nil, nil)).To(Succeed())
+
+			var event cdp.Event
+			Eventually(subscription.Events()).Should(Receive(&event))
+			Expect(event.Method).To(Equal("Other.event"))
+			Consistently(subscription.Events()).ShouldNot(Receive())
+		})
+	})
+
+	Describe("Event#Decode", func() {
+		It("should return an error when the params are invalid", func() {
+			event := cdp.Event{Method: "Some.event", Params: json.RawMessage(`[]`)}
+			var params struct{}
+			err := event.Decode(&params)
+			Expect(err.Error()).To(HavePrefix("failed to decode Some.event event: "))
+		})
+	})
+})
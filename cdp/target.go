@@ -0,0 +1,66 @@
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// A Target is a page, worker, or other debuggable target in the browser.
+type Target struct {
+	ID                   string `json:"id"`
+	Type                 string `json:"type"`
+	Title                string `json:"title"`
+	URL                  string `json:"url"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+// Targets returns the targets of the browser with the provided debugger
+// address (ex. "localhost:9222").
+func Targets(ctx context.Context, debuggerAddress string) ([]Target, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", "http://"+debuggerAddress+"/json/list", nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %s", response.Status)
+	}
+	var targets []Target
+	if err := json.NewDecoder(response.Body).Decode(&targets); err != nil {
+		return nil, fmt.Errorf("invalid target list: %w", err)
+	}
+	return targets, nil
+}
+
+// Connect dials the page target with the provided ID in the browser with the
+// provided debugger address. WebDriver window handles are accepted as target
+// IDs. If the target ID is empty, the first page target is used.
+func Connect(ctx context.Context, debuggerAddress, targetID string) (*Client, error) {
+	targets, err := Targets(ctx, debuggerAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve targets: %w", err)
+	}
+
+	// older versions of ChromeDriver prefix window handles with "CDwindow-"
+	targetID = strings.TrimPrefix(targetID, "CDwindow-")
+	for _, target := range targets {
+		if target.Type != "page" || target.WebSocketDebuggerURL == "" {
+			continue
+		}
+		if targetID == "" || strings.EqualFold(target.ID, targetID) {
+			return Dial(ctx, target.WebSocketDebuggerURL)
+		}
+	}
+	if targetID == "" {
+		return nil, fmt.Errorf("no page target found at %s", debuggerAddress)
+	}
+	return nil, fmt.Errorf("no page target with ID %s found at %s", targetID, debuggerAddress)
+}
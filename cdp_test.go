@@ -0,0 +1,125 @@
+package agouti_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti"
+	"github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/internal/mocks"
+	"github.com/sclevine/agouti/internal/websocket"
+)
+
+var _ = Describe("CDP", func() {
+	var (
+		page    *Page
+		session *mocks.Session
+	)
+
+	BeforeEach(func() {
+		session = &mocks.Session{}
+		page = NewTestPage(session)
+	})
+
+	Describe("#ExecuteCDP", func() {
+		It("should execute the command and decode the result", func() {
+			session.ExecuteCDPCall.Result = `{"metrics": [{"name": "Nodes", "value": 10}]}`
+			var result struct {
+				Metrics []struct{ Name string }
+			}
+			params := map[string]bool{"offline": true}
+			Expect(page.ExecuteCDP("Performance.getMetrics", params, &result)).To(Succeed())
+			Expect(session.ExecuteCDPCall.Command).To(Equal("Performance.getMetrics"))
+			Expect(session.ExecuteCDPCall.Params).To(Equal(params))
+			Expect(result.Metrics[0].Name).To(Equal("Nodes"))
+		})
+
+		Context("when the session fails to execute the command", func() {
+			It("should return an error", func() {
+				session.ExecuteCDPCall.Err = errors.New("some error")
+				err := page.ExecuteCDP("Performance.enable", nil, nil)
+				Expect(err).To(MatchError("failed to execute Performance.enable: some error"))
+			})
+		})
+	})
+
+	Describe("#CDP", func() {
+		var debugger *httptest.Server
+
+		BeforeEach(func() {
+			debugger = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/json/list" {
+					wsURL := "ws" + strings.TrimPrefix(debugger.URL, "http") + "/devtools/page/"
+					json.NewEncoder(w).Encode([]map[string]string{
+						{"id": "OTHER", "type": "page", "webSocketDebuggerUrl": wsURL + "OTHER"},
+						{"id": "SOME-WINDOW", "type": "page", "webSocketDebuggerUrl": wsURL + "SOME-WINDOW"},
+					})
+					return
+				}
+				conn, err := websocket.Upgrade(w, r)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				message, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				var request struct{ ID int }
+				json.Unmarshal(message, &request)
+				response, _ := json.Marshal(map[string]interface{}{
+					"id":     request.ID,
+					"result": map[string]string{"path": r.URL.Path},
+				})
+				conn.WriteMessage(response)
+				conn.ReadMessage()
+			}))
+			session.DebuggerAddressCall.ReturnAddress = strings.TrimPrefix(debugger.URL, "http://")
+			session.GetWindowCall.ReturnWindow = &api.Window{ID: "SOME-WINDOW"}
+		})
+
+		AfterEach(func() {
+			debugger.Close()
+		})
+
+		It("should return a client connected to the current window", func() {
+			client, err := page.CDP()
+			Expect(err).NotTo(HaveOccurred())
+			defer client.Close()
+
+			var result struct{ Path string }
+			Expect(client.Execute(context.Background(), "Target.getTarget", nil, &result)).To(Succeed())
+			Expect(result.Path).To(Equal("/devtools/page/SOME-WINDOW"))
+		})
+
+		Context("when the debugger address is not available", func() {
+			It("should return an error", func() {
+				session.DebuggerAddressCall.Err = errors.New("some error")
+				_, err := page.CDP()
+				Expect(err).To(MatchError("failed to retrieve debugger address: some error"))
+			})
+		})
+
+		Context("when the current window cannot be retrieved", func() {
+			It("should return an error", func() {
+				session.GetWindowCall.Err = errors.New("some error")
+				_, err := page.CDP()
+				Expect(err).To(MatchError("failed to retrieve window: some error"))
+			})
+		})
+
+		Context("when the window has no debugger target", func() {
+			It("should return an error", func() {
+				session.GetWindowCall.ReturnWindow = &api.Window{ID: "missing"}
+				_, err := page.CDP()
+				Expect(err.Error()).To(HavePrefix("failed to connect to debugger: no page target with ID missing"))
+			})
+		})
+	})
+})
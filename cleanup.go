@@ -0,0 +1,23 @@
+package agouti
+
+import "github.com/sclevine/agouti/api"
+
+// KillDriversOnInterrupt kills the WebDriver processes that were started by
+// any WebDriver, along with the browsers that they started, when the test
+// binary receives SIGINT or SIGTERM (ex. when "go test" is interrupted). It
+// should be called once before any WebDriver is started, for instance:
+//    func TestMain(m *testing.M) {
+//        agouti.KillDriversOnInterrupt()
+//        os.Exit(m.Run())
+//    }
+// WebDriver processes that are started after it is called are started in their
+// own process group, so that Stop also stops the browsers that they started.
+// As processes in their own group do not receive signals sent by the terminal
+// (ex. Ctrl-C), they are only started in one after this function is called.
+//
+// The signal is not otherwise handled, so that other handlers (ex. Ginkgo's)
+// may clean up and exit. If there are none, the test binary exits when it
+// receives the signal again.
+func KillDriversOnInterrupt() {
+	api.KillDriversOnInterrupt()
+}
@@ -0,0 +1,97 @@
+package agouti
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// versionTimeout is how long a binary may take to report its version.
+const versionTimeout = 5 * time.Second
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// driverBinary returns the binary provided by the DriverBinary Option, or else
+// the binary named by the provided environment variable, or else the default.
+func driverBinary(defaultBinary, env string, options []Option) string {
+	if binary := (config{}).Merge(options).DriverBinary; binary != "" {
+		return binary
+	}
+	if binary := os.Getenv(env); binary != "" {
+		return binary
+	}
+	return defaultBinary
+}
+
+// A versionCheck compares the major versions reported by the --version flag
+// of a WebDriver binary and of the browser that it controls.
+type versionCheck struct {
+	driver       string
+	driverBinary string
+	browser      string
+
+	// browserBinaries are checked in order, and the first to report a version
+	// is compared to the driver.
+	browserBinaries []string
+}
+
+func chromeVersionCheck(driverBinary string, options []Option) *versionCheck {
+	check := &versionCheck{driver: "ChromeDriver", driverBinary: driverBinary, browser: "Chrome"}
+	if binary, ok := (config{}).Merge(options).ChromeOptions["binary"].(string); ok && binary != "" {
+		check.browserBinaries = []string{binary}
+		return check
+	}
+	switch runtime.GOOS {
+	case "windows":
+		// chrome.exe does not print its version
+	case "darwin":
+		check.browserBinaries = []string{
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		}
+	default:
+		check.browserBinaries = []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser"}
+	}
+	return check
+}
+
+// warning returns a warning if the major versions of the driver and browser
+// differ, or else an empty string. Binaries that cannot report a version are
+// ignored, as a missing driver is reported when it is started.
+func (v *versionCheck) warning() string {
+	driverVersion := binaryVersion(v.driverBinary)
+	if driverVersion == "" {
+		return ""
+	}
+	for _, browserBinary := range v.browserBinaries {
+		browserVersion := binaryVersion(browserBinary)
+		if browserVersion == "" {
+			continue
+		}
+		if majorVersion(driverVersion) != majorVersion(browserVersion) {
+			return fmt.Sprintf("%s %s may not support %s %s, as their major versions differ",
+				v.driver, driverVersion, v.browser, browserVersion)
+		}
+		return ""
+	}
+	return ""
+}
+
+func binaryVersion(binary string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, binary, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return versionPattern.FindString(string(output))
+}
+
+func majorVersion(version string) string {
+	return strings.SplitN(version, ".", 2)[0]
+}
@@ -1,22 +1,37 @@
 package agouti
 
-import "github.com/sclevine/agouti/internal/target"
+import (
+	"github.com/sclevine/agouti/internal/target"
+	"github.com/sclevine/agouti/proxy"
+)
 
 func NewTestSelection(session apiSession, elements elementRepository, firstSelector string) *Selection {
 	selector := target.Selector{Type: target.CSS, Value: firstSelector, Single: true}
-	return &Selection{selectable{session, target.Selectors{selector}}, elements}
+	return &Selection{selectable{session: session, selectors: target.Selectors{selector}}, elements}
 }
 
 func NewTestMultiSelection(session apiSession, elements elementRepository, firstSelector string) *MultiSelection {
 	selector := target.Selector{Type: target.CSS, Value: firstSelector}
-	selection := Selection{selectable{session, target.Selectors{selector}}, elements}
+	selection := Selection{selectable{session: session, selectors: target.Selectors{selector}}, elements}
 	return &MultiSelection{selection}
 }
 
 func NewTestPage(session apiSession) *Page {
-	return &Page{selectable{session, nil}, nil}
+	return &Page{selectable{session: session}, nil, newPageState(), nil}
+}
+
+func NewTestPageWithProxy(session apiSession, networkProxy *proxy.Proxy) *Page {
+	return &Page{selectable{session: session}, networkProxy, newPageState(), nil}
+}
+
+func NewTestPagePool(size int, open func() (*Page, error)) (*PagePool, error) {
+	return newPagePool(size, open)
 }
 
 func NewTestConfig() *config {
 	return &config{}
 }
+
+func SetTestStaleRetries(selection *Selection, retries int) {
+	selection.staleRetries = retries
+}
@@ -64,6 +64,10 @@ type Element struct {
 	IsDisplayedCall struct {
 		ReturnDisplayed bool
 		Err             error
+		// ReturnDisplayed is only returned after ReturnAfter calls,
+		// and earlier calls return false
+		ReturnAfter int
+		Calls       int
 	}
 
 	IsEnabledCall struct {
@@ -87,6 +91,17 @@ type Element struct {
 		ReturnY int
 		Err     error
 	}
+
+	GetSizeCall struct {
+		ReturnWidth  int
+		ReturnHeight int
+		Err          error
+	}
+
+	GetScreenshotCall struct {
+		ReturnImage []byte
+		Err         error
+	}
 }
 
 func (e *Element) GetElement(selector api.Selector) (*api.Element, error) {
@@ -141,6 +156,10 @@ func (e *Element) IsSelected() (bool, error) {
 }
 
 func (e *Element) IsDisplayed() (bool, error) {
+	e.IsDisplayedCall.Calls++
+	if e.IsDisplayedCall.Calls <= e.IsDisplayedCall.ReturnAfter {
+		return false, e.IsDisplayedCall.Err
+	}
 	return e.IsDisplayedCall.ReturnDisplayed, e.IsDisplayedCall.Err
 }
 
@@ -161,3 +180,11 @@ func (e *Element) IsEqualTo(other *api.Element) (bool, error) {
 func (e *Element) GetLocation() (x, y int, err error) {
 	return e.GetLocationCall.ReturnX, e.GetLocationCall.ReturnY, e.GetLocationCall.Err
 }
+
+func (e *Element) GetSize() (width, height int, err error) {
+	return e.GetSizeCall.ReturnWidth, e.GetSizeCall.ReturnHeight, e.GetSizeCall.Err
+}
+
+func (e *Element) GetScreenshot() ([]byte, error) {
+	return e.GetScreenshotCall.ReturnImage, e.GetScreenshotCall.Err
+}
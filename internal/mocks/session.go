@@ -82,6 +82,11 @@ type Session struct {
 	GetURLCall struct {
 		ReturnURL string
 		Err       error
+		// ReturnURL is only returned after ReturnAfter calls, and
+		// earlier calls return PreviousURL
+		ReturnAfter int
+		PreviousURL string
+		Calls       int
 	}
 
 	SetURLCall struct {
@@ -234,6 +239,16 @@ type Session struct {
 		Err     error
 	}
 
+	PerformActionsCall struct {
+		Actions *api.Actions
+		Err     error
+	}
+
+	ReleaseActionsCall struct {
+		Called bool
+		Err    error
+	}
+
 	DeleteLocalStorageCall struct {
 		Called bool
 		Err    error
@@ -258,6 +273,18 @@ type Session struct {
 		Called bool
 		Err    error
 	}
+
+	ExecuteCDPCall struct {
+		Command string
+		Params  interface{}
+		Result  string
+		Err     error
+	}
+
+	DebuggerAddressCall struct {
+		ReturnAddress string
+		Err           error
+	}
 }
 
 func (s *Session) Delete() error {
@@ -326,6 +353,10 @@ func (s *Session) DeleteCookies() error {
 }
 
 func (s *Session) GetURL() (string, error) {
+	s.GetURLCall.Calls++
+	if s.GetURLCall.Calls <= s.GetURLCall.ReturnAfter {
+		return s.GetURLCall.PreviousURL, s.GetURLCall.Err
+	}
 	return s.GetURLCall.ReturnURL, s.GetURLCall.Err
 }
 
@@ -474,6 +505,16 @@ func (s *Session) TouchScroll(element *api.Element, offset api.Offset) error {
 	return s.TouchScrollCall.Err
 }
 
+func (s *Session) PerformActions(actions *api.Actions) error {
+	s.PerformActionsCall.Actions = actions
+	return s.PerformActionsCall.Err
+}
+
+func (s *Session) ReleaseActions() error {
+	s.ReleaseActionsCall.Called = true
+	return s.ReleaseActionsCall.Err
+}
+
 func (s *Session) DeleteLocalStorage() error {
 	s.DeleteLocalStorageCall.Called = true
 	return s.DeleteLocalStorageCall.Err
@@ -498,3 +539,16 @@ func (s *Session) SetScriptTimeout(timeout int) error {
 	s.SetScriptTimeoutCall.Called = true
 	return s.SetScriptTimeoutCall.Err
 }
+
+func (s *Session) ExecuteCDP(command string, params, result interface{}) error {
+	s.ExecuteCDPCall.Command = command
+	s.ExecuteCDPCall.Params = params
+	if result != nil && s.ExecuteCDPCall.Result != "" {
+		json.Unmarshal([]byte(s.ExecuteCDPCall.Result), result)
+	}
+	return s.ExecuteCDPCall.Err
+}
+
+func (s *Session) DebuggerAddress() (string, error) {
+	return s.DebuggerAddressCall.ReturnAddress, s.DebuggerAddressCall.Err
+}
@@ -0,0 +1,210 @@
+// Package rpc implements the message exchange shared by the Chrome DevTools
+// Protocol and WebDriver BiDi, where commands with numeric IDs are sent over
+// a websocket and responses and events are received asynchronously.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sclevine/agouti/internal/websocket"
+)
+
+// ErrClosed is returned when a command is sent on a closed Client.
+var ErrClosed = errors.New("connection closed")
+
+// A Client sends commands and receives events over a websocket. A Client is
+// safe for concurrent use.
+type Client struct {
+	conn *websocket.Conn
+
+	mutex         sync.Mutex
+	nextID        int64
+	pending       map[int64]chan []byte
+	subscriptions []*Subscription
+	closed        bool
+	done          chan struct{}
+}
+
+// An Event is a message from the browser that is not a response to a command.
+type Event struct {
+	Method string
+	Params json.RawMessage
+}
+
+// Decode decodes the event parameters into the provided value.
+func (e Event) Decode(value interface{}) error {
+	if err := json.Unmarshal(e.Params, value); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", e.Method, err)
+	}
+	return nil
+}
+
+type command struct {
+	ID     int64       `json:"id"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+type incoming struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// New returns a Client that communicates over the provided connection.
+func New(conn *websocket.Conn) *Client {
+	client := &Client{
+		conn:    conn,
+		pending: map[int64]chan []byte{},
+		done:    make(chan struct{}),
+	}
+	go client.read()
+	return client
+}
+
+// Call sends a command and returns the raw response message, which the
+// caller must check for errors.
+func (c *Client) Call(ctx context.Context, method string, params interface{}) ([]byte, error) {
+	if params == nil {
+		params = struct{}{}
+	}
+
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return nil, ErrClosed
+	}
+	c.nextID++
+	id := c.nextID
+	responses := make(chan []byte, 1)
+	c.pending[id] = responses
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, id)
+		c.mutex.Unlock()
+	}()
+
+	request, err := json.Marshal(command{id, method, params})
+	if err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if err := c.conn.WriteMessage(request); err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-responses:
+		return response, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("request canceled: %w", ctx.Err())
+	case <-c.done:
+		return nil, ErrClosed
+	}
+}
+
+// Subscribe returns a Subscription that receives events with the provided
+// methods (ex. "Network.responseReceived"). A method without a "." matches
+// every event in that domain or module (ex. "Network"). If no methods are
+// provided, all events are received.
+func (c *Client) Subscribe(methods ...string) *Subscription {
+	subscription := newSubscription(c, methods)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		close(subscription.incoming)
+	} else {
+		c.subscriptions = append(c.subscriptions, subscription)
+	}
+	return subscription
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close closes the connection. Subscriptions stop receiving events, and
+// commands in progress return ErrClosed.
+func (c *Client) Close() error {
+	err := c.conn.Close()
+	<-c.done
+	if err == websocket.ErrClosed {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) read() {
+	defer c.shutdown()
+
+	for {
+		data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg incoming
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		if msg.ID != 0 {
+			c.mutex.Lock()
+			responses := c.pending[msg.ID]
+			c.mutex.Unlock()
+			if responses != nil {
+				responses <- data
+			}
+			continue
+		}
+
+		if msg.Method != "" {
+			c.dispatch(Event{Method: msg.Method, Params: msg.Params})
+		}
+	}
+}
+
+func (c *Client) dispatch(event Event) {
+	c.mutex.Lock()
+	subscriptions := append([]*Subscription(nil), c.subscriptions...)
+	c.mutex.Unlock()
+
+	for _, subscription := range subscriptions {
+		if subscription.matches(event.Method) {
+			subscription.send(event)
+		}
+	}
+}
+
+func (c *Client) shutdown() {
+	c.conn.Close()
+
+	c.mutex.Lock()
+	c.closed = true
+	subscriptions := c.subscriptions
+	c.subscriptions = nil
+	c.mutex.Unlock()
+
+	close(c.done)
+	for _, subscription := range subscriptions {
+		close(subscription.incoming)
+	}
+}
+
+func (c *Client) unsubscribe(subscription *Subscription) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, existing := range c.subscriptions {
+		if existing == subscription {
+			c.subscriptions = append(c.subscriptions[:i], c.subscriptions[i+1:]...)
+			return
+		}
+	}
+}
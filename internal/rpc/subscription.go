@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"strings"
+	"sync"
+)
+
+// A Subscription receives events from a Client. Events are buffered
+// until they are received, so that slow consumers never block the Client.
+type Subscription struct {
+	client   *Client
+	methods  []string
+	incoming chan Event
+	events   chan Event
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func newSubscription(client *Client, methods []string) *Subscription {
+	subscription := &Subscription{
+		client:   client,
+		methods:  methods,
+		incoming: make(chan Event),
+		events:   make(chan Event),
+		closed:   make(chan struct{}),
+	}
+	go subscription.deliver()
+	return subscription
+}
+
+// Events returns a channel of events in the order that they were received.
+// The channel is closed after the Subscription or its Client is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops the Subscription. Events that have not been received are discarded.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.client.unsubscribe(s)
+		close(s.closed)
+	})
+}
+
+func (s *Subscription) matches(method string) bool {
+	if len(s.methods) == 0 {
+		return true
+	}
+	for _, expected := range s.methods {
+		if method == expected || (!strings.Contains(expected, ".") && strings.HasPrefix(method, expected+".")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Subscription) send(event Event) {
+	select {
+	case s.incoming <- event:
+	case <-s.closed:
+	}
+}
+
+func (s *Subscription) deliver() {
+	defer close(s.events)
+
+	var queue []Event
+	incoming := s.incoming
+	for {
+		var events chan Event
+		var next Event
+		if len(queue) > 0 {
+			events, next = s.events, queue[0]
+		} else if incoming == nil {
+			return
+		}
+
+		select {
+		case event, ok := <-incoming:
+			if !ok {
+				incoming = nil
+				continue
+			}
+			queue = append(queue, event)
+		case events <- next:
+			queue = queue[1:]
+		case <-s.closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Package websocket implements the subset of the WebSocket protocol
+// (https://tools.ietf.org/html/rfc6455) needed to communicate with browser
+// debugging protocols, which exchange JSON text messages.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// DefaultMaxMessageSize is the maximum size of a message read by a Conn with
+// no MaxMessageSize. It allows for large messages, such as screenshots.
+const DefaultMaxMessageSize = 64 << 20
+
+// ErrClosed is returned when reading from or writing to a closed connection.
+var ErrClosed = errors.New("websocket closed")
+
+// ErrMessageTooLarge is returned when reading a message that is larger than
+// the maximum message size. The connection is closed, as the rest of the
+// message cannot be skipped safely.
+var ErrMessageTooLarge = errors.New("websocket message too large")
+
+// A Conn is a WebSocket connection. ReadMessage must not be called
+// concurrently, but WriteMessage and Close are safe for concurrent use.
+type Conn struct {
+	// MaxMessageSize is the maximum size in bytes of a message returned by
+	// ReadMessage. If zero, DefaultMaxMessageSize is used.
+	MaxMessageSize int
+
+	conn   net.Conn
+	reader *bufio.Reader
+	client bool
+
+	writeMutex sync.Mutex
+	closeOnce  sync.Once
+}
+
+// Dial opens a WebSocket connection to the provided ws:// or wss:// URL.
+func Dial(ctx context.Context, rawURL string) (*Conn, error) {
+	wsURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	address := wsURL.Host
+	if wsURL.Port() == "" {
+		switch wsURL.Scheme {
+		case "ws":
+			address += ":80"
+		case "wss":
+			address += ":443"
+		}
+	}
+	if wsURL.Scheme != "ws" && wsURL.Scheme != "wss" {
+		return nil, fmt.Errorf("invalid URL scheme: %s", wsURL.Scheme)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if wsURL.Scheme == "wss" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: wsURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	ws, err := handshake(conn, wsURL)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open websocket: %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+	return ws, nil
+}
+
+func handshake(conn net.Conn, wsURL *url.URL) (*Conn, error) {
+	nonce := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	request := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: wsURL.Path, RawPath: wsURL.RawPath, RawQuery: wsURL.RawQuery},
+		Host:   wsURL.Host,
+		Header: http.Header{
+			"Upgrade":               {"websocket"},
+			"Connection":            {"Upgrade"},
+			"Sec-Websocket-Key":     {key},
+			"Sec-Websocket-Version": {"13"},
+		},
+	}
+	if request.URL.Path == "" {
+		request.URL.Path = "/"
+	}
+	if err := request.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("unexpected response: %s", response.Status)
+	}
+	if response.Header.Get("Sec-Websocket-Accept") != acceptKey(key) {
+		return nil, errors.New("invalid Sec-WebSocket-Accept header")
+	}
+	return &Conn{conn: conn, reader: reader, client: true}, nil
+}
+
+// Upgrade upgrades an HTTP request to a WebSocket connection on the server.
+func Upgrade(w http.ResponseWriter, request *http.Request) (*Conn, error) {
+	if !strings.EqualFold(request.Header.Get("Upgrade"), "websocket") {
+		http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+		return nil, errors.New("not a websocket request")
+	}
+	key := request.Header.Get("Sec-Websocket-Key")
+	if key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websockets are not supported", http.StatusInternalServerError)
+		return nil, errors.New("response cannot be hijacked")
+	}
+	conn, buffer, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(buffer, "HTTP/1.1 101 Switching Protocols\r\n")
+	fmt.Fprintf(buffer, "Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	fmt.Fprintf(buffer, "Sec-WebSocket-Accept: %s\r\n\r\n", acceptKey(key))
+	if err := buffer.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Conn{conn: conn, reader: buffer.Reader}, nil
+}
+
+func acceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// ReadMessage returns the next text or binary message. Control frames are
+// handled automatically. ErrClosed is returned when the connection is closed.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	maxSize := c.MaxMessageSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+
+	var message []byte
+	for {
+		final, opcode, payload, err := c.readFrame(uint64(maxSize - len(message)))
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+		case opPong:
+		case opClose:
+			c.writeFrame(opClose, payload)
+			c.conn.Close()
+			return nil, ErrClosed
+		case opText, opBinary, opContinuation:
+			message = append(message, payload...)
+			if final {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("unsupported websocket opcode: %d", opcode)
+		}
+	}
+}
+
+// WriteMessage sends a text message.
+func (c *Conn) WriteMessage(message []byte) error {
+	return c.writeFrame(opText, message)
+}
+
+// Close sends a close frame and closes the connection.
+func (c *Conn) Close() error {
+	err := ErrClosed
+	c.closeOnce.Do(func() {
+		c.writeFrame(opClose, []byte{0x03, 0xe8})
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// readFrame reads a frame with a payload of at most maxLength bytes.
+func (c *Conn) readFrame(maxLength uint64) (final bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+	final = header[0]&0x80 != 0
+	opcode = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+
+	if length > maxLength {
+		c.conn.Close()
+		return false, 0, nil, ErrMessageTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return final, opcode, payload, nil
+}
+
+func (c *Conn) readError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return ErrClosed
+	}
+	return err
+}
+
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode, 0}
+	length := len(payload)
+	switch {
+	case length < 126:
+		frame[1] = byte(length)
+	case length <= 0xffff:
+		frame[1] = 126
+		frame = append(frame, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(length))
+	default:
+		frame[1] = 127
+		frame = append(frame, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(frame[2:], uint64(length))
+	}
+
+	if c.client {
+		// clients must mask frames sent to servers
+		var mask [4]byte
+		if _, err := io.ReadFull(rand.Reader, mask[:]); err != nil {
+			return err
+		}
+		frame[1] |= 0x80
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if _, err := c.conn.Write(frame); err != nil {
+		return c.readError(err)
+	}
+	return nil
+}
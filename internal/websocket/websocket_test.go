@@ -0,0 +1,164 @@
+package websocket_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/agouti/internal/websocket"
+)
+
+var _ = Describe("Websocket", func() {
+	var (
+		server   *httptest.Server
+		received chan string
+	)
+
+	BeforeEach(func() {
+		received = make(chan string, 10)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := websocket.Upgrade(w, r)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			for {
+				message, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				received <- string(message)
+				if string(message) == "close" {
+					return
+				}
+				if err := conn.WriteMessage([]byte("echo: " + string(message))); err != nil {
+					return
+				}
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	wsURL := func() string {
+		return "ws" + strings.TrimPrefix(server.URL, "http") + "/some/path"
+	}
+
+	It("should exchange messages with the server", func() {
+		conn, err := websocket.Dial(context.Background(), wsURL())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		Expect(conn.WriteMessage([]byte("some message"))).To(Succeed())
+		Expect(conn.ReadMessage()).To(Equal([]byte("echo: some message")))
+		Eventually(received).Should(Receive(Equal("some message")))
+	})
+
+	It("should exchange large messages", func() {
+		conn, err := websocket.Dial(context.Background(), wsURL())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		for _, size := range []int{125, 126, 65535, 65536, 200000} {
+			message := strings.Repeat("a", size)
+			Expect(conn.WriteMessage([]byte(message))).To(Succeed())
+			Expect(conn.ReadMessage()).To(HaveLen(size + len("echo: ")))
+		}
+	})
+
+	It("should return ErrClosed when the server closes the connection", func() {
+		conn, err := websocket.Dial(context.Background(), wsURL())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		Expect(conn.WriteMessage([]byte("close"))).To(Succeed())
+		_, err = conn.ReadMessage()
+		Expect(err).To(Equal(websocket.ErrClosed))
+	})
+
+	It("should return ErrClosed when reading after the connection is closed", func() {
+		conn, err := websocket.Dial(context.Background(), wsURL())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.Close()).To(Succeed())
+		Expect(conn.Close()).To(Equal(websocket.ErrClosed))
+		_, err = conn.ReadMessage()
+		Expect(err).To(Equal(websocket.ErrClosed))
+	})
+
+	Context("when a message is larger than the maximum message size", func() {
+		It("should return an error without reading the message", func() {
+			limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := websocket.Upgrade(w, r)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				conn.MaxMessageSize = 1000
+				for {
+					message, err := conn.ReadMessage()
+					if err != nil {
+						received <- err.Error()
+						return
+					}
+					received <- string(message)
+				}
+			}))
+			defer limited.Close()
+
+			conn, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(limited.URL, "http"))
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+			Expect(conn.WriteMessage([]byte(strings.Repeat("a", 1000)))).To(Succeed())
+			Eventually(received).Should(Receive(HaveLen(1000)))
+			Expect(conn.WriteMessage([]byte(strings.Repeat("a", 1001)))).To(Succeed())
+			Eventually(received).Should(Receive(Equal("websocket message too large")))
+		})
+
+		It("should not allocate the length claimed by a frame", func() {
+			raw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, buffer, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				hash := sha1.Sum([]byte(r.Header.Get("Sec-Websocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+				buffer.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+				buffer.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n\r\n")
+				buffer.Write([]byte{0x82, 0x7f, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+				buffer.Flush()
+				ioutil.ReadAll(conn)
+			}))
+			defer raw.Close()
+
+			conn, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(raw.URL, "http"))
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+			_, err = conn.ReadMessage()
+			Expect(err).To(Equal(websocket.ErrMessageTooLarge))
+		})
+	})
+
+	Context("when the server does not support websockets", func() {
+		It("should return an error", func() {
+			plain := httptest.NewServer(http.NotFoundHandler())
+			defer plain.Close()
+			_, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(plain.URL, "http"))
+			Expect(err).To(MatchError("failed to open websocket: unexpected response: 404 Not Found"))
+		})
+	})
+
+	Context("when the URL is not a websocket URL", func() {
+		It("should return an error", func() {
+			_, err := websocket.Dial(context.Background(), server.URL)
+			Expect(err).To(MatchError("invalid URL scheme: http"))
+		})
+	})
+})
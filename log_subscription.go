@@ -0,0 +1,353 @@
+package agouti
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sclevine/agouti/api"
+)
+
+const (
+	defaultLogPollInterval = 500 * time.Millisecond
+	defaultLogBufferSize   = 100
+)
+
+var logLevels = map[string]int{
+	"ALL":     0,
+	"FINEST":  1,
+	"FINER":   1,
+	"FINE":    1,
+	"DEBUG":   1,
+	"CONFIG":  2,
+	"INFO":    2,
+	"WARNING": 3,
+	"SEVERE":  4,
+}
+
+// A LogFilter selects the logs that are received by a LogSubscription.
+type LogFilter struct {
+	// MinLevel is the lowest level of log that is received ("DEBUG", "INFO",
+	// "WARNING", or "SEVERE"). Logs of all levels are received by default.
+	MinLevel string
+
+	// Match, if not nil, must return true for a log to be received.
+	Match func(log Log) bool
+}
+
+func (f LogFilter) matches(log Log) bool {
+	if f.MinLevel != "" && logLevels[strings.ToUpper(log.Level)] < logLevels[strings.ToUpper(f.MinLevel)] {
+		return false
+	}
+	return f.Match == nil || f.Match(log)
+}
+
+// A LogSubscription receives the logs of a Page as they are logged.
+// Logs are buffered until they are received. When the buffer is full, the
+// oldest logs are discarded (see LogBufferSize and Dropped).
+type LogSubscription struct {
+	logs      chan Log
+	handle    func(Log)
+	handleErr func(error)
+	filter    LogFilter
+	cleanup   func() error
+	logging   *logging
+
+	mutex   sync.Mutex
+	dropped int
+	err     error
+
+	once    sync.Once
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// SubscribeLogs returns a LogSubscription that receives new logs of the
+// provided log types (ex. "browser") that match the provided filter. Valid log
+// types may be obtained using the LogTypes method.
+//
+// If the page has a WebDriver BiDi websocket (see the BiDi Option) and only
+// "browser" logs are requested, logs are received from the browser as they
+// occur. Otherwise, the WebDriver is polled for new logs (see LogPollInterval).
+// Logs received by a polling subscription are also returned by ReadNewLogs
+// and ReadAllLogs.
+//
+// The subscription must be closed when it is no longer needed. Subscriptions
+// are closed when the page is destroyed.
+func (p *Page) SubscribeLogs(logTypes []string, filter LogFilter) (*LogSubscription, error) {
+	return p.subscribeLogs(logTypes, filter, nil, nil)
+}
+
+func (p *Page) subscribeLogs(logTypes []string, filter LogFilter, handle func(Log), handleErr func(error)) (*LogSubscription, error) {
+	if len(logTypes) == 0 {
+		return nil, errors.New("failed to subscribe to logs: no log types provided")
+	}
+	if _, ok := logLevels[strings.ToUpper(filter.MinLevel)]; filter.MinLevel != "" && !ok {
+		return nil, fmt.Errorf("failed to subscribe to logs: invalid log level: %s", filter.MinLevel)
+	}
+
+	subscription := &LogSubscription{
+		logs:      make(chan Log, p.logging.bufferSize()),
+		handle:    handle,
+		handleErr: handleErr,
+		filter:    filter,
+		logging:   p.logging,
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+
+	session, ok := p.session.(*api.Session)
+	if ok && session.BiDiURL() != "" && len(logTypes) == 1 && logTypes[0] == "browser" {
+		bidi, err := session.OpenBiDi(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to subscribe to logs: %w", err)
+		}
+		entries, err := bidi.SubscribeLogs(context.Background())
+		if err != nil {
+			bidi.Close()
+			return nil, fmt.Errorf("failed to subscribe to logs: %w", err)
+		}
+		subscription.cleanup = bidi.Close
+		go subscription.receive(entries)
+	} else {
+		go subscription.poll(p.pollLogs, logTypes, p.logging.pollInterval())
+	}
+
+	p.logging.add(subscription)
+	return subscription, nil
+}
+
+// Logs returns a channel of logs in the order that they were received.
+// The channel is closed after the subscription is closed or fails (see Err).
+func (s *LogSubscription) Logs() <-chan Log {
+	return s.logs
+}
+
+// Dropped returns the number of logs that were discarded because the buffer
+// was full.
+func (s *LogSubscription) Dropped() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.dropped
+}
+
+// Err returns the error that stopped the subscription, if any.
+func (s *LogSubscription) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+// Close stops the subscription. Logs that are already buffered may still be
+// received before the channel returned by Logs is closed.
+func (s *LogSubscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		s.logging.remove(s)
+		close(s.stop)
+		<-s.stopped
+		if s.cleanup != nil {
+			err = s.cleanup()
+		}
+	})
+	return err
+}
+
+func (s *LogSubscription) poll(readLogs func(logType string) ([]Log, error), logTypes []string, interval time.Duration) {
+	defer s.finish()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, logType := range logTypes {
+			logs, err := readLogs(logType)
+			if err != nil {
+				s.fail(err)
+				return
+			}
+			for _, log := range logs {
+				s.deliver(log)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *LogSubscription) receive(entries *api.LogSubscription) {
+	defer s.finish()
+	defer entries.Close()
+
+	for {
+		select {
+		case entry, ok := <-entries.Entries():
+			if !ok {
+				s.fail(errors.New("failed to retrieve logs: BiDi connection closed"))
+				return
+			}
+			s.deliver(Log{Message: entry.Text, Level: bidiLogLevel(entry.Level), Time: entry.Time()})
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *LogSubscription) deliver(log Log) {
+	if !s.filter.matches(log) {
+		return
+	}
+	if s.handle != nil {
+		s.handle(log)
+		return
+	}
+
+	for {
+		select {
+		case s.logs <- log:
+			return
+		default:
+		}
+
+		// discard the oldest log to make room
+		select {
+		case <-s.logs:
+			s.mutex.Lock()
+			s.dropped++
+			s.mutex.Unlock()
+		default:
+		}
+	}
+}
+
+func (s *LogSubscription) fail(err error) {
+	s.mutex.Lock()
+	s.err = err
+	s.mutex.Unlock()
+
+	if s.handleErr != nil {
+		s.handleErr(err)
+	}
+}
+
+func (s *LogSubscription) finish() {
+	close(s.logs)
+	close(s.stopped)
+}
+
+func bidiLogLevel(level string) string {
+	switch level {
+	case "debug":
+		return "DEBUG"
+	case "info":
+		return "INFO"
+	case "warn":
+		return "WARNING"
+	case "error":
+		return "SEVERE"
+	}
+	return strings.ToUpper(level)
+}
+
+// failOnSevereLogs subscribes to browser logs and calls fail for each SEVERE log.
+// If the logs cannot be retrieved (ex. because the WebDriver does not support
+// logs), fail is called with the error, as SEVERE logs would go unreported.
+func (p *Page) failOnSevereLogs(fail func(message string, callerSkip ...int)) {
+	callFail := func(message string) {
+		// ginkgo.Fail panics to abort the test, which must not crash the subscription
+		defer func() { recover() }()
+		fail(message)
+	}
+
+	_, err := p.subscribeLogs([]string{"browser"}, LogFilter{MinLevel: "SEVERE"}, func(log Log) {
+		message := "SEVERE browser log: " + log.Message
+		if log.Location != "" {
+			message += " (" + log.Location + ")"
+		}
+		callFail(message)
+	}, func(err error) {
+		callFail("failed to check for SEVERE browser logs: " + err.Error())
+	})
+	if err != nil {
+		callFail("failed to check for SEVERE browser logs: " + err.Error())
+	}
+}
+
+// logging tracks the log subscriptions of a page and its copies.
+type logging struct {
+	interval time.Duration
+	size     int
+
+	mutex         sync.Mutex
+	subscriptions []*LogSubscription
+}
+
+func newLogging(pageOptions *config) *logging {
+	return &logging{interval: pageOptions.LogPollInterval, size: pageOptions.LogBufferSize}
+}
+
+func (l *logging) pollInterval() time.Duration {
+	if l == nil || l.interval <= 0 {
+		return defaultLogPollInterval
+	}
+	return l.interval
+}
+
+func (l *logging) bufferSize() int {
+	if l == nil || l.size <= 0 {
+		return defaultLogBufferSize
+	}
+	return l.size
+}
+
+// storedLogs returns the number of logs of each type retained for ReadAllLogs,
+// or zero if all logs are retained.
+func (l *logging) storedLogs() int {
+	if l == nil || l.size <= 0 {
+		return 0
+	}
+	return l.size
+}
+
+func (l *logging) add(subscription *LogSubscription) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.subscriptions = append(l.subscriptions, subscription)
+}
+
+func (l *logging) remove(subscription *LogSubscription) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for i, existing := range l.subscriptions {
+		if existing == subscription {
+			l.subscriptions = append(l.subscriptions[:i], l.subscriptions[i+1:]...)
+			return
+		}
+	}
+}
+
+func (l *logging) close() {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	subscriptions := l.subscriptions
+	l.subscriptions = nil
+	l.mutex.Unlock()
+
+	for _, subscription := range subscriptions {
+		subscription.Close()
+	}
+}
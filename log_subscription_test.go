@@ -0,0 +1,262 @@
+package agouti_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti"
+	"github.com/sclevine/agouti/agoutitest"
+	"github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/internal/mocks"
+	"github.com/sclevine/agouti/internal/websocket"
+	"github.com/sclevine/agouti/matchers"
+)
+
+var _ = Describe("LogSubscription", func() {
+	var (
+		server  *agoutitest.Server
+		page    *Page
+		session *agoutitest.Session
+	)
+
+	BeforeEach(func() {
+		server = agoutitest.NewServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	openPage := func(options ...Option) {
+		var err error
+		page, err = NewPage(server.URL(), append([]Option{LogPollInterval(10 * time.Millisecond)}, options...)...)
+		Expect(err).NotTo(HaveOccurred())
+		session = server.Sessions()[0]
+	}
+
+	addLog := func(message, level string) {
+		session.AddLog("browser", api.Log{Message: message, Level: level, Timestamp: 1500000000000})
+	}
+
+	Describe("Page#SubscribeLogs", func() {
+		It("should receive new logs that match the filter", func() {
+			openPage()
+			addLog("some debug log", "DEBUG")
+			addLog("some log (1:22)", "INFO")
+			addLog("some filtered log", "WARNING")
+			addLog("some error", "SEVERE")
+
+			subscription, err := page.SubscribeLogs([]string{"browser"}, LogFilter{
+				MinLevel: "info",
+				Match:    func(log Log) bool { return !strings.Contains(log.Message, "filtered") },
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer subscription.Close()
+
+			var log Log
+			Eventually(subscription.Logs()).Should(Receive(&log))
+			Expect(log).To(Equal(Log{Message: "some log", Location: "1:22", Level: "INFO", Time: time.Unix(1500000000, 0)}))
+			Eventually(subscription.Logs()).Should(Receive(&log))
+			Expect(log.Message).To(Equal("some error"))
+
+			addLog("some later log", "INFO")
+			Eventually(subscription.Logs()).Should(Receive(&log))
+			Expect(log.Message).To(Equal("some later log"))
+			Consistently(subscription.Logs(), "50ms").ShouldNot(Receive())
+		})
+
+		It("should discard the oldest logs when the buffer is full", func() {
+			openPage(LogBufferSize(2))
+			addLog("first log", "INFO")
+			addLog("second log", "INFO")
+			addLog("third log", "INFO")
+
+			subscription, err := page.SubscribeLogs([]string{"browser"}, LogFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			defer subscription.Close()
+
+			Eventually(subscription.Dropped).Should(Equal(1))
+			var log Log
+			Expect(subscription.Logs()).To(Receive(&log))
+			Expect(log.Message).To(Equal("second log"))
+			Expect(subscription.Logs()).To(Receive(&log))
+			Expect(log.Message).To(Equal("third log"))
+		})
+
+		It("should store the logs that it receives for ReadAllLogs and ReadNewLogs", func() {
+			openPage()
+			subscription, err := page.SubscribeLogs([]string{"browser"}, LogFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			defer subscription.Close()
+
+			addLog("first log", "INFO")
+			Eventually(subscription.Logs()).Should(Receive())
+			addLog("second log", "SEVERE")
+			Eventually(subscription.Logs()).Should(Receive())
+
+			logs, err := page.ReadAllLogs("browser")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logs).To(HaveLen(2))
+			Expect(logs[0].Message).To(Equal("first log"))
+			Expect(logs[1].Message).To(Equal("second log"))
+			Expect(page).To(matchers.HaveLoggedError("second log"))
+
+			logs, err = page.ReadNewLogs("browser")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logs).To(HaveLen(2))
+			Expect(page.ReadNewLogs("browser")).To(BeEmpty())
+		})
+
+		It("should close the logs channel when the subscription is closed", func() {
+			openPage()
+			subscription, err := page.SubscribeLogs([]string{"browser", "driver"}, LogFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subscription.Close()).To(Succeed())
+			Expect(subscription.Close()).To(Succeed())
+			Eventually(subscription.Logs()).Should(BeClosed())
+			Expect(subscription.Err()).NotTo(HaveOccurred())
+		})
+
+		It("should close the subscription when the page is destroyed", func() {
+			openPage()
+			subscription, err := page.SubscribeLogs([]string{"browser"}, LogFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page.Destroy()).To(Succeed())
+			Eventually(subscription.Logs()).Should(BeClosed())
+		})
+
+		Context("when the session has a BiDi websocket", func() {
+			var bidiServer *httptest.Server
+
+			BeforeEach(func() {
+				bidiServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					conn, err := websocket.Upgrade(w, r)
+					if err != nil {
+						return
+					}
+					defer conn.Close()
+					for {
+						message, err := conn.ReadMessage()
+						if err != nil {
+							return
+						}
+						var command struct{ ID int }
+						json.Unmarshal(message, &command)
+						response, _ := json.Marshal(map[string]interface{}{"type": "success", "id": command.ID, "result": map[string]string{}})
+						conn.WriteMessage(response)
+						for _, level := range []string{"debug", "error"} {
+							conn.WriteMessage([]byte(`{"type": "event", "method": "log.entryAdded", "params": {
+								"type": "console", "level": "` + level + `", "text": "some ` + level + ` log", "timestamp": 1500000000000
+							}}`))
+						}
+					}
+				}))
+			})
+
+			AfterEach(func() {
+				bidiServer.Close()
+			})
+
+			It("should receive browser logs from the browser as they occur", func() {
+				wsURL := "ws" + strings.TrimPrefix(bidiServer.URL, "http")
+				openPage(Desired(Capabilities{"webSocketUrl": wsURL}), LogPollInterval(time.Hour))
+
+				subscription, err := page.SubscribeLogs([]string{"browser"}, LogFilter{MinLevel: "INFO"})
+				Expect(err).NotTo(HaveOccurred())
+				defer subscription.Close()
+
+				var log Log
+				Eventually(subscription.Logs()).Should(Receive(&log))
+				Expect(log).To(Equal(Log{Message: "some error log", Level: "SEVERE", Time: time.Unix(1500000000, 0)}))
+			})
+		})
+
+		Context("when the logs cannot be retrieved", func() {
+			It("should close the logs channel and return the error", func() {
+				mockSession := &mocks.Session{}
+				mockSession.NewLogsCall.Err = errors.New("some error")
+				subscription, err := NewTestPage(mockSession).SubscribeLogs([]string{"browser"}, LogFilter{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(subscription.Logs()).Should(BeClosed())
+				Expect(subscription.Err()).To(MatchError("failed to retrieve logs: some error"))
+			})
+		})
+
+		Context("when no log types are provided", func() {
+			It("should return an error", func() {
+				_, err := NewTestPage(&mocks.Session{}).SubscribeLogs(nil, LogFilter{})
+				Expect(err).To(MatchError("failed to subscribe to logs: no log types provided"))
+			})
+		})
+
+		Context("when the minimum level is invalid", func() {
+			It("should return an error", func() {
+				_, err := NewTestPage(&mocks.Session{}).SubscribeLogs([]string{"browser"}, LogFilter{MinLevel: "some level"})
+				Expect(err).To(MatchError("failed to subscribe to logs: invalid log level: some level"))
+			})
+		})
+	})
+
+	Describe("Page#ReadAllLogs", func() {
+		It("should only retain the most recent logs when the LogBufferSize Option is provided", func() {
+			openPage(LogBufferSize(2))
+			addLog("first log", "INFO")
+			addLog("second log", "INFO")
+			addLog("third log", "INFO")
+
+			logs, err := page.ReadAllLogs("browser")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logs).To(HaveLen(2))
+			Expect(logs[0].Message).To(Equal("second log"))
+			Expect(logs[1].Message).To(Equal("third log"))
+		})
+	})
+
+	Describe("FailOnSevereLogs", func() {
+		It("should call the failure handler for each severe browser log", func() {
+			messages := make(chan string, 10)
+			openPage(FailOnSevereLogs(func(message string, _ ...int) {
+				messages <- message
+			}))
+			addLog("some warning", "WARNING")
+			addLog("some error (1:22)", "SEVERE")
+
+			Eventually(messages).Should(Receive(Equal("SEVERE browser log: some error (1:22)")))
+			Consistently(messages, "50ms").ShouldNot(Receive())
+			Expect(page.Destroy()).To(Succeed())
+		})
+
+		It("should call the failure handler when the logs cannot be retrieved", func() {
+			unsupported := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				response.WriteHeader(404)
+				response.Write([]byte(`{"value": {"error": "unknown command", "message": "some error"}}`))
+			}))
+			defer unsupported.Close()
+
+			messages := make(chan string, 10)
+			JoinPage(unsupported.URL+"/session/some-id", LogPollInterval(10*time.Millisecond), FailOnSevereLogs(func(message string, _ ...int) {
+				messages <- message
+			}))
+			Eventually(messages).Should(Receive(Equal("failed to check for SEVERE browser logs: failed to retrieve logs: request unsuccessful: some error")))
+		})
+
+		It("should recover from failure handlers that panic", func() {
+			failures := make(chan int, 10)
+			openPage(FailOnSevereLogs(func(string, ...int) {
+				failures <- 1
+				panic("some failure")
+			}))
+			addLog("first error", "SEVERE")
+			Eventually(failures).Should(Receive())
+			addLog("second error", "SEVERE")
+			Eventually(failures).Should(Receive())
+			Expect(page.Destroy()).To(Succeed())
+		})
+	})
+})
@@ -1,6 +1,10 @@
 package mocks
 
-import "github.com/sclevine/agouti"
+import (
+	"image"
+
+	"github.com/sclevine/agouti"
+)
 
 type Page struct {
 	TitleCall struct {
@@ -28,6 +32,11 @@ type Page struct {
 		ReturnLogs []agouti.Log
 		Err        error
 	}
+
+	ScreenshotImageCall struct {
+		ReturnImage image.Image
+		Err         error
+	}
 }
 
 func (*Page) String() string {
@@ -54,3 +63,7 @@ func (p *Page) ReadAllLogs(logType string) ([]agouti.Log, error) {
 	p.ReadAllLogsCall.LogType = logType
 	return p.ReadAllLogsCall.ReturnLogs, p.ReadAllLogsCall.Err
 }
+
+func (p *Page) ScreenshotImage() (image.Image, error) {
+	return p.ScreenshotImageCall.ReturnImage, p.ScreenshotImageCall.Err
+}
@@ -1,5 +1,7 @@
 package mocks
 
+import "image"
+
 type Selection struct {
 	StringCall struct {
 		ReturnString string
@@ -52,6 +54,16 @@ type Selection struct {
 		ReturnEquals bool
 		Err          error
 	}
+
+	ScreenshotImageCall struct {
+		ReturnImage image.Image
+		Err         error
+	}
+
+	BoundsCall struct {
+		ReturnBounds image.Rectangle
+		Err          error
+	}
 }
 
 func (s *Selection) String() string {
@@ -96,3 +108,11 @@ func (s *Selection) EqualsElement(selection interface{}) (bool, error) {
 	s.EqualsElementCall.Selection = selection
 	return s.EqualsElementCall.ReturnEquals, s.EqualsElementCall.Err
 }
+
+func (s *Selection) ScreenshotImage() (image.Image, error) {
+	return s.ScreenshotImageCall.ReturnImage, s.ScreenshotImageCall.Err
+}
+
+func (s *Selection) Bounds() (image.Rectangle, error) {
+	return s.BoundsCall.ReturnBounds, s.BoundsCall.Err
+}
@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+)
+
+// UpdateGoldensEnv is the environment variable that causes ScreenshotMatcher
+// to replace golden images with the actual screenshots instead of comparing them.
+const UpdateGoldensEnv = "AGOUTI_UPDATE_GOLDENS"
+
+// A Region is a part of a screenshot, such as a selection.
+type Region interface {
+	Bounds() (image.Rectangle, error)
+}
+
+type ScreenshotMatcher struct {
+	GoldenPath    string
+	Tolerance     uint8
+	MaxDiffPixels int
+	Ignore        []Region
+	AntiAliasing  bool
+	DiffPath      string
+
+	diffPixels int
+	sizes      string
+}
+
+func (m *ScreenshotMatcher) Match(actual interface{}) (success bool, err error) {
+	actualScreenshot, ok := actual.(interface {
+		ScreenshotImage() (image.Image, error)
+	})
+
+	if !ok {
+		return false, fmt.Errorf("MatchScreenshot matcher requires a Page or Selection.  Got:\n%s", format.Object(actual, 1))
+	}
+
+	screenshot, err := actualScreenshot.ScreenshotImage()
+	if err != nil {
+		return false, err
+	}
+
+	if updateGoldens() {
+		if err := os.MkdirAll(filepath.Dir(m.GoldenPath), 0777); err != nil {
+			return false, fmt.Errorf("failed to create golden image directory: %w", err)
+		}
+		if err := writePNG(m.GoldenPath, screenshot); err != nil {
+			return false, fmt.Errorf("failed to update golden image: %w", err)
+		}
+		return true, nil
+	}
+
+	golden, err := readPNG(m.GoldenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("golden image %s does not exist, set %s=true to create it", m.GoldenPath, UpdateGoldensEnv)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read golden image: %w", err)
+	}
+
+	ignored, err := m.ignoredRegions(actual)
+	if err != nil {
+		return false, err
+	}
+
+	m.diffPixels, m.sizes = 0, ""
+	if golden.Bounds().Size() != screenshot.Bounds().Size() {
+		m.sizes = fmt.Sprintf("%s vs. %s", golden.Bounds().Size(), screenshot.Bounds().Size())
+		return false, nil
+	}
+
+	diff, diffPixels := m.compare(golden, screenshot, ignored)
+	m.diffPixels = diffPixels
+	if diffPixels <= m.MaxDiffPixels {
+		return true, nil
+	}
+	if err := writePNG(m.diffPath(), diff); err != nil {
+		return false, fmt.Errorf("failed to save diff image: %w", err)
+	}
+	return false, nil
+}
+
+func (m *ScreenshotMatcher) FailureMessage(actual interface{}) (message string) {
+	return valueMessage(actual, "to match screenshot", m.GoldenPath, m.difference())
+}
+
+func (m *ScreenshotMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return valueMessage(actual, "not to match screenshot", m.GoldenPath, m.difference())
+}
+
+func (m *ScreenshotMatcher) difference() string {
+	if m.sizes != "" {
+		return "a screenshot with a different size: " + m.sizes
+	}
+	if m.diffPixels <= m.MaxDiffPixels {
+		return fmt.Sprintf("%d different pixels", m.diffPixels)
+	}
+	return fmt.Sprintf("%d different pixels (see %s)", m.diffPixels, m.diffPath())
+}
+
+func (m *ScreenshotMatcher) diffPath() string {
+	if m.DiffPath != "" {
+		return m.DiffPath
+	}
+	return strings.TrimSuffix(m.GoldenPath, filepath.Ext(m.GoldenPath)) + ".diff.png"
+}
+
+// ignoredRegions returns the ignored regions relative to the actual
+// screenshot, which is offset by the bounds of the actual selection.
+func (m *ScreenshotMatcher) ignoredRegions(actual interface{}) ([]image.Rectangle, error) {
+	var origin image.Point
+	if actualSelection, ok := actual.(interface {
+		Bounds() (image.Rectangle, error)
+	}); ok && len(m.Ignore) > 0 {
+		bounds, err := actualSelection.Bounds()
+		if err != nil {
+			return nil, err
+		}
+		origin = bounds.Min
+	}
+
+	var regions []image.Rectangle
+	for _, selection := range m.Ignore {
+		bounds, err := selection.Bounds()
+		if err != nil {
+			return nil, err
+		}
+		regions = append(regions, bounds.Sub(origin))
+	}
+	return regions, nil
+}
+
+// compare returns a diff image, where different pixels are red and ignored
+// regions are yellow, along with the number of different pixels.
+func (m *ScreenshotMatcher) compare(golden, screenshot image.Image, ignored []image.Rectangle) (image.Image, int) {
+	size := golden.Bounds().Size()
+	diff := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	diffPixels := 0
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			point := image.Pt(x, y)
+			expected := pixel(golden, point)
+			switch {
+			case isIgnored(point, ignored):
+				diff.SetRGBA(x, y, color.RGBA{255, 255, 0, 255})
+			case m.similar(expected, pixel(screenshot, point)):
+				diff.SetRGBA(x, y, faded(expected))
+			case m.AntiAliasing && m.antiAliased(golden, screenshot, point) && m.antiAliased(screenshot, golden, point):
+				diff.SetRGBA(x, y, color.RGBA{255, 192, 192, 255})
+			default:
+				diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+				diffPixels++
+			}
+		}
+	}
+	return diff, diffPixels
+}
+
+// antiAliased returns true if the pixel in the first image is similar to a
+// neighboring pixel in the second image, as is the case for edges that are
+// rendered with slightly different anti-aliasing.
+func (m *ScreenshotMatcher) antiAliased(first, second image.Image, point image.Point) bool {
+	expected := pixel(first, point)
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			neighbor := point.Add(image.Pt(dx, dy))
+			if neighbor == point || !neighbor.In(rectangleOf(second)) {
+				continue
+			}
+			if m.similar(expected, pixel(second, neighbor)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *ScreenshotMatcher) similar(expected, actual color.RGBA) bool {
+	return channelDifference(expected.R, actual.R) <= m.Tolerance &&
+		channelDifference(expected.G, actual.G) <= m.Tolerance &&
+		channelDifference(expected.B, actual.B) <= m.Tolerance &&
+		channelDifference(expected.A, actual.A) <= m.Tolerance
+}
+
+func channelDifference(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func isIgnored(point image.Point, ignored []image.Rectangle) bool {
+	for _, region := range ignored {
+		if point.In(region) {
+			return true
+		}
+	}
+	return false
+}
+
+// pixel returns the pixel at a point relative to the top-left corner of the image.
+func pixel(img image.Image, point image.Point) color.RGBA {
+	return color.RGBAModel.Convert(img.At(point.X+img.Bounds().Min.X, point.Y+img.Bounds().Min.Y)).(color.RGBA)
+}
+
+func rectangleOf(img image.Image) image.Rectangle {
+	return image.Rectangle{Max: img.Bounds().Size()}
+}
+
+func faded(original color.RGBA) color.RGBA {
+	gray := uint8((uint16(original.R) + uint16(original.G) + uint16(original.B)) / 3)
+	gray = 255 - (255-gray)/4
+	return color.RGBA{gray, gray, gray, 255}
+}
+
+func updateGoldens() bool {
+	switch strings.ToLower(os.Getenv(UpdateGoldensEnv)) {
+	case "", "0", "false", "no":
+		return false
+	}
+	return true
+}
+
+func readPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
+
+func writePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,244 @@
+package internal_test
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/matchers/internal"
+	"github.com/sclevine/agouti/matchers/internal/mocks"
+)
+
+func solidImage(width, height int, fill color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.SetRGBA(x, y, fill)
+		}
+	}
+	return img
+}
+
+func savePNG(path string, img image.Image) {
+	file, err := os.Create(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer file.Close()
+	Expect(png.Encode(file, img)).To(Succeed())
+}
+
+func loadPNG(path string) image.Image {
+	file, err := os.Open(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer file.Close()
+	img, err := png.Decode(file)
+	Expect(err).NotTo(HaveOccurred())
+	return img
+}
+
+var _ = Describe("ScreenshotMatcher", func() {
+	var (
+		matcher    *ScreenshotMatcher
+		page       *mocks.Page
+		directory  string
+		goldenPath string
+		white      = color.RGBA{255, 255, 255, 255}
+		black      = color.RGBA{0, 0, 0, 255}
+		red        = color.RGBA{255, 0, 0, 255}
+	)
+
+	BeforeEach(func() {
+		var err error
+		directory, err = ioutil.TempDir("", "agouti")
+		Expect(err).NotTo(HaveOccurred())
+		goldenPath = filepath.Join(directory, "golden.png")
+		savePNG(goldenPath, solidImage(10, 10, white))
+
+		page = &mocks.Page{}
+		page.ScreenshotImageCall.ReturnImage = solidImage(10, 10, white)
+		matcher = &ScreenshotMatcher{GoldenPath: goldenPath}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(directory)
+	})
+
+	Describe("#Match", func() {
+		Context("when the actual object has a screenshot", func() {
+			Context("when the screenshot matches the golden image", func() {
+				It("should successfully return true", func() {
+					Expect(matcher.Match(page)).To(BeTrue())
+				})
+
+				It("should not save a diff image", func() {
+					matcher.Match(page)
+					Expect(filepath.Join(directory, "golden.diff.png")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			Context("when the screenshot does not match the golden image", func() {
+				BeforeEach(func() {
+					screenshot := solidImage(10, 10, white)
+					screenshot.SetRGBA(2, 3, black)
+					screenshot.SetRGBA(4, 5, color.RGBA{250, 250, 250, 255})
+					page.ScreenshotImageCall.ReturnImage = screenshot
+				})
+
+				It("should successfully return false", func() {
+					Expect(matcher.Match(page)).To(BeFalse())
+				})
+
+				It("should save a diff image next to the golden image", func() {
+					matcher.Match(page)
+					diff := loadPNG(filepath.Join(directory, "golden.diff.png"))
+					Expect(color.RGBAModel.Convert(diff.At(2, 3))).To(Equal(red))
+					Expect(color.RGBAModel.Convert(diff.At(4, 5))).To(Equal(red))
+					Expect(color.RGBAModel.Convert(diff.At(0, 0))).To(Equal(white))
+				})
+
+				It("should save the diff image to the provided diff path", func() {
+					matcher.DiffPath = filepath.Join(directory, "some.png")
+					matcher.Match(page)
+					Expect(matcher.DiffPath).To(BeAnExistingFile())
+				})
+
+				Context("when the different pixels are within the tolerance", func() {
+					It("should only count pixels outside of the tolerance", func() {
+						matcher.Tolerance = 5
+						matcher.MaxDiffPixels = 1
+						Expect(matcher.Match(page)).To(BeTrue())
+						matcher.Tolerance = 4
+						Expect(matcher.Match(page)).To(BeFalse())
+					})
+				})
+
+				Context("when the different pixels are in an ignored region", func() {
+					var ignored *mocks.Selection
+
+					BeforeEach(func() {
+						ignored = &mocks.Selection{}
+						ignored.BoundsCall.ReturnBounds = image.Rect(2, 3, 5, 6)
+						matcher.Ignore = append(matcher.Ignore, ignored)
+					})
+
+					It("should successfully return true", func() {
+						Expect(matcher.Match(page)).To(BeTrue())
+					})
+
+					Context("when the actual object is a selection", func() {
+						It("should offset the ignored region by the bounds of the selection", func() {
+							selection := &mocks.Selection{}
+							selection.ScreenshotImageCall.ReturnImage = page.ScreenshotImageCall.ReturnImage
+							selection.BoundsCall.ReturnBounds = image.Rect(2, 3, 12, 13)
+							Expect(matcher.Match(selection)).To(BeFalse())
+							ignored.BoundsCall.ReturnBounds = image.Rect(4, 6, 7, 9)
+							Expect(matcher.Match(selection)).To(BeTrue())
+						})
+					})
+
+					Context("when retrieving the bounds of the ignored region fails", func() {
+						It("should return an error", func() {
+							ignored.BoundsCall.Err = errors.New("some error")
+							_, err := matcher.Match(page)
+							Expect(err).To(MatchError("some error"))
+						})
+					})
+				})
+			})
+
+			Context("when the screenshot differs by anti-aliasing", func() {
+				BeforeEach(func() {
+					golden := solidImage(10, 10, white)
+					screenshot := solidImage(10, 10, white)
+					for y := 0; y < 10; y++ {
+						golden.SetRGBA(4, y, black)
+						screenshot.SetRGBA(5, y, black)
+					}
+					savePNG(goldenPath, golden)
+					page.ScreenshotImageCall.ReturnImage = screenshot
+				})
+
+				It("should only return true when anti-aliasing is ignored", func() {
+					Expect(matcher.Match(page)).To(BeFalse())
+					matcher.AntiAliasing = true
+					Expect(matcher.Match(page)).To(BeTrue())
+				})
+			})
+
+			Context("when the screenshot is a different size than the golden image", func() {
+				It("should successfully return false", func() {
+					page.ScreenshotImageCall.ReturnImage = solidImage(10, 20, white)
+					Expect(matcher.Match(page)).To(BeFalse())
+					Expect(matcher.FailureMessage(page)).To(ContainSubstring("a screenshot with a different size: (10,10) vs. (10,20)"))
+				})
+			})
+
+			Context("when the golden image does not exist", func() {
+				It("should return an error", func() {
+					matcher.GoldenPath = filepath.Join(directory, "missing.png")
+					_, err := matcher.Match(page)
+					Expect(err).To(MatchError(ContainSubstring("missing.png does not exist, set AGOUTI_UPDATE_GOLDENS=true to create it")))
+				})
+			})
+
+			Context("when golden images are being updated", func() {
+				BeforeEach(func() {
+					os.Setenv(UpdateGoldensEnv, "true")
+				})
+
+				AfterEach(func() {
+					os.Unsetenv(UpdateGoldensEnv)
+				})
+
+				It("should save the screenshot as the golden image and return true", func() {
+					matcher.GoldenPath = filepath.Join(directory, "new", "golden.png")
+					page.ScreenshotImageCall.ReturnImage = solidImage(3, 3, red)
+					Expect(matcher.Match(page)).To(BeTrue())
+					golden := loadPNG(matcher.GoldenPath)
+					Expect(golden.Bounds()).To(Equal(image.Rect(0, 0, 3, 3)))
+					Expect(color.RGBAModel.Convert(golden.At(1, 1))).To(Equal(red))
+				})
+			})
+
+			Context("when taking the screenshot fails", func() {
+				It("should return an error", func() {
+					page.ScreenshotImageCall.Err = errors.New("some error")
+					_, err := matcher.Match(page)
+					Expect(err).To(MatchError("some error"))
+				})
+			})
+		})
+
+		Context("when the actual object does not have a screenshot", func() {
+			It("should return an error", func() {
+				_, err := matcher.Match("not a page")
+				Expect(err).To(MatchError("MatchScreenshot matcher requires a Page or Selection.  Got:\n    <string>: not a page"))
+			})
+		})
+	})
+
+	Describe("#FailureMessage", func() {
+		It("should return a failure message with the number of different pixels and the diff path", func() {
+			screenshot := solidImage(10, 10, white)
+			screenshot.SetRGBA(2, 3, black)
+			page.ScreenshotImageCall.ReturnImage = screenshot
+			matcher.Match(page)
+			message := matcher.FailureMessage(page)
+			Expect(message).To(ContainSubstring("Expected page to match screenshot\n    " + goldenPath))
+			Expect(message).To(ContainSubstring("but found\n    1 different pixels (see " + filepath.Join(directory, "golden.diff.png") + ")"))
+		})
+	})
+
+	Describe("#NegatedFailureMessage", func() {
+		It("should return a negated failure message", func() {
+			matcher.Match(page)
+			message := matcher.NegatedFailureMessage(page)
+			Expect(message).To(Equal("Expected page not to match screenshot\n    " + goldenPath + "\nbut found\n    0 different pixels"))
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package matchers
+
+import (
+	"github.com/onsi/gomega/types"
+	"github.com/sclevine/agouti"
+	"github.com/sclevine/agouti/matchers/internal"
+)
+
+// UpdateGoldensEnv is the environment variable that, when set (ex. to "true"),
+// causes MatchScreenshot to save the actual screenshot as the golden image
+// instead of comparing them.
+const UpdateGoldensEnv = internal.UpdateGoldensEnv
+
+// ScreenshotOptions configure how MatchScreenshot compares screenshots.
+type ScreenshotOptions struct {
+	// Tolerance is the maximum difference in any color channel (0-255) for
+	// which pixels are considered equal.
+	Tolerance uint8
+
+	// MaxDiffPixels is the number of different pixels allowed.
+	MaxDiffPixels int
+
+	// Ignore contains selections (ex. timestamps) that are excluded from the
+	// comparison. Each selection must refer to exactly one element.
+	Ignore []*agouti.Selection
+
+	// AntiAliasing ignores different pixels that are similar to a neighboring
+	// pixel in the other image, such as anti-aliased edges of text.
+	AntiAliasing bool
+
+	// DiffPath is where a diff image is saved when the screenshots do not
+	// match. It defaults to the golden path with a ".diff.png" extension.
+	DiffPath string
+}
+
+// MatchScreenshot passes when a screenshot of the provided page or selection
+// matches the PNG golden image at goldenPath. Selection screenshots fail if
+// the selection refers to more than one element. When the screenshots do not
+// match, a diff image is saved where different pixels are red and ignored
+// regions are yellow. See UpdateGoldensEnv to create or update golden images.
+//    Expect(page.Find("#login")).To(MatchScreenshot("golden/login.png", ScreenshotOptions{Tolerance: 8}))
+func MatchScreenshot(goldenPath string, options ScreenshotOptions) types.GomegaMatcher {
+	var ignore []internal.Region
+	for _, selection := range options.Ignore {
+		ignore = append(ignore, selection)
+	}
+	return &internal.ScreenshotMatcher{
+		GoldenPath:    goldenPath,
+		Tolerance:     options.Tolerance,
+		MaxDiffPixels: options.MaxDiffPixels,
+		Ignore:        ignore,
+		AntiAliasing:  options.AntiAliasing,
+		DiffPath:      options.DiffPath,
+	}
+}
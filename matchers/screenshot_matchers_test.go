@@ -0,0 +1,48 @@
+package matchers_test
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti/matchers"
+	"github.com/sclevine/agouti/matchers/internal/mocks"
+)
+
+var _ = Describe("Screenshot Matchers", func() {
+	Describe("#MatchScreenshot", func() {
+		var (
+			directory  string
+			goldenPath string
+		)
+
+		BeforeEach(func() {
+			var err error
+			directory, err = ioutil.TempDir("", "agouti")
+			Expect(err).NotTo(HaveOccurred())
+			goldenPath = filepath.Join(directory, "golden.png")
+			file, err := os.Create(goldenPath)
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+			Expect(png.Encode(file, image.NewRGBA(image.Rect(0, 0, 2, 2)))).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(directory)
+		})
+
+		It("should return a ScreenshotMatcher that compares screenshots of pages and selections", func() {
+			page := &mocks.Page{}
+			page.ScreenshotImageCall.ReturnImage = image.NewRGBA(image.Rect(0, 0, 2, 2))
+			Expect(page).To(MatchScreenshot(goldenPath, ScreenshotOptions{}))
+
+			selection := &mocks.Selection{}
+			selection.ScreenshotImageCall.ReturnImage = image.NewRGBA(image.Rect(0, 0, 2, 3))
+			Expect(selection).NotTo(MatchScreenshot(goldenPath, ScreenshotOptions{Tolerance: 8}))
+		})
+	})
+})
@@ -1,6 +1,6 @@
 package agouti
 
-import "github.com/sclevine/agouti/internal/target"
+import "context"
 
 // A MultiSelection is a Selection that may be indexed using the At() method.
 // All Selection methods are available on a MultiSelection.
@@ -17,13 +17,16 @@ type MultiSelection struct {
 	Selection
 }
 
-func newMultiSelection(session apiSession, selectors target.Selectors) *MultiSelection {
-	return &MultiSelection{*newSelection(session, selectors)}
-}
-
 // At finds an element at the provided index. It only applies to the immediate selection,
 // meaning that the returned selection may still refer to multiple elements if any parent
 // of the immediate selection is also a *MultiSelection.
 func (s *MultiSelection) At(index int) *Selection {
-	return newSelection(s.session, s.selectors.At(index))
+	return s.selection(s.selectors.At(index))
+}
+
+// WithContext returns a copy of the multi-selection that aborts all WebDriver
+// requests when the provided context is canceled or exceeds its deadline.
+func (s *MultiSelection) WithContext(ctx context.Context) *MultiSelection {
+	contextSelectable := selectable{s.sessionWithContext(ctx), nil, s.staleRetries}
+	return contextSelectable.multiSelection(s.selectors)
 }
@@ -1,6 +1,9 @@
 package agouti_test
 
 import (
+	"context"
+	"errors"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/sclevine/agouti"
@@ -32,4 +35,19 @@ var _ = Describe("MultiSelection", func() {
 			Expect(elements[0].ID).To(Equal("some-id"))
 		})
 	})
+
+	Describe("#WithContext", func() {
+		It("should return a selection with the same selectors", func() {
+			Expect(selection.WithContext(context.Background()).String()).To(Equal("selection 'CSS: #selector'"))
+		})
+
+		It("should send requests using the provided context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := selection.WithContext(ctx).At(0).Elements()
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			_, err = selection.Selection.WithContext(ctx).Elements()
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		})
+	})
 })
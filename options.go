@@ -1,8 +1,13 @@
 package agouti
 
 import (
+	"encoding/base64"
+	"io"
 	"net/http"
 	"time"
+
+	"github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/proxy"
 )
 
 type config struct {
@@ -13,6 +18,26 @@ type config struct {
 	Debug               bool
 	HTTPClient          *http.Client
 	ChromeOptions       map[string]interface{}
+	StaleRetries        int
+	Observers           []api.Observer
+	InterceptNetwork    bool
+	NetworkProxy        *proxy.Proxy
+	BiDi                bool
+	LogPollInterval     time.Duration
+	LogBufferSize       int
+	FailOnSevereLogs    func(message string, callerSkip ...int)
+	SessionIdleTimeout  time.Duration
+	DriverStdout        io.Writer
+	DriverStderr        io.Writer
+	DriverLogDir        string
+	Headers             http.Header
+	RestartOnCrash      bool
+	StartRetries        int
+	DriverHost          string
+	DriverMinPort       int
+	DriverMaxPort       int
+	DriverBinary        string
+	Warnings            io.Writer
 }
 
 // An Option specifies configuration for a new WebDriver or Page.
@@ -61,6 +86,167 @@ var Debug Option = func(c *config) {
 	c.Debug = true
 }
 
+// RestartOnCrash is an Option that restarts the WebDriver process if it exits
+// unexpectedly (ex. because it crashed), so that new pages may be opened.
+// Pages opened before the process exited return errors that describe how the
+// process exited (see WebDriver.Err). This Option must be provided to a
+// WebDriver to take effect.
+var RestartOnCrash Option = func(c *config) {
+	c.RestartOnCrash = true
+}
+
+// StartRetries provides an Option for specifying how many times the WebDriver
+// process is started again on a new port if it exits before it becomes
+// available, as when another process binds to its port first. The default is
+// 2 retries. This Option must be provided to a WebDriver to take effect.
+func StartRetries(retries int) Option {
+	return func(c *config) {
+		c.StartRetries = retries
+	}
+}
+
+// DriverAddress provides an Option for specifying the host that the WebDriver
+// process is started on, and the range of ports that it may be started on
+// (ex. for environments with firewall rules). By default, any free port on
+// 127.0.0.1 is used. If minPort and maxPort are 0, any free port on the host
+// is used. This Option must be provided to a WebDriver to take effect.
+func DriverAddress(host string, minPort, maxPort int) Option {
+	return func(c *config) {
+		c.DriverHost = host
+		c.DriverMinPort = minPort
+		c.DriverMaxPort = maxPort
+	}
+}
+
+// DriverOutput provides an Option for specifying writers that receive the
+// stdout and stderr of the WebDriver process (ex. a *bytes.Buffer or an
+// *os.File). Either writer may be nil. This Option must be provided to a
+// WebDriver to take effect.
+func DriverOutput(stdout, stderr io.Writer) Option {
+	return func(c *config) {
+		c.DriverStdout = stdout
+		c.DriverStderr = stderr
+	}
+}
+
+// DriverBinary provides an Option for specifying the path to the binary
+// started by ChromeDriver, GeckoDriver, EdgeDriver, PhantomJS, or Selenium.
+// By default, the binary named by the AGOUTI_CHROMEDRIVER, AGOUTI_GECKODRIVER,
+// AGOUTI_EDGEDRIVER, AGOUTI_PHANTOMJS, or AGOUTI_SELENIUM environment variable
+// is started, or else the binary is located in PATH.
+func DriverBinary(path string) Option {
+	return func(c *config) {
+		c.DriverBinary = path
+	}
+}
+
+// Warnings provides an Option for specifying a writer that receives warnings
+// from the WebDriver (ex. when the versions of ChromeDriver and Chrome may be
+// incompatible). By default, warnings are written to os.Stderr. Warnings are
+// not written to the writers provided by DriverOutput or to DriverLogDir, as
+// they are not output of the WebDriver process. This Option must be provided
+// to a WebDriver to take effect.
+func Warnings(w io.Writer) Option {
+	return func(c *config) {
+		c.Warnings = w
+	}
+}
+
+// DriverLogDir provides an Option for specifying a directory that the output
+// of the WebDriver process is written to. A new log file named after the
+// command and its port is created each time the WebDriver is started. This
+// Option must be provided to a WebDriver to take effect.
+func DriverLogDir(dir string) Option {
+	return func(c *config) {
+		c.DriverLogDir = dir
+	}
+}
+
+// InterceptNetwork is an Option that starts a proxy.Proxy for each new page of
+// a WebDriver, so that network requests made by the page may be mocked and
+// inspected (see *Page.Route). New pages are configured to use their proxy,
+// unless a Desired Option specifies a different proxy.
+//
+// This Option must be provided to a WebDriver (ex. ChromeDriver) to take effect.
+// Browsers may bypass proxies for localhost (ex. Chrome requires the
+// "--proxy-bypass-list=<-loopback>" argument to proxy localhost).
+var InterceptNetwork Option = func(c *config) {
+	c.InterceptNetwork = true
+}
+
+// BiDi is an Option that requests a WebDriver BiDi websocket for new pages,
+// so that browser events may be received as they occur (see
+// api.Session.OpenBiDi). It requires a driver that supports WebDriver BiDi,
+// such as a recent geckodriver or ChromeDriver.
+var BiDi Option = func(c *config) {
+	c.BiDi = true
+}
+
+// LogPollInterval provides an Option for specifying how often a
+// LogSubscription polls the WebDriver for new logs. The default is 500ms.
+func LogPollInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.LogPollInterval = interval
+	}
+}
+
+// LogBufferSize provides an Option for specifying how many logs a
+// LogSubscription buffers before it discards the oldest logs. The default is 100.
+// If provided, it also limits the number of logs of each type that are
+// retained for ReadAllLogs and ReadNewLogs, which are otherwise all retained.
+func LogBufferSize(size int) Option {
+	return func(c *config) {
+		c.LogBufferSize = size
+	}
+}
+
+// FailOnSevereLogs provides an Option that subscribes new pages to their
+// "browser" logs and calls the provided function for each SEVERE log, so that
+// JavaScript errors fail the current test. The function is also called if the
+// logs cannot be retrieved (ex. because the WebDriver does not support logs).
+// The function is compatible with ginkgo.Fail, and it is called from a
+// background goroutine, so any panic that it raises is recovered. See
+// *Page.SubscribeLogs for how logs are received. To use it with the testing
+// package:
+//    agouti.FailOnSevereLogs(func(message string, _ ...int) { t.Error(message) })
+func FailOnSevereLogs(fail func(message string, callerSkip ...int)) Option {
+	return func(c *config) {
+		c.FailOnSevereLogs = fail
+	}
+}
+
+// SessionIdleTimeout provides an Option for specifying how long a page may go
+// unused before its session is deleted by the WebDriver, so that sessions
+// leaked by tests that never destroy their pages do not accumulate. Sessions
+// are not deleted for being idle by default. This Option must be provided to
+// a WebDriver to take effect.
+func SessionIdleTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.SessionIdleTimeout = timeout
+	}
+}
+
+// BasicAuth provides an Option for specifying credentials that are sent with
+// every request to the WebDriver, as required by some Selenium Grid services.
+// This Option must be provided to a WebDriver to take effect.
+func BasicAuth(username, password string) Option {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return Header("Authorization", "Basic "+credentials)
+}
+
+// Header provides an Option for specifying a header that is sent with every
+// request to the WebDriver (ex. an API key for a cloud browser service). This
+// Option may be provided multiple times. It must be provided to a WebDriver
+// to take effect.
+func Header(name, value string) Option {
+	return func(c *config) {
+		if c.Headers == nil {
+			c.Headers = http.Header{}
+		}
+		c.Headers.Add(name, value)
+	}
+}
+
 // HTTPClient provides an Option for specifying a *http.Client
 func HTTPClient(client *http.Client) Option {
 	return func(c *config) {
@@ -68,7 +254,36 @@ func HTTPClient(client *http.Client) Option {
 	}
 }
 
+// StaleRetries provides an Option for specifying how many times a Selection
+// method should re-select its elements and try again when an element becomes
+// stale (ex. because the DOM was re-rendered). When an action on multiple
+// elements is retried, it is only repeated on the elements that it did not
+// already succeed on, in the order that they are re-selected. Retries are
+// disabled by default.
+func StaleRetries(retries int) Option {
+	return func(c *config) {
+		c.StaleRetries = retries
+	}
+}
+
+// Observe provides an Option for specifying an api.Observer that is notified
+// after each WebDriver command sent by a Page, with the command's method,
+// endpoint, body, response, duration, and error. This Option may be provided
+// multiple times. See api.LogObserver, api.JSONObserver, and
+// api.NewCommandSummary for built-in observers. For example:
+//    summary := api.NewCommandSummary()
+//    page, _ := driver.NewPage(agouti.Observe(summary))
+//    ...
+//    fmt.Println(summary)
+func Observe(observer api.Observer) Option {
+	return func(c *config) {
+		c.Observers = append(c.Observers, observer)
+	}
+}
+
 func (c config) Merge(options []Option) *config {
+	c.Observers = append([]api.Observer(nil), c.Observers...)
+	c.Headers = c.Headers.Clone()
 	for _, option := range options {
 		option(&c)
 	}
@@ -89,5 +304,12 @@ func (c *config) Capabilities() Capabilities {
 	if c.RejectInvalidSSL {
 		merged.Without("acceptSslCerts")
 	}
+	if c.BiDi {
+		merged.With("webSocketUrl")
+	}
+	if _, ok := merged["proxy"]; !ok && c.NetworkProxy != nil {
+		address := c.NetworkProxy.Address()
+		merged.Proxy(ProxyConfig{ProxyType: "manual", HTTPProxy: address, SSLProxy: address})
+	}
 	return merged
 }
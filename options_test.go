@@ -1,13 +1,16 @@
 package agouti_test
 
 import (
+	"bytes"
 	"net/http"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/sclevine/agouti"
+	"github.com/sclevine/agouti/api"
 	. "github.com/sclevine/agouti/internal/matchers"
+	"github.com/sclevine/agouti/proxy"
 )
 
 var _ = Describe("Options", func() {
@@ -54,6 +57,25 @@ var _ = Describe("Options", func() {
 		})
 	})
 
+	Describe("#InterceptNetwork", func() {
+		It("should return an Option that intercepts network requests", func() {
+			config := NewTestConfig()
+			Expect(config.InterceptNetwork).To(BeFalse())
+			InterceptNetwork(config)
+			Expect(config.InterceptNetwork).To(BeTrue())
+		})
+	})
+
+	Describe("#BiDi", func() {
+		It("should return an Option that requests a BiDi websocket", func() {
+			config := NewTestConfig()
+			Expect(config.Capabilities()).NotTo(HaveKey("webSocketUrl"))
+			BiDi(config)
+			Expect(config.BiDi).To(BeTrue())
+			Expect(config.Capabilities()["webSocketUrl"]).To(BeTrue())
+		})
+	})
+
 	Describe("#HTTPClient", func() {
 		It("should return an Option that sets a *http.Client", func() {
 			config := NewTestConfig()
@@ -74,6 +96,136 @@ var _ = Describe("Options", func() {
 		})
 	})
 
+	Describe("#StaleRetries", func() {
+		It("should return an Option that sets the number of stale element retries", func() {
+			config := NewTestConfig()
+			StaleRetries(3)(config)
+			Expect(config.StaleRetries).To(Equal(3))
+		})
+	})
+
+	Describe("#LogPollInterval", func() {
+		It("should return an Option that sets the log polling interval", func() {
+			config := NewTestConfig()
+			LogPollInterval(time.Second)(config)
+			Expect(config.LogPollInterval).To(Equal(time.Second))
+		})
+	})
+
+	Describe("#LogBufferSize", func() {
+		It("should return an Option that sets the log buffer size", func() {
+			config := NewTestConfig()
+			LogBufferSize(10)(config)
+			Expect(config.LogBufferSize).To(Equal(10))
+		})
+	})
+
+	Describe("#RestartOnCrash", func() {
+		It("should return an Option that restarts the WebDriver when it crashes", func() {
+			config := NewTestConfig()
+			RestartOnCrash(config)
+			Expect(config.RestartOnCrash).To(BeTrue())
+		})
+	})
+
+	Describe("#StartRetries", func() {
+		It("should return an Option that sets the number of WebDriver start retries", func() {
+			config := NewTestConfig()
+			StartRetries(5)(config)
+			Expect(config.StartRetries).To(Equal(5))
+		})
+	})
+
+	Describe("#DriverAddress", func() {
+		It("should return an Option that sets the address range of the WebDriver", func() {
+			config := NewTestConfig()
+			DriverAddress("0.0.0.0", 4444, 4450)(config)
+			Expect(config.DriverHost).To(Equal("0.0.0.0"))
+			Expect(config.DriverMinPort).To(Equal(4444))
+			Expect(config.DriverMaxPort).To(Equal(4450))
+		})
+	})
+
+	Describe("#DriverOutput", func() {
+		It("should return an Option that sets the writers for the WebDriver output", func() {
+			config := NewTestConfig()
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			DriverOutput(stdout, stderr)(config)
+			Expect(config.DriverStdout).To(BeIdenticalTo(stdout))
+			Expect(config.DriverStderr).To(BeIdenticalTo(stderr))
+		})
+	})
+
+	Describe("#DriverLogDir", func() {
+		It("should return an Option that sets the directory for WebDriver logs", func() {
+			config := NewTestConfig()
+			DriverLogDir("some/dir")(config)
+			Expect(config.DriverLogDir).To(Equal("some/dir"))
+		})
+	})
+
+	Describe("#Warnings", func() {
+		It("should return an Option that sets the writer for WebDriver warnings", func() {
+			config := NewTestConfig()
+			warnings := &bytes.Buffer{}
+			Warnings(warnings)(config)
+			Expect(config.Warnings).To(BeIdenticalTo(warnings))
+		})
+	})
+
+	Describe("#DriverBinary", func() {
+		It("should return an Option that sets the path to the WebDriver binary", func() {
+			config := NewTestConfig()
+			DriverBinary("some/chromedriver")(config)
+			Expect(config.DriverBinary).To(Equal("some/chromedriver"))
+		})
+	})
+
+	Describe("#SessionIdleTimeout", func() {
+		It("should return an Option that sets the session idle timeout", func() {
+			config := NewTestConfig()
+			SessionIdleTimeout(time.Minute)(config)
+			Expect(config.SessionIdleTimeout).To(Equal(time.Minute))
+		})
+	})
+
+	Describe("#BasicAuth", func() {
+		It("should return an Option that adds a basic authorization header", func() {
+			config := NewTestConfig()
+			BasicAuth("some-user", "some-password")(config)
+			Expect(config.Headers.Get("Authorization")).To(Equal("Basic c29tZS11c2VyOnNvbWUtcGFzc3dvcmQ="))
+		})
+	})
+
+	Describe("#Header", func() {
+		It("should return an Option that adds a header", func() {
+			config := NewTestConfig()
+			Header("Some-Header", "some value")(config)
+			Header("Some-Header", "some other value")(config)
+			Expect(config.Headers).To(Equal(http.Header{"Some-Header": {"some value", "some other value"}}))
+		})
+	})
+
+	Describe("#FailOnSevereLogs", func() {
+		It("should return an Option that sets the failure handler for severe logs", func() {
+			config := NewTestConfig()
+			var message string
+			FailOnSevereLogs(func(m string, _ ...int) { message = m })(config)
+			config.FailOnSevereLogs("some message")
+			Expect(message).To(Equal("some message"))
+		})
+	})
+
+	Describe("#Observe", func() {
+		It("should return an Option that adds an observer", func() {
+			config := NewTestConfig()
+			first, second := api.NewCommandSummary(), api.NewCommandSummary()
+			Observe(first)(config)
+			Observe(second)(config)
+			Expect(config.Observers).To(Equal([]api.Observer{first, second}))
+		})
+	})
+
 	Describe("#Merge", func() {
 		It("should apply any provided options to an existing config", func() {
 			config := NewTestConfig()
@@ -83,6 +235,14 @@ var _ = Describe("Options", func() {
 			Expect(newConfig.Timeout).To(Equal(5 * time.Second))
 			Expect(newConfig.Debug).To(BeTrue())
 		})
+
+		It("should not modify the headers of the existing config", func() {
+			config := NewTestConfig()
+			Header("Some-Header", "some value")(config)
+			newConfig := config.Merge([]Option{Header("Some-Header", "some other value")})
+			Expect(config.Headers.Values("Some-Header")).To(Equal([]string{"some value"}))
+			Expect(newConfig.Headers.Values("Some-Header")).To(Equal([]string{"some value", "some other value"}))
+		})
 	})
 
 	Describe("#Capabilities", func() {
@@ -101,5 +261,35 @@ var _ = Describe("Options", func() {
 				Equal(map[string]interface{}{"args": "someArg"}),
 			)
 		})
+
+		Context("when the config has a network proxy", func() {
+			var networkProxy *proxy.Proxy
+
+			BeforeEach(func() {
+				networkProxy = proxy.New()
+				Expect(networkProxy.Start()).To(Succeed())
+			})
+
+			AfterEach(func() {
+				networkProxy.Stop()
+			})
+
+			It("should configure the page to use the proxy", func() {
+				config := NewTestConfig()
+				config.NetworkProxy = networkProxy
+				Expect(config.Capabilities()["proxy"]).To(Equal(ProxyConfig{
+					ProxyType: "manual",
+					HTTPProxy: networkProxy.Address(),
+					SSLProxy:  networkProxy.Address(),
+				}))
+			})
+
+			It("should not override a desired proxy", func() {
+				config := NewTestConfig()
+				config.NetworkProxy = networkProxy
+				Desired(NewCapabilities().Proxy(ProxyConfig{ProxyType: "direct"}))(config)
+				Expect(config.Capabilities()["proxy"]).To(Equal(ProxyConfig{ProxyType: "direct"}))
+			})
+		})
 	})
 })
@@ -1,24 +1,45 @@
 package agouti
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/proxy"
 )
 
 // A Page represents an open browser session. Pages may be created using the
 // *WebDriver.Page() method or by calling the NewPage or SauceLabs functions.
 type Page struct {
 	selectable
-	logs map[string][]Log
+	proxy   *proxy.Proxy
+	state   *pageState
+	logging *logging
+}
+
+// pageState is shared by a Page and the copies of it returned by WithContext.
+type pageState struct {
+	mutex sync.Mutex
+	logs  map[string][]Log
+
+	// unread contains the logs read by log subscriptions that have not been
+	// returned by ReadNewLogs.
+	unread map[string][]Log
+
+	// harOffset is the number of exchanges recorded by the proxy before
+	// StartHAR was called, or -1 if HAR recording is not started.
+	harOffset int
+}
+
+func newPageState() *pageState {
+	return &pageState{harOffset: -1}
 }
 
 // A Log represents a single log message
@@ -43,21 +64,28 @@ func NewPage(url string, options ...Option) (*Page, error) {
 	pageOptions := config{}.Merge(options)
 	session, err := api.OpenWithClient(url, pageOptions.Capabilities(), pageOptions.HTTPClient)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to WebDriver: %s", err)
+		return nil, fmt.Errorf("failed to connect to WebDriver: %w", err)
 	}
-	return newPage(session), nil
+	return newPage(session, pageOptions), nil
 }
 
 // JoinPage creates a Page using existing session URL. This method takes Options
-// but respects only the HTTPClient Option if provided.
+// but respects only the HTTPClient, StaleRetries, and Observe Options if provided.
 func JoinPage(url string, options ...Option) *Page {
 	pageOptions := config{}.Merge(options)
 	session := api.NewWithClient(url, pageOptions.HTTPClient)
-	return newPage(session)
+	return newPage(session, pageOptions)
 }
 
-func newPage(session *api.Session) *Page {
-	return &Page{selectable{session, nil}, nil}
+func newPage(session *api.Session, pageOptions *config) *Page {
+	for _, observer := range pageOptions.Observers {
+		session = session.WithObserver(observer)
+	}
+	page := &Page{selectable{session, nil, pageOptions.StaleRetries}, pageOptions.NetworkProxy, newPageState(), newLogging(pageOptions)}
+	if pageOptions.FailOnSevereLogs != nil {
+		page.failOnSevereLogs(pageOptions.FailOnSevereLogs)
+	}
+	return page
 }
 
 // String returns a string representation of the Page. Currently: "page"
@@ -71,19 +99,99 @@ func (p *Page) Session() *api.Session {
 	return p.session.(*api.Session)
 }
 
-// Destroy closes any open browsers by ending the session.
+// Proxy returns the proxy used by the page, or nil if the page was not created
+// by a WebDriver with the InterceptNetwork Option. Each page has its own proxy,
+// which is stopped when the page is destroyed.
+func (p *Page) Proxy() *proxy.Proxy {
+	return p.proxy
+}
+
+// Route registers a handler for network requests with the provided method
+// that match the provided URL pattern. See proxy.Proxy.Route for details. For
+// example, to mock an API:
+//    page.Route("GET", "/api/user", proxy.JSON(200, map[string]string{"name": "Some Name"}))
+// This method requires a WebDriver created with the InterceptNetwork Option.
+// Routes only apply to requests made by the page, and are removed when the
+// page is reset.
+func (p *Page) Route(method, pattern string, handler proxy.Handler) error {
+	if p.proxy == nil {
+		return errors.New("failed to route requests: network interception is not enabled, see InterceptNetwork")
+	}
+	p.proxy.Route(method, pattern, handler)
+	return nil
+}
+
+// StartHAR starts recording the network traffic of the page for StopHAR.
+// This method requires a WebDriver created with the InterceptNetwork Option.
+func (p *Page) StartHAR() error {
+	if p.proxy == nil {
+		return errors.New("failed to start HAR: network interception is not enabled, see InterceptNetwork")
+	}
+	p.state.mutex.Lock()
+	defer p.state.mutex.Unlock()
+	p.state.harOffset = len(p.proxy.Traffic())
+	return nil
+}
+
+// StopHAR stops recording network traffic and returns a HAR 1.2 archive of
+// all requests made by the page that completed since StartHAR was called,
+// including their headers, bodies, and timings. For example, to save the
+// traffic of a failed test:
+//    har, err := page.StopHAR()
+//    ...
+//    har.WriteFile("failed-test.har")
+func (p *Page) StopHAR() (*proxy.HAR, error) {
+	p.state.mutex.Lock()
+	defer p.state.mutex.Unlock()
+	if p.proxy == nil || p.state.harOffset < 0 {
+		return nil, errors.New("failed to stop HAR: HAR recording was not started")
+	}
+
+	traffic := p.proxy.Traffic()
+	offset := p.state.harOffset
+	if offset > len(traffic) {
+		// the traffic was cleared while recording
+		offset = 0
+	}
+	p.state.harOffset = -1
+	return proxy.NewHAR(traffic[offset:]), nil
+}
+
+// WithContext returns a copy of the Page that aborts all WebDriver requests
+// when the provided context is canceled or exceeds its deadline. Errors caused
+// by the context wrap the context error (ex. context.DeadlineExceeded).
+// Selections created from the returned Page share its context, and HAR
+// recording and logs are shared with the original Page.
+func (p *Page) WithContext(ctx context.Context) *Page {
+	return &Page{selectable{p.sessionWithContext(ctx), nil, p.staleRetries}, p.proxy, p.state, p.logging}
+}
+
+// Destroy closes any open browsers by ending the session, and stops the proxy
+// of the page.
 func (p *Page) Destroy() error {
+	p.logging.close()
 	if err := p.session.Delete(); err != nil {
-		return fmt.Errorf("failed to destroy session: %s", err)
+		stopProxy(p.proxy)
+		return fmt.Errorf("failed to destroy session: %w", err)
 	}
-	return nil
+	return stopProxy(p.proxy)
 }
 
-// Reset deletes all cookies set for the current domain and navigates to a blank page.
+// Reset deletes all cookies set for the current domain, removes the routes
+// and recorded traffic of the page's proxy, and navigates to a blank page.
 // Unlike Destroy, Reset will permit the page to be re-used after it is called.
 // Reset is faster than Destroy, but any cookies from domains outside the current
 // domain will remain after a page is reset.
 func (p *Page) Reset() error {
+	if p.proxy != nil {
+		p.state.mutex.Lock()
+		p.proxy.ClearRoutes()
+		p.proxy.ClearTraffic()
+		if p.state.harOffset > 0 {
+			p.state.harOffset = 0
+		}
+		p.state.mutex.Unlock()
+	}
 	p.ConfirmPopup()
 
 	url, err := p.URL()
@@ -116,7 +224,7 @@ func (p *Page) Reset() error {
 // Navigate navigates to the provided URL.
 func (p *Page) Navigate(url string) error {
 	if err := p.session.SetURL(url); err != nil {
-		return fmt.Errorf("failed to navigate: %s", err)
+		return fmt.Errorf("failed to navigate: %w", err)
 	}
 	return nil
 }
@@ -125,7 +233,7 @@ func (p *Page) Navigate(url string) error {
 func (p *Page) GetCookies() ([]*http.Cookie, error) {
 	apiCookies, err := p.session.GetCookies()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get cookies: %s", err)
+		return nil, fmt.Errorf("failed to get cookies: %w", err)
 	}
 	cookies := []*http.Cookie{}
 	for _, apiCookie := range apiCookies {
@@ -167,7 +275,7 @@ func (p *Page) SetCookie(cookie *http.Cookie) error {
 	}
 
 	if err := p.session.SetCookie(apiCookie); err != nil {
-		return fmt.Errorf("failed to set cookie: %s", err)
+		return fmt.Errorf("failed to set cookie: %w", err)
 	}
 	return nil
 }
@@ -175,7 +283,7 @@ func (p *Page) SetCookie(cookie *http.Cookie) error {
 // DeleteCookie deletes a cookie on the page by name.
 func (p *Page) DeleteCookie(name string) error {
 	if err := p.session.DeleteCookie(name); err != nil {
-		return fmt.Errorf("failed to delete cookie %s: %s", name, err)
+		return fmt.Errorf("failed to delete cookie %s: %w", name, err)
 	}
 	return nil
 }
@@ -183,7 +291,7 @@ func (p *Page) DeleteCookie(name string) error {
 // ClearCookies deletes all cookies on the page.
 func (p *Page) ClearCookies() error {
 	if err := p.session.DeleteCookies(); err != nil {
-		return fmt.Errorf("failed to clear cookies: %s", err)
+		return fmt.Errorf("failed to clear cookies: %w", err)
 	}
 	return nil
 }
@@ -192,7 +300,7 @@ func (p *Page) ClearCookies() error {
 func (p *Page) URL() (string, error) {
 	url, err := p.session.GetURL()
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve URL: %s", err)
+		return "", fmt.Errorf("failed to retrieve URL: %w", err)
 	}
 	return url, nil
 }
@@ -201,11 +309,11 @@ func (p *Page) URL() (string, error) {
 func (p *Page) Size(width, height int) error {
 	window, err := p.session.GetWindow()
 	if err != nil {
-		return fmt.Errorf("failed to retrieve window: %s", err)
+		return fmt.Errorf("failed to retrieve window: %w", err)
 	}
 
 	if err := window.SetSize(width, height); err != nil {
-		return fmt.Errorf("failed to set window size: %s", err)
+		return fmt.Errorf("failed to set window size: %w", err)
 	}
 
 	return nil
@@ -214,28 +322,18 @@ func (p *Page) Size(width, height int) error {
 // Screenshot takes a screenshot and saves it to the provided filename.
 // The provided filename may be an absolute or relative path.
 func (p *Page) Screenshot(filename string) error {
-	absFilePath, err := filepath.Abs(filename)
+	screenshot, err := p.ScreenshotPNG()
 	if err != nil {
-		return fmt.Errorf("failed to find absolute path for filename: %s", err)
-	}
-
-	screenshot, err := p.session.GetScreenshot()
-	if err != nil {
-		return fmt.Errorf("failed to retrieve screenshot: %s", err)
-	}
-
-	if err := ioutil.WriteFile(absFilePath, screenshot, 0666); err != nil {
-		return fmt.Errorf("failed to save screenshot: %s", err)
+		return err
 	}
-
-	return nil
+	return savePNG(filename, screenshot)
 }
 
 // Title returns the page title.
 func (p *Page) Title() (string, error) {
 	title, err := p.session.GetTitle()
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve page title: %s", err)
+		return "", fmt.Errorf("failed to retrieve page title: %w", err)
 	}
 	return title, nil
 }
@@ -244,7 +342,7 @@ func (p *Page) Title() (string, error) {
 func (p *Page) HTML() (string, error) {
 	html, err := p.session.GetSource()
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve page HTML: %s", err)
+		return "", fmt.Errorf("failed to retrieve page HTML: %w", err)
 	}
 	return html, nil
 }
@@ -273,7 +371,7 @@ func (p *Page) RunScript(body string, arguments map[string]interface{}, result i
 	cleanBody := fmt.Sprintf("return (function(%s) { %s; }).apply(this, arguments);", argumentList, body)
 
 	if err := p.session.Execute(cleanBody, values, result); err != nil {
-		return fmt.Errorf("failed to run script: %s", err)
+		return fmt.Errorf("failed to run script: %w", err)
 	}
 
 	return nil
@@ -283,7 +381,7 @@ func (p *Page) RunScript(body string, arguments map[string]interface{}, result i
 func (p *Page) PopupText() (string, error) {
 	text, err := p.session.GetAlertText()
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve popup text: %s", err)
+		return "", fmt.Errorf("failed to retrieve popup text: %w", err)
 	}
 	return text, nil
 }
@@ -291,7 +389,7 @@ func (p *Page) PopupText() (string, error) {
 // EnterPopupText enters text into an open prompt popup.
 func (p *Page) EnterPopupText(text string) error {
 	if err := p.session.SetAlertText(text); err != nil {
-		return fmt.Errorf("failed to enter popup text: %s", err)
+		return fmt.Errorf("failed to enter popup text: %w", err)
 	}
 	return nil
 }
@@ -299,7 +397,7 @@ func (p *Page) EnterPopupText(text string) error {
 // ConfirmPopup confirms an alert, confirm, or prompt popup.
 func (p *Page) ConfirmPopup() error {
 	if err := p.session.AcceptAlert(); err != nil {
-		return fmt.Errorf("failed to confirm popup: %s", err)
+		return fmt.Errorf("failed to confirm popup: %w", err)
 	}
 	return nil
 }
@@ -307,7 +405,7 @@ func (p *Page) ConfirmPopup() error {
 // CancelPopup cancels an alert, confirm, or prompt popup.
 func (p *Page) CancelPopup() error {
 	if err := p.session.DismissAlert(); err != nil {
-		return fmt.Errorf("failed to cancel popup: %s", err)
+		return fmt.Errorf("failed to cancel popup: %w", err)
 	}
 	return nil
 }
@@ -315,7 +413,7 @@ func (p *Page) CancelPopup() error {
 // Forward navigates forward in history.
 func (p *Page) Forward() error {
 	if err := p.session.Forward(); err != nil {
-		return fmt.Errorf("failed to navigate forward in history: %s", err)
+		return fmt.Errorf("failed to navigate forward in history: %w", err)
 	}
 	return nil
 }
@@ -323,7 +421,7 @@ func (p *Page) Forward() error {
 // Back navigates backwards in history.
 func (p *Page) Back() error {
 	if err := p.session.Back(); err != nil {
-		return fmt.Errorf("failed to navigate backwards in history: %s", err)
+		return fmt.Errorf("failed to navigate backwards in history: %w", err)
 	}
 	return nil
 }
@@ -331,7 +429,7 @@ func (p *Page) Back() error {
 // Refresh refreshes the page.
 func (p *Page) Refresh() error {
 	if err := p.session.Refresh(); err != nil {
-		return fmt.Errorf("failed to refresh page: %s", err)
+		return fmt.Errorf("failed to refresh page: %w", err)
 	}
 	return nil
 }
@@ -343,7 +441,7 @@ func (p *Page) Refresh() error {
 // This method is not supported by PhantomJS. Please use SwitchToRootFrame instead.
 func (p *Page) SwitchToParentFrame() error {
 	if err := p.session.FrameParent(); err != nil {
-		return fmt.Errorf("failed to switch to parent frame: %s", err)
+		return fmt.Errorf("failed to switch to parent frame: %w", err)
 	}
 	return nil
 }
@@ -354,7 +452,7 @@ func (p *Page) SwitchToParentFrame() error {
 // as well.
 func (p *Page) SwitchToRootFrame() error {
 	if err := p.session.Frame(nil); err != nil {
-		return fmt.Errorf("failed to switch to original page frame: %s", err)
+		return fmt.Errorf("failed to switch to original page frame: %w", err)
 	}
 	return nil
 }
@@ -363,7 +461,7 @@ func (p *Page) SwitchToRootFrame() error {
 // (JavaScript `window.name` attribute).
 func (p *Page) SwitchToWindow(name string) error {
 	if err := p.session.SetWindowByName(name); err != nil {
-		return fmt.Errorf("failed to switch to named window: %s", err)
+		return fmt.Errorf("failed to switch to named window: %w", err)
 	}
 	return nil
 }
@@ -372,7 +470,7 @@ func (p *Page) SwitchToWindow(name string) error {
 func (p *Page) NextWindow() error {
 	windows, err := p.session.GetWindows()
 	if err != nil {
-		return fmt.Errorf("failed to find available windows: %s", err)
+		return fmt.Errorf("failed to find available windows: %w", err)
 	}
 
 	var windowIDs []string
@@ -385,7 +483,7 @@ func (p *Page) NextWindow() error {
 
 	activeWindow, err := p.session.GetWindow()
 	if err != nil {
-		return fmt.Errorf("failed to find active window: %s", err)
+		return fmt.Errorf("failed to find active window: %w", err)
 	}
 
 	for position, windowID := range windowIDs {
@@ -396,7 +494,7 @@ func (p *Page) NextWindow() error {
 	}
 
 	if err := p.session.SetWindow(activeWindow); err != nil {
-		return fmt.Errorf("failed to change active window: %s", err)
+		return fmt.Errorf("failed to change active window: %w", err)
 	}
 
 	return nil
@@ -405,7 +503,7 @@ func (p *Page) NextWindow() error {
 // CloseWindow closes the active window.
 func (p *Page) CloseWindow() error {
 	if err := p.session.DeleteWindow(); err != nil {
-		return fmt.Errorf("failed to close active window: %s", err)
+		return fmt.Errorf("failed to close active window: %w", err)
 	}
 	return nil
 }
@@ -414,7 +512,7 @@ func (p *Page) CloseWindow() error {
 func (p *Page) WindowCount() (int, error) {
 	windows, err := p.session.GetWindows()
 	if err != nil {
-		return 0, fmt.Errorf("failed to find available windows: %s", err)
+		return 0, fmt.Errorf("failed to find available windows: %w", err)
 	}
 	return len(windows), nil
 }
@@ -423,7 +521,7 @@ func (p *Page) WindowCount() (int, error) {
 func (p *Page) LogTypes() ([]string, error) {
 	types, err := p.session.GetLogTypes()
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve log types: %s", err)
+		return nil, fmt.Errorf("failed to retrieve log types: %w", err)
 	}
 	return types, nil
 }
@@ -433,15 +531,75 @@ func (p *Page) LogTypes() ([]string, error) {
 // logs and errors. Only logs since the last call to ReadNewLogs are returned.
 // Valid log types may be obtained using the LogTypes method.
 func (p *Page) ReadNewLogs(logType string) ([]Log, error) {
-	if p.logs == nil {
-		p.logs = map[string][]Log{}
+	p.state.mutex.Lock()
+	defer p.state.mutex.Unlock()
+	logs, err := p.readNewLogs(logType)
+	if err != nil {
+		return nil, err
+	}
+	logs = append(p.state.unread[logType], logs...)
+	delete(p.state.unread, logType)
+	return logs, nil
+}
+
+// pollLogs is like ReadNewLogs, but the logs are still returned by the next
+// call to ReadNewLogs.
+func (p *Page) pollLogs(logType string) ([]Log, error) {
+	p.state.mutex.Lock()
+	defer p.state.mutex.Unlock()
+	logs, err := p.readNewLogs(logType)
+	if err != nil {
+		return nil, err
+	}
+	if p.state.unread == nil {
+		p.state.unread = map[string][]Log{}
+	}
+	p.state.unread[logType] = p.limitLogs(append(p.state.unread[logType], logs...))
+	return logs, nil
+}
+
+// readNewLogs retrieves new logs from the WebDriver and stores them for
+// ReadAllLogs.
+func (p *Page) readNewLogs(logType string) ([]Log, error) {
+	if p.state.logs == nil {
+		p.state.logs = map[string][]Log{}
 	}
 
 	clientLogs, err := p.session.NewLogs(logType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve logs: %s", err)
+		return nil, fmt.Errorf("failed to retrieve logs: %w", err)
 	}
 
+	logs := parseLogs(clientLogs)
+	p.state.logs[logType] = p.limitLogs(append(p.state.logs[logType], logs...))
+	return logs, nil
+}
+
+// limitLogs discards the oldest of the provided logs if there are more than
+// the LogBufferSize Option allows.
+func (p *Page) limitLogs(logs []Log) []Log {
+	if limit := p.logging.storedLogs(); limit > 0 && len(logs) > limit {
+		return append([]Log(nil), logs[len(logs)-limit:]...)
+	}
+	return logs
+}
+
+// ReadAllLogs returns all log messages of the provided log type. For example,
+// page.ReadAllLogs("browser") returns browser console logs, such as JavaScript logs
+// and errors. All logs since the session was created are returned, unless the
+// LogBufferSize Option was provided, in which case only the most recent logs
+// are retained. Valid log types may be obtained using the LogTypes method.
+func (p *Page) ReadAllLogs(logType string) ([]Log, error) {
+	p.state.mutex.Lock()
+	defer p.state.mutex.Unlock()
+	if _, err := p.readNewLogs(logType); err != nil {
+		return nil, err
+	}
+
+	return append([]Log(nil), p.state.logs[logType]...), nil
+}
+
+func parseLogs(clientLogs []api.Log) []Log {
 	messageMatcher := regexp.MustCompile(`^(?s:(.+))\s\(([^)]*:\w*)\)$`)
 
 	var logs []Log
@@ -455,21 +613,7 @@ func (p *Page) ReadNewLogs(logType string) ([]Log, error) {
 		log := Log{message, location, clientLog.Level, msToTime(clientLog.Timestamp)}
 		logs = append(logs, log)
 	}
-	p.logs[logType] = append(p.logs[logType], logs...)
-
-	return logs, nil
-}
-
-// ReadAllLogs returns all log messages of the provided log type. For example,
-// page.ReadAllLogs("browser") returns browser console logs, such as JavaScript logs
-// and errors. All logs since the session was created are returned.
-// Valid log types may be obtained using the LogTypes method.
-func (p *Page) ReadAllLogs(logType string) ([]Log, error) {
-	if _, err := p.ReadNewLogs(logType); err != nil {
-		return nil, err
-	}
-
-	return append([]Log(nil), p.logs[logType]...), nil
+	return logs
 }
 
 func msToTime(ms int64) time.Time {
@@ -481,7 +625,7 @@ func msToTime(ms int64) time.Time {
 // MoveMouseBy moves the mouse by the provided offset.
 func (p *Page) MoveMouseBy(xOffset, yOffset int) error {
 	if err := p.session.MoveTo(nil, api.XYOffset{X: xOffset, Y: yOffset}); err != nil {
-		return fmt.Errorf("failed to move mouse: %s", err)
+		return fmt.Errorf("failed to move mouse: %w", err)
 	}
 
 	return nil
@@ -491,7 +635,7 @@ func (p *Page) MoveMouseBy(xOffset, yOffset int) error {
 // position.
 func (p *Page) DoubleClick() error {
 	if err := p.session.DoubleClick(); err != nil {
-		return fmt.Errorf("failed to double click: %s", err)
+		return fmt.Errorf("failed to double click: %w", err)
 	}
 
 	return nil
@@ -512,7 +656,7 @@ func (p *Page) Click(event Click, button Button) error {
 		err = errors.New("invalid touch event")
 	}
 	if err != nil {
-		return fmt.Errorf("failed to %s %s: %s", event, button, err)
+		return fmt.Errorf("failed to %s %s: %w", event, button, err)
 	}
 
 	return nil
@@ -1,9 +1,11 @@
 package agouti_test
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -11,9 +13,11 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/sclevine/agouti"
+	"github.com/sclevine/agouti/agoutitest"
 	"github.com/sclevine/agouti/api"
 	. "github.com/sclevine/agouti/internal/matchers"
 	"github.com/sclevine/agouti/internal/mocks"
+	"github.com/sclevine/agouti/proxy"
 )
 
 var _ = Describe("Page", func() {
@@ -33,6 +37,166 @@ var _ = Describe("Page", func() {
 		})
 	})
 
+	Describe("#Route", func() {
+		It("should register the route with the proxy of the page", func() {
+			networkProxy := proxy.New()
+			page = NewTestPageWithProxy(session, networkProxy)
+			Expect(page.Proxy()).To(ExactlyEqual(networkProxy))
+			Expect(page.Route("GET", "/api", proxy.Fulfill(200, "text/plain", "some body"))).To(Succeed())
+
+			Expect(networkProxy.Start()).To(Succeed())
+			defer networkProxy.Stop()
+			proxyURL, _ := url.Parse("http://" + networkProxy.Address())
+			client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+			response, err := client.Get("http://example.com/api")
+			Expect(err).NotTo(HaveOccurred())
+			defer response.Body.Close()
+			Expect(ioutil.ReadAll(response.Body)).To(Equal([]byte("some body")))
+		})
+
+		It("should remove the routes and traffic of the proxy when the page is reset", func() {
+			networkProxy := proxy.New()
+			Expect(networkProxy.Start()).To(Succeed())
+			defer networkProxy.Stop()
+			proxyURL, _ := url.Parse("http://" + networkProxy.Address())
+			client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+			page = NewTestPageWithProxy(session, networkProxy)
+			Expect(page.WithContext(context.Background()).Route("GET", "/api", proxy.Fulfill(200, "text/plain", "some body"))).To(Succeed())
+			response, err := client.Get("http://example.com/api")
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+			Expect(networkProxy.Traffic()).To(HaveLen(1))
+
+			session.GetURLCall.ReturnURL = "about:blank"
+			Expect(page.Reset()).To(Succeed())
+			Expect(networkProxy.Traffic()).To(BeEmpty())
+			networkProxy.Route("", "/*", proxy.Fulfill(200, "text/plain", "other body"))
+			response, err = client.Get("http://example.com/api")
+			Expect(err).NotTo(HaveOccurred())
+			defer response.Body.Close()
+			Expect(ioutil.ReadAll(response.Body)).To(Equal([]byte("other body")))
+		})
+
+		It("should stop the proxy when the page is destroyed", func() {
+			networkProxy := proxy.New()
+			Expect(networkProxy.Start()).To(Succeed())
+			page = NewTestPageWithProxy(session, networkProxy)
+			Expect(page.Destroy()).To(Succeed())
+			Expect(networkProxy.Address()).To(BeEmpty())
+		})
+
+		Context("when network interception is not enabled", func() {
+			It("should return an error", func() {
+				Expect(page.Proxy()).To(BeNil())
+				err := page.Route("GET", "/api", proxy.Fail())
+				Expect(err).To(MatchError("failed to route requests: network interception is not enabled, see InterceptNetwork"))
+			})
+		})
+	})
+
+	Describe("#StartHAR", func() {
+		Context("when network interception is not enabled", func() {
+			It("should return an error", func() {
+				err := page.StartHAR()
+				Expect(err).To(MatchError("failed to start HAR: network interception is not enabled, see InterceptNetwork"))
+			})
+		})
+	})
+
+	Describe("#StopHAR", func() {
+		var (
+			networkProxy *proxy.Proxy
+			client       *http.Client
+		)
+
+		BeforeEach(func() {
+			networkProxy = proxy.New()
+			Expect(networkProxy.Start()).To(Succeed())
+			networkProxy.Route("", "/*", proxy.Fulfill(200, "text/plain", "some body"))
+			proxyURL, _ := url.Parse("http://" + networkProxy.Address())
+			client = &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+			page = NewTestPageWithProxy(session, networkProxy)
+		})
+
+		AfterEach(func() {
+			networkProxy.Stop()
+		})
+
+		get := func(url string) {
+			response, err := client.Get(url)
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+		}
+
+		It("should return a HAR of the requests completed since StartHAR was called", func() {
+			get("http://example.com/before")
+			Expect(page.StartHAR()).To(Succeed())
+			get("http://example.com/during")
+			har, err := page.StopHAR()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(har.Log.Entries).To(HaveLen(1))
+			Expect(har.Log.Entries[0].Request.URL).To(Equal("http://example.com/during"))
+			Expect(har.Log.Entries[0].Response.Content.Text).To(Equal("some body"))
+		})
+
+		It("should share HAR recording with copies of the page made by WithContext", func() {
+			Expect(page.StartHAR()).To(Succeed())
+			get("http://example.com/during")
+			har, err := page.WithContext(context.Background()).StopHAR()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(har.Log.Entries).To(HaveLen(1))
+			_, err = page.StopHAR()
+			Expect(err).To(MatchError("failed to stop HAR: HAR recording was not started"))
+		})
+
+		It("should not include requests made through the proxies of other pages", func() {
+			otherProxy := proxy.New()
+			Expect(otherProxy.Start()).To(Succeed())
+			defer otherProxy.Stop()
+			otherProxyURL, _ := url.Parse("http://" + otherProxy.Address())
+			otherClient := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(otherProxyURL)}}
+
+			Expect(page.StartHAR()).To(Succeed())
+			get("http://example.com/during")
+			response, err := otherClient.Get("http://example.com/other")
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+			har, err := page.StopHAR()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(har.Log.Entries).To(HaveLen(1))
+			Expect(har.Log.Entries[0].Request.URL).To(Equal("http://example.com/during"))
+		})
+
+		Context("when the page is reset while recording", func() {
+			It("should return a HAR of the requests completed since the page was reset", func() {
+				get("http://example.com/before")
+				Expect(page.StartHAR()).To(Succeed())
+				get("http://example.com/during")
+				session.GetURLCall.ReturnURL = "about:blank"
+				Expect(page.Reset()).To(Succeed())
+				networkProxy.Route("", "/*", proxy.Fulfill(200, "text/plain", "some body"))
+				get("http://example.com/after")
+				har, err := page.StopHAR()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(har.Log.Entries).To(HaveLen(1))
+				Expect(har.Log.Entries[0].Request.URL).To(Equal("http://example.com/after"))
+			})
+		})
+
+		Context("when HAR recording was not started", func() {
+			It("should return an error", func() {
+				_, err := page.StopHAR()
+				Expect(err).To(MatchError("failed to stop HAR: HAR recording was not started"))
+				Expect(page.StartHAR()).To(Succeed())
+				_, err = page.StopHAR()
+				Expect(err).NotTo(HaveOccurred())
+				_, err = page.StopHAR()
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
 	Describe("#Session", func() {
 		It("should return the unexported session as a *api.Session", func() {
 			apiSession := &api.Session{}
@@ -41,6 +205,75 @@ var _ = Describe("Page", func() {
 		})
 	})
 
+	Describe("#WithContext", func() {
+		var bus *mocks.Bus
+
+		BeforeEach(func() {
+			bus = &mocks.Bus{}
+			page = NewTestPage(&api.Session{Bus: bus})
+		})
+
+		It("should return a page that sends requests using the context", func() {
+			bus.SendCall.Result = `"some title"`
+			Expect(page.WithContext(context.Background()).Title()).To(Equal("some title"))
+			Expect(bus.SendCall.Endpoint).To(Equal("title"))
+		})
+
+		It("should return a page with selections that share the context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := page.WithContext(ctx).Find("#selector").Click()
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(bus.SendCall.Endpoint).To(BeEmpty())
+		})
+
+		It("should not modify the original page", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			page.WithContext(ctx)
+			bus.SendCall.Result = `"some title"`
+			Expect(page.Title()).To(Equal("some title"))
+		})
+	})
+
+	Describe("with the Observe Option", func() {
+		var (
+			server  *agoutitest.Server
+			summary *api.CommandSummary
+		)
+
+		BeforeEach(func() {
+			server = agoutitest.NewServer()
+			server.Page("http://example.com/", "<title>Example</title>")
+			summary = api.NewCommandSummary()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should notify the observer of each command sent by the page and its selections", func() {
+			var err error
+			page, err = NewPage(server.URL(), Observe(summary))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page.Navigate("http://example.com/")).To(Succeed())
+			Expect(page.Find("title").Count()).To(Equal(1))
+			Expect(summary.Count()).To(Equal(2))
+			Expect(summary.Slowest(2)).To(ConsistOf(
+				WithTransform(func(command api.Command) string { return command.Endpoint }, Equal("url")),
+				WithTransform(func(command api.Command) string { return command.Endpoint }, Equal("elements")),
+			))
+		})
+
+		It("should notify the observer for joined pages", func() {
+			_, err := api.Open(server.URL(), nil)
+			Expect(err).NotTo(HaveOccurred())
+			page = JoinPage(server.URL()+"/session/"+server.Sessions()[0].ID, Observe(summary))
+			Expect(page.Title()).To(Equal(""))
+			Expect(summary.Count()).To(Equal(1))
+		})
+	})
+
 	Describe("#Destroy", func() {
 		It("should successfully delete the session", func() {
 			Expect(page.Destroy()).To(Succeed())
@@ -704,6 +937,18 @@ var _ = Describe("Page", func() {
 			Expect(logs[2].Message).To(Equal("newer log"))
 		})
 
+		It("should return logs read by copies of the page made by WithContext", func() {
+			session.NewLogsCall.ReturnLogs = []api.Log{
+				{Message: "some log", Level: "some level", Timestamp: 1418196096123},
+			}
+			page.WithContext(context.Background()).ReadNewLogs("some type")
+			session.NewLogsCall.ReturnLogs = nil
+			logs, err := page.ReadAllLogs("some type")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logs).To(HaveLen(1))
+			Expect(logs[0].Message).To(Equal("some log"))
+		})
+
 		It("should return a copy of the stored logs", func() {
 			session.NewLogsCall.ReturnLogs = []api.Log{
 				{Message: "some log", Level: "some level", Timestamp: 1418196096123},
@@ -0,0 +1,212 @@
+package agouti
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// A PagePool is a fixed-size pool of pages that may be shared by tests that
+// run in parallel (ex. with "go test -parallel"). Pages are reset between
+// uses, and pages with crashed sessions are replaced. A PagePool is safe for
+// concurrent use.
+//
+// Ginkgo runs parallel nodes in separate processes, so each node should
+// create its own pool. For example:
+//    var pool *agouti.PagePool
+//
+//    var _ = BeforeSuite(func() {
+//        ...
+//        pool, err = driver.NewPagePool(2)
+//    })
+//
+//    var _ = BeforeEach(func() {
+//        page, err = pool.Acquire()
+//        ...
+//    })
+//
+//    var _ = AfterEach(func() {
+//        pool.Release(page)
+//    })
+type PagePool struct {
+	open  func() (*Page, error)
+	slots chan struct{}
+
+	mutex    sync.Mutex
+	idle     []*Page
+	acquired map[*Page]bool
+	closed   bool
+}
+
+// NewPagePool concurrently opens the provided number of pages, which are
+// configured by the provided Options as they would be by NewPage.
+func (w *WebDriver) NewPagePool(size int, options ...Option) (*PagePool, error) {
+	return newPagePool(size, func() (*Page, error) {
+		return w.NewPage(options...)
+	})
+}
+
+func newPagePool(size int, open func() (*Page, error)) (*PagePool, error) {
+	if size < 1 {
+		return nil, errors.New("failed to create page pool: size must be at least 1")
+	}
+
+	pool := &PagePool{
+		open:     open,
+		slots:    make(chan struct{}, size),
+		acquired: map[*Page]bool{},
+	}
+
+	type result struct {
+		page *Page
+		err  error
+	}
+	results := make(chan result, size)
+	for i := 0; i < size; i++ {
+		go func() {
+			page, err := open()
+			results <- result{page, err}
+		}()
+	}
+
+	var err error
+	for i := 0; i < size; i++ {
+		result := <-results
+		if result.err != nil {
+			if err == nil {
+				err = result.err
+			}
+			continue
+		}
+		pool.idle = append(pool.idle, result.page)
+		pool.slots <- struct{}{}
+	}
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to create page pool: %w", err)
+	}
+	return pool, nil
+}
+
+// Size returns the number of pages in the pool.
+func (p *PagePool) Size() int {
+	return cap(p.slots)
+}
+
+// Acquire returns a page from the pool, waiting until a page is released if
+// all pages are in use. The page must be returned to the pool using Release.
+func (p *PagePool) Acquire() (*Page, error) {
+	return p.AcquireContext(context.Background())
+}
+
+// AcquireContext is like Acquire, but stops waiting for a page when the
+// provided context is canceled or exceeds its deadline.
+func (p *PagePool) AcquireContext(ctx context.Context) (*Page, error) {
+	select {
+	case <-p.slots:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to acquire page: %w", ctx.Err())
+	}
+
+	page, err := p.checkout()
+	if err != nil {
+		p.slots <- struct{}{}
+		return nil, fmt.Errorf("failed to acquire page: %w", err)
+	}
+	return page, nil
+}
+
+func (p *PagePool) checkout() (*Page, error) {
+	for {
+		p.mutex.Lock()
+		if p.closed {
+			p.mutex.Unlock()
+			return nil, errors.New("pool is closed")
+		}
+		var page *Page
+		if len(p.idle) > 0 {
+			page, p.idle = p.idle[len(p.idle)-1], p.idle[:len(p.idle)-1]
+		}
+		p.mutex.Unlock()
+
+		if page == nil {
+			newPage, err := p.open()
+			if err != nil {
+				return nil, err
+			}
+			page = newPage
+		} else if _, err := page.URL(); err != nil {
+			// the session crashed while the page was idle
+			page.Destroy()
+			continue
+		}
+
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		if p.closed {
+			page.Destroy()
+			return nil, errors.New("pool is closed")
+		}
+		p.acquired[page] = true
+		return page, nil
+	}
+}
+
+// Release resets the provided page and returns it to the pool. If the page
+// cannot be reset, its session is replaced by a new session. If the new
+// session cannot be created either, an error is returned and a new session is
+// created by the next call to Acquire. The page must not be used after it is
+// released.
+func (p *PagePool) Release(page *Page) error {
+	p.mutex.Lock()
+	if !p.acquired[page] {
+		p.mutex.Unlock()
+		return errors.New("failed to release page: page was not acquired from this pool")
+	}
+	delete(p.acquired, page)
+	closed := p.closed
+	p.mutex.Unlock()
+
+	defer func() { p.slots <- struct{}{} }()
+
+	if closed {
+		return page.Destroy()
+	}
+
+	if resetErr := page.Reset(); resetErr != nil {
+		page.Destroy()
+		replacement, err := p.open()
+		if err != nil {
+			// a new page is opened by the next Acquire
+			return fmt.Errorf("failed to release page: failed to reset page (%s) and failed to open replacement page: %w", resetErr, err)
+		}
+		page = replacement
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return page.Destroy()
+	}
+	p.idle = append(p.idle, page)
+	return nil
+}
+
+// Close destroys the pages in the pool. Pages that are in use are destroyed
+// when they are released.
+func (p *PagePool) Close() error {
+	p.mutex.Lock()
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mutex.Unlock()
+
+	var err error
+	for _, page := range idle {
+		if destroyErr := page.Destroy(); destroyErr != nil && err == nil {
+			err = fmt.Errorf("failed to close page pool: %w", destroyErr)
+		}
+	}
+	return err
+}
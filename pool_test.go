@@ -0,0 +1,217 @@
+package agouti_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti"
+	"github.com/sclevine/agouti/agoutitest"
+)
+
+var _ = Describe("PagePool", func() {
+	var (
+		server *agoutitest.Server
+		opened int32
+		open   func() (*Page, error)
+		pool   *PagePool
+	)
+
+	BeforeEach(func() {
+		server = agoutitest.NewServer()
+		server.Page("/some/page", "<h1>some page</h1>")
+		opened = 0
+		open = func() (*Page, error) {
+			atomic.AddInt32(&opened, 1)
+			return NewPage(server.URL())
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("NewPagePool", func() {
+		It("should open the provided number of pages", func() {
+			var err error
+			pool, err = NewTestPagePool(3, open)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pool.Size()).To(Equal(3))
+			Expect(atomic.LoadInt32(&opened)).To(BeEquivalentTo(3))
+			Expect(server.Sessions()).To(HaveLen(3))
+		})
+
+		Context("when a page cannot be opened", func() {
+			It("should destroy the opened pages and return an error", func() {
+				_, err := NewTestPagePool(3, func() (*Page, error) {
+					if atomic.AddInt32(&opened, 1) == 2 {
+						return nil, errors.New("some error")
+					}
+					return NewPage(server.URL())
+				})
+				Expect(err).To(MatchError("failed to create page pool: some error"))
+				Expect(server.Sessions()).To(BeEmpty())
+			})
+		})
+
+		Context("when the size is invalid", func() {
+			It("should return an error", func() {
+				_, err := NewTestPagePool(0, open)
+				Expect(err).To(MatchError("failed to create page pool: size must be at least 1"))
+			})
+		})
+	})
+
+	Describe("#Acquire and #Release", func() {
+		BeforeEach(func() {
+			var err error
+			pool, err = NewTestPagePool(2, open)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			pool.Close()
+		})
+
+		It("should reuse pages after resetting them", func() {
+			page, err := pool.Acquire()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page.Navigate(server.URL() + "/some/page")).To(Succeed())
+			Expect(pool.Release(page)).To(Succeed())
+
+			reused, err := pool.Acquire()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reused).To(BeIdenticalTo(page))
+			Expect(reused.URL()).To(Equal("about:blank"))
+			Expect(atomic.LoadInt32(&opened)).To(BeEquivalentTo(2))
+		})
+
+		It("should wait for a page to be released when all pages are in use", func() {
+			first, _ := pool.Acquire()
+			_, err := pool.Acquire()
+			Expect(err).NotTo(HaveOccurred())
+
+			acquired := make(chan *Page)
+			go func() {
+				defer GinkgoRecover()
+				page, err := pool.Acquire()
+				Expect(err).NotTo(HaveOccurred())
+				acquired <- page
+			}()
+			Consistently(acquired, "50ms").ShouldNot(Receive())
+			Expect(pool.Release(first)).To(Succeed())
+			Eventually(acquired).Should(Receive(BeIdenticalTo(first)))
+		})
+
+		It("should support concurrent use", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					page, err := pool.Acquire()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(page.Navigate(server.URL() + "/some/page")).To(Succeed())
+					Expect(pool.Release(page)).To(Succeed())
+				}()
+			}
+			wg.Wait()
+			Expect(atomic.LoadInt32(&opened)).To(BeEquivalentTo(2))
+		})
+
+		It("should replace pages with sessions that crashed while in use", func() {
+			page, _ := pool.Acquire()
+			Expect(page.Session().Delete()).To(Succeed())
+			Expect(pool.Release(page)).To(Succeed())
+			Expect(atomic.LoadInt32(&opened)).To(BeEquivalentTo(3))
+
+			for i := 0; i < 2; i++ {
+				replacement, err := pool.Acquire()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(replacement).NotTo(BeIdenticalTo(page))
+			}
+		})
+
+		It("should replace pages with sessions that crashed while idle", func() {
+			page, _ := pool.Acquire()
+			Expect(pool.Release(page)).To(Succeed())
+			Expect(page.Session().Delete()).To(Succeed())
+
+			replacement, err := pool.Acquire()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(replacement).NotTo(BeIdenticalTo(page))
+			Expect(replacement.URL()).To(Equal("about:blank"))
+		})
+
+		Context("when a crashed page cannot be replaced", func() {
+			It("should return an error and open a new page on the next acquire", func() {
+				var failing int32
+				pool, err := NewTestPagePool(1, func() (*Page, error) {
+					if atomic.LoadInt32(&failing) == 1 {
+						return nil, errors.New("some error")
+					}
+					return NewPage(server.URL())
+				})
+				Expect(err).NotTo(HaveOccurred())
+				defer pool.Close()
+
+				page, _ := pool.Acquire()
+				Expect(page.Session().Delete()).To(Succeed())
+				atomic.StoreInt32(&failing, 1)
+				err = pool.Release(page)
+				Expect(err).To(MatchError(HavePrefix("failed to release page: failed to reset page (")))
+				Expect(err).To(MatchError(HaveSuffix(") and failed to open replacement page: some error")))
+				Expect(errors.Unwrap(err)).To(MatchError("some error"))
+
+				atomic.StoreInt32(&failing, 0)
+				replacement, err := pool.Acquire()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(replacement).NotTo(BeIdenticalTo(page))
+			})
+		})
+
+		Context("when the context is canceled while waiting for a page", func() {
+			It("should return an error", func() {
+				pool.Acquire()
+				pool.Acquire()
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				defer cancel()
+				_, err := pool.AcquireContext(ctx)
+				Expect(err).To(MatchError("failed to acquire page: context deadline exceeded"))
+			})
+		})
+
+		Context("when the page was not acquired from the pool", func() {
+			It("should return an error", func() {
+				page, _ := pool.Acquire()
+				Expect(pool.Release(page)).To(Succeed())
+				err := pool.Release(page)
+				Expect(err).To(MatchError("failed to release page: page was not acquired from this pool"))
+			})
+		})
+	})
+
+	Describe("#Close", func() {
+		BeforeEach(func() {
+			var err error
+			pool, err = NewTestPagePool(2, open)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should destroy idle pages, and pages in use when they are released", func() {
+			page, _ := pool.Acquire()
+			Expect(pool.Close()).To(Succeed())
+			Expect(server.Sessions()).To(HaveLen(1))
+			Expect(pool.Release(page)).To(Succeed())
+			Expect(server.Sessions()).To(BeEmpty())
+
+			_, err := pool.Acquire()
+			Expect(err).To(MatchError("failed to acquire page: pool is closed"))
+		})
+	})
+})
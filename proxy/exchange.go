@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// An Exchange is a request recorded by a Proxy and the response to it.
+type Exchange struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+
+	// StatusCode is the status code of the response, or zero if the proxy
+	// closed the connection without responding (see Fail).
+	StatusCode     int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+
+	// Intercepted is true if the request matched a route.
+	Intercepted bool
+
+	Start    time.Time
+	Duration time.Duration
+
+	proto         string
+	responseProto string
+	headersAt     time.Time
+}
+
+// JSON decodes the request body into the provided value.
+func (e *Exchange) JSON(value interface{}) error {
+	if err := json.Unmarshal(e.Body, value); err != nil {
+		return fmt.Errorf("failed to decode request body: %w", err)
+	}
+	return nil
+}
+
+func (e *Exchange) String() string {
+	return fmt.Sprintf("%s %s (%d)", e.Method, e.URL, e.StatusCode)
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	header    http.Header
+	status    int
+	headersAt time.Time
+	body      bytes.Buffer
+	hijacked  bool
+
+	// proto is the protocol of the upstream response, if the request was
+	// passed through
+	proto string
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status != 0 {
+		return
+	}
+	r.status = status
+	r.headersAt = time.Now()
+	for key, values := range r.ResponseWriter.Header() {
+		r.header[key] = append([]string(nil), values...)
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("hijacking is not supported")
+	}
+	r.hijacked = true
+	return hijacker.Hijack()
+}
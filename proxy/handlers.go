@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// A Handler responds to an intercepted request. The passThrough handler sends
+// the request to its destination and writes the response, so that handlers
+// may modify or delay real responses.
+type Handler func(w http.ResponseWriter, request *http.Request, passThrough http.Handler)
+
+// Fulfill returns a Handler that responds with the provided status code,
+// content type, and body.
+func Fulfill(status int, contentType, body string) Handler {
+	return func(w http.ResponseWriter, _ *http.Request, _ http.Handler) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
+
+// JSON returns a Handler that responds with the provided status code and the
+// provided value encoded as JSON.
+func JSON(status int, value interface{}) Handler {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return Fulfill(http.StatusInternalServerError, "text/plain", "agouti proxy: failed to encode JSON: "+err.Error())
+	}
+	return Fulfill(status, "application/json", string(body))
+}
+
+// Delay returns a Handler that waits for the provided duration before calling
+// the provided handler. If the provided handler is nil, the request is passed
+// through to its destination.
+func Delay(duration time.Duration, handler Handler) Handler {
+	if handler == nil {
+		handler = PassThrough()
+	}
+	return func(w http.ResponseWriter, request *http.Request, passThrough http.Handler) {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			handler(w, request, passThrough)
+		case <-request.Context().Done():
+		}
+	}
+}
+
+// Fail returns a Handler that closes the connection without responding, so
+// that the browser reports a network error.
+func Fail() Handler {
+	return func(w http.ResponseWriter, _ *http.Request, _ http.Handler) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			panic(http.ErrAbortHandler)
+		}
+		connection, _, err := hijacker.Hijack()
+		if err != nil {
+			panic(http.ErrAbortHandler)
+		}
+		connection.Close()
+	}
+}
+
+// PassThrough returns a Handler that sends the request to its destination.
+// It may be used to exclude requests from a route registered earlier.
+func PassThrough() Handler {
+	return func(w http.ResponseWriter, request *http.Request, passThrough http.Handler) {
+		passThrough.ServeHTTP(w, request)
+	}
+}
@@ -0,0 +1,241 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// HARVersion is the version of the HAR format produced by NewHAR.
+const HARVersion = "1.2"
+
+// A HAR is an HTTP Archive, see http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings are in milliseconds. Timings that do not apply are -1.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NewHAR returns a HAR containing the provided exchanges. Tunneled HTTPS
+// requests are omitted, as their contents are not recorded.
+func NewHAR(exchanges []*Exchange) *HAR {
+	har := &HAR{Log: HARLog{
+		Version: HARVersion,
+		Creator: HARCreator{Name: "agouti"},
+		Entries: []HAREntry{},
+	}}
+	for _, exchange := range exchanges {
+		if exchange.Method == http.MethodConnect {
+			continue
+		}
+		har.Log.Entries = append(har.Log.Entries, exchange.harEntry())
+	}
+	sort.SliceStable(har.Log.Entries, func(i, j int) bool {
+		return har.Log.Entries[i].StartedDateTime.Before(har.Log.Entries[j].StartedDateTime)
+	})
+	return har
+}
+
+// Write writes the HAR as JSON to the provided writer.
+func (h *HAR) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(h); err != nil {
+		return fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	return nil
+}
+
+// WriteFile writes the HAR as JSON to the provided filename.
+// The provided filename may be an absolute or relative path.
+func (h *HAR) WriteFile(filename string) error {
+	absFilePath, err := filepath.Abs(filename)
+	if err != nil {
+		return fmt.Errorf("failed to find absolute path for filename: %w", err)
+	}
+	contents, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	if err := ioutil.WriteFile(absFilePath, contents, 0666); err != nil {
+		return fmt.Errorf("failed to save HAR: %w", err)
+	}
+	return nil
+}
+
+func (e *Exchange) harEntry() HAREntry {
+	wait, receive := e.Duration, time.Duration(0)
+	if !e.headersAt.IsZero() {
+		wait = e.headersAt.Sub(e.Start)
+		receive = e.Duration - wait
+	}
+
+	entry := HAREntry{
+		StartedDateTime: e.Start,
+		Time:            milliseconds(e.Duration),
+		Request: HARRequest{
+			Method:      e.Method,
+			URL:         e.URL.String(),
+			HTTPVersion: e.proto,
+			Cookies:     harCookies((&http.Request{Header: e.Header}).Cookies()),
+			Headers:     harHeaders(e.Header),
+			QueryString: []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(e.Body),
+		},
+		Response: HARResponse{
+			Status:      e.StatusCode,
+			StatusText:  http.StatusText(e.StatusCode),
+			HTTPVersion: e.responseProto,
+			Cookies:     harCookies((&http.Response{Header: e.ResponseHeader}).Cookies()),
+			Headers:     harHeaders(e.ResponseHeader),
+			Content:     harContent(e.ResponseHeader.Get("Content-Type"), e.ResponseBody),
+			RedirectURL: e.ResponseHeader.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(e.ResponseBody),
+		},
+		Timings: HARTimings{
+			Blocked: -1,
+			DNS:     -1,
+			Connect: -1,
+			Send:    0,
+			Wait:    milliseconds(wait),
+			Receive: milliseconds(receive),
+		},
+	}
+
+	for name, values := range e.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, HARNameValue{name, value})
+		}
+	}
+	sortNameValues(entry.Request.QueryString)
+
+	if len(e.Body) > 0 {
+		entry.Request.PostData = &HARPostData{
+			MimeType: e.Header.Get("Content-Type"),
+			Text:     string(e.Body),
+		}
+	}
+	if e.StatusCode == 0 {
+		entry.Comment = "connection closed without a response"
+	}
+	return entry
+}
+
+func harHeaders(header http.Header) []HARNameValue {
+	headers := []HARNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			headers = append(headers, HARNameValue{name, value})
+		}
+	}
+	sortNameValues(headers)
+	return headers
+}
+
+func harCookies(cookies []*http.Cookie) []HARNameValue {
+	harCookies := []HARNameValue{}
+	for _, cookie := range cookies {
+		harCookies = append(harCookies, HARNameValue{cookie.Name, cookie.Value})
+	}
+	return harCookies
+}
+
+func harContent(mimeType string, body []byte) HARContent {
+	content := HARContent{Size: len(body), MimeType: mimeType}
+	if utf8.Valid(body) {
+		content.Text = string(body)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+	return content
+}
+
+func sortNameValues(values []HARNameValue) {
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Name < values[j].Name
+	})
+}
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
@@ -0,0 +1,164 @@
+package proxy_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/agouti/proxy"
+)
+
+var _ = Describe("HAR", func() {
+	var (
+		networkProxy *proxy.Proxy
+		backend      *httptest.Server
+		client       *http.Client
+	)
+
+	BeforeEach(func() {
+		backend = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "some-session"})
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("some error"))
+		}))
+
+		networkProxy = proxy.New()
+		Expect(networkProxy.Start()).To(Succeed())
+		proxyURL, _ := url.Parse("http://" + networkProxy.Address())
+		client = &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+	})
+
+	AfterEach(func() {
+		networkProxy.Stop()
+		backend.Close()
+	})
+
+	send := func(method, path, body string) {
+		request, err := http.NewRequest(method, backend.URL+path, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		if body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		request.AddCookie(&http.Cookie{Name: "some-cookie", Value: "some-value"})
+		response, err := client.Do(request)
+		Expect(err).NotTo(HaveOccurred())
+		ioutil.ReadAll(response.Body)
+		response.Body.Close()
+	}
+
+	Describe("NewHAR", func() {
+		It("should return a HAR 1.2 archive of the exchanges", func() {
+			networkProxy.Route("GET", "/image", proxy.Fulfill(http.StatusOK, "image/png", "\x89PNG\xff"))
+			send("POST", "/api/orders?some=query&other=query", `{"item": "some-item"}`)
+			send("GET", "/image", "")
+
+			har := proxy.NewHAR(networkProxy.Traffic())
+			Expect(har.Log.Version).To(Equal("1.2"))
+			Expect(har.Log.Creator.Name).To(Equal("agouti"))
+			Expect(har.Log.Entries).To(HaveLen(2))
+
+			entry := har.Log.Entries[0]
+			Expect(entry.StartedDateTime).To(Equal(networkProxy.Traffic()[0].Start))
+			Expect(entry.Time).To(BeNumerically(">", 0))
+			Expect(entry.Request.Method).To(Equal("POST"))
+			Expect(entry.Request.URL).To(Equal(backend.URL + "/api/orders?some=query&other=query"))
+			Expect(entry.Request.HTTPVersion).To(Equal("HTTP/1.1"))
+			Expect(entry.Request.Cookies).To(Equal([]proxy.HARNameValue{{Name: "some-cookie", Value: "some-value"}}))
+			Expect(entry.Request.Headers).To(ContainElement(proxy.HARNameValue{Name: "Content-Type", Value: "application/json"}))
+			Expect(entry.Request.QueryString).To(Equal([]proxy.HARNameValue{
+				{Name: "other", Value: "query"},
+				{Name: "some", Value: "query"},
+			}))
+			Expect(entry.Request.PostData).To(Equal(&proxy.HARPostData{MimeType: "application/json", Text: `{"item": "some-item"}`}))
+			Expect(entry.Request.BodySize).To(Equal(21))
+			Expect(entry.Response.Status).To(Equal(500))
+			Expect(entry.Response.StatusText).To(Equal("Internal Server Error"))
+			Expect(entry.Response.HTTPVersion).To(Equal("HTTP/1.1"))
+			Expect(entry.Response.Cookies).To(Equal([]proxy.HARNameValue{{Name: "session", Value: "some-session"}}))
+			Expect(entry.Response.Content).To(Equal(proxy.HARContent{Size: 10, MimeType: "text/plain", Text: "some error"}))
+			Expect(entry.Timings.Blocked).To(Equal(-1.0))
+			Expect(entry.Timings.Wait + entry.Timings.Receive).To(BeNumerically("~", entry.Time, 0.001))
+
+			image := har.Log.Entries[1].Response.Content
+			Expect(image).To(Equal(proxy.HARContent{Size: 5, MimeType: "image/png", Text: base64.StdEncoding.EncodeToString([]byte("\x89PNG\xff")), Encoding: "base64"}))
+		})
+
+		It("should record the protocol of the upstream response", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+			go func() {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				http.ReadRequest(bufio.NewReader(conn))
+				conn.Write([]byte("HTTP/1.0 200 OK\r\nContent-Length: 9\r\n\r\nsome body"))
+			}()
+
+			response, err := client.Get("http://" + listener.Addr().String() + "/old")
+			Expect(err).NotTo(HaveOccurred())
+			ioutil.ReadAll(response.Body)
+			response.Body.Close()
+
+			har := proxy.NewHAR(networkProxy.Traffic())
+			Expect(har.Log.Entries).To(HaveLen(1))
+			Expect(har.Log.Entries[0].Request.HTTPVersion).To(Equal("HTTP/1.1"))
+			Expect(har.Log.Entries[0].Response.HTTPVersion).To(Equal("HTTP/1.0"))
+		})
+
+		It("should omit tunneled requests", func() {
+			connect, _ := url.Parse("//example.com:443")
+			har := proxy.NewHAR([]*proxy.Exchange{{Method: "CONNECT", URL: connect}})
+			Expect(har.Log.Entries).To(BeEmpty())
+		})
+	})
+
+	Describe("#Write", func() {
+		It("should write the HAR as JSON", func() {
+			send("GET", "/", "")
+			buffer := &bytes.Buffer{}
+			Expect(proxy.NewHAR(networkProxy.Traffic()).Write(buffer)).To(Succeed())
+
+			var har map[string]map[string]interface{}
+			Expect(json.Unmarshal(buffer.Bytes(), &har)).To(Succeed())
+			Expect(har["log"]["version"]).To(Equal("1.2"))
+			Expect(har["log"]["entries"]).To(HaveLen(1))
+		})
+	})
+
+	Describe("#WriteFile", func() {
+		It("should save the HAR as JSON", func() {
+			directory, err := ioutil.TempDir("", "agouti")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(directory)
+
+			send("GET", "/", "")
+			filename := filepath.Join(directory, "some.har")
+			Expect(proxy.NewHAR(networkProxy.Traffic()).WriteFile(filename)).To(Succeed())
+			contents, err := ioutil.ReadFile(filename)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`"version": "1.2"`))
+		})
+
+		Context("when the file cannot be saved", func() {
+			It("should return an error", func() {
+				err := proxy.NewHAR(nil).WriteFile("")
+				Expect(err.Error()).To(HavePrefix("failed to save HAR: "))
+			})
+		})
+	})
+})
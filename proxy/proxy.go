@@ -0,0 +1,332 @@
+// Package proxy provides a local HTTP proxy that intercepts and records the
+// network traffic of a browser under test.
+//
+// Pages opened by a WebDriver that is created with the agouti.InterceptNetwork
+// Option are automatically configured to use a Proxy. For example:
+//    page.Route("POST", "/api/orders", proxy.JSON(201, map[string]int{"id": 1}))
+//    page.Route("GET", "https://cdn.example.com/*", proxy.Fail())
+//    ...
+//    orders := page.Proxy().Requests("POST", "/api/orders")
+//
+// HTTPS requests are tunneled to their destination without being intercepted,
+// so only plain HTTP requests may be routed or inspected.
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Proxy is an HTTP proxy that responds to requests using registered route
+// handlers, and passes any other requests through to their destination.
+// Every request is recorded.
+type Proxy struct {
+	mutex     sync.RWMutex
+	listener  net.Listener
+	server    *http.Server
+	transport *http.Transport
+	routes    []route
+	traffic   []*Exchange
+}
+
+type route struct {
+	method  string
+	pattern *regexp.Regexp
+	path    bool
+	handler Handler
+}
+
+// New returns a Proxy that is not yet started.
+func New() *Proxy {
+	return &Proxy{transport: &http.Transport{Proxy: nil}}
+}
+
+// Start starts the proxy on a free port on 127.0.0.1.
+func (p *Proxy) Start() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.listener != nil {
+		return errors.New("already started")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	p.listener = listener
+	p.server = &http.Server{Handler: p}
+	go p.server.Serve(listener)
+	return nil
+}
+
+// Stop stops the proxy. Requests in progress are aborted.
+func (p *Proxy) Stop() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.listener == nil {
+		return errors.New("already stopped")
+	}
+
+	err := p.server.Close()
+	p.transport.CloseIdleConnections()
+	p.listener, p.server = nil, nil
+	if err != nil {
+		return fmt.Errorf("failed to stop proxy: %w", err)
+	}
+	return nil
+}
+
+// Address returns the host and port of the proxy (ex. "127.0.0.1:51234"), or
+// an empty string if the proxy is not started.
+func (p *Proxy) Address() string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if p.listener == nil {
+		return ""
+	}
+	return p.listener.Addr().String()
+}
+
+// Route registers a handler for requests with the provided method that match
+// the provided URL pattern. An empty method matches any method.
+//
+// Patterns that start with "/" match the URL path, and other patterns match
+// the full URL (ex. "https://example.com/api/*"). Patterns only match the
+// query string if they contain a "?". A "*" in a pattern matches any text.
+//
+// Routes registered later take precedence over routes registered earlier.
+func (p *Proxy) Route(method, pattern string, handler Handler) {
+	newRoute := route{
+		method:  strings.ToUpper(method),
+		pattern: compilePattern(pattern),
+		path:    strings.HasPrefix(pattern, "/"),
+		handler: handler,
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.routes = append(p.routes, newRoute)
+}
+
+// ClearRoutes removes all registered routes, so that all requests are passed
+// through to their destination.
+func (p *Proxy) ClearRoutes() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.routes = nil
+}
+
+// Traffic returns all completed requests in the order that they completed.
+func (p *Proxy) Traffic() []*Exchange {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return append([]*Exchange(nil), p.traffic...)
+}
+
+// Requests returns the completed requests with the provided method that match
+// the provided URL pattern. See Route for valid patterns.
+func (p *Proxy) Requests(method, pattern string) []*Exchange {
+	filter := route{
+		method:  strings.ToUpper(method),
+		pattern: compilePattern(pattern),
+		path:    strings.HasPrefix(pattern, "/"),
+	}
+
+	var requests []*Exchange
+	for _, exchange := range p.Traffic() {
+		if filter.matches(exchange.Method, exchange.URL.String(), exchange.URL.Path, exchange.URL.RawQuery) {
+			requests = append(requests, exchange)
+		}
+	}
+	return requests
+}
+
+// ClearTraffic discards all recorded requests.
+func (p *Proxy) ClearTraffic() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.traffic = nil
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	if request.Method == http.MethodConnect {
+		p.tunnel(w, request)
+		return
+	}
+
+	if !request.URL.IsAbs() {
+		http.Error(w, "agouti proxy: only proxy requests are supported", http.StatusBadRequest)
+		return
+	}
+
+	exchange := &Exchange{
+		Method: request.Method,
+		URL:    request.URL,
+		Header: request.Header.Clone(),
+		Start:  time.Now(),
+		proto:  request.Proto,
+	}
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		http.Error(w, "agouti proxy: failed to read request", http.StatusBadRequest)
+		return
+	}
+	exchange.Body = body
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	recorder := &responseRecorder{ResponseWriter: w, header: http.Header{}}
+	passThrough := http.HandlerFunc(p.passThrough)
+	if handler := p.handler(request); handler != nil {
+		exchange.Intercepted = true
+		handler(recorder, request, passThrough)
+	} else {
+		passThrough(recorder, request)
+	}
+
+	exchange.StatusCode = recorder.status
+	if recorder.status == 0 && !recorder.hijacked {
+		exchange.StatusCode = http.StatusOK
+	}
+	exchange.ResponseHeader = recorder.header
+	exchange.ResponseBody = recorder.body.Bytes()
+	exchange.responseProto = recorder.proto
+	if exchange.responseProto == "" {
+		exchange.responseProto = request.Proto
+	}
+	exchange.headersAt = recorder.headersAt
+	exchange.Duration = time.Since(exchange.Start)
+	p.record(exchange)
+}
+
+func (p *Proxy) handler(request *http.Request) Handler {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for i := len(p.routes) - 1; i >= 0; i-- {
+		if p.routes[i].matches(request.Method, request.URL.String(), request.URL.Path, request.URL.RawQuery) {
+			return p.routes[i].handler
+		}
+	}
+	return nil
+}
+
+func (p *Proxy) record(exchange *Exchange) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.traffic = append(p.traffic, exchange)
+}
+
+// hop-by-hop headers, see https://tools.ietf.org/html/rfc7230#section-6.1
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate",
+	"Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+func (p *Proxy) passThrough(w http.ResponseWriter, request *http.Request) {
+	outgoing := request.Clone(request.Context())
+	outgoing.RequestURI = ""
+	for _, header := range hopHeaders {
+		outgoing.Header.Del(header)
+	}
+
+	response, err := p.transport.RoundTrip(outgoing)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("agouti proxy: %s", err), http.StatusBadGateway)
+		return
+	}
+	defer response.Body.Close()
+
+	if recorder, ok := w.(*responseRecorder); ok {
+		recorder.proto = response.Proto
+	}
+	for key, values := range response.Header {
+		w.Header()[key] = values
+	}
+	for _, header := range hopHeaders {
+		w.Header().Del(header)
+	}
+	w.WriteHeader(response.StatusCode)
+	io.Copy(w, response.Body)
+}
+
+func (p *Proxy) tunnel(w http.ResponseWriter, request *http.Request) {
+	exchange := &Exchange{
+		Method: request.Method,
+		URL:    request.URL,
+		Header: request.Header.Clone(),
+		Start:  time.Now(),
+	}
+	defer func() {
+		exchange.Duration = time.Since(exchange.Start)
+		p.record(exchange)
+	}()
+
+	destination, err := net.DialTimeout("tcp", request.Host, 10*time.Second)
+	if err != nil {
+		exchange.StatusCode = http.StatusBadGateway
+		http.Error(w, fmt.Sprintf("agouti proxy: %s", err), http.StatusBadGateway)
+		return
+	}
+	defer destination.Close()
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		exchange.StatusCode = http.StatusInternalServerError
+		http.Error(w, "agouti proxy: tunneling is not supported", http.StatusInternalServerError)
+		return
+	}
+	client, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	exchange.StatusCode = http.StatusOK
+	io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n")
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(destination, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, destination)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+func (r route) matches(method, url, path, query string) bool {
+	if r.method != "" && r.method != strings.ToUpper(method) {
+		return false
+	}
+	target := url
+	if r.path {
+		target = path
+		if query != "" {
+			target += "?" + query
+		}
+	}
+	if !strings.Contains(r.pattern.String(), `\?`) {
+		target = strings.SplitN(target, "?", 2)[0]
+	}
+	return r.pattern.MatchString(target)
+}
+
+func compilePattern(pattern string) *regexp.Regexp {
+	quoted := strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1)
+	return regexp.MustCompile("^" + quoted + "$")
+}
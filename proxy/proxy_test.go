@@ -0,0 +1,287 @@
+package proxy_test
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/agouti/proxy"
+)
+
+var _ = Describe("Proxy", func() {
+	var (
+		networkProxy *proxy.Proxy
+		backend      *httptest.Server
+		client       *http.Client
+	)
+
+	BeforeEach(func() {
+		backend = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("X-Backend", "true")
+			w.WriteHeader(http.StatusTeapot)
+			w.Write([]byte("backend " + r.Method + " " + r.URL.RequestURI() + " " + string(body)))
+		}))
+
+		networkProxy = proxy.New()
+		Expect(networkProxy.Start()).To(Succeed())
+		proxyURL, err := url.Parse("http://" + networkProxy.Address())
+		Expect(err).NotTo(HaveOccurred())
+		client = &http.Client{Transport: &http.Transport{
+			Proxy:           http.ProxyURL(proxyURL),
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}}
+	})
+
+	AfterEach(func() {
+		networkProxy.Stop()
+		backend.Close()
+	})
+
+	get := func(path string) (*http.Response, string) {
+		response, err := client.Get(backend.URL + path)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		body, err := ioutil.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return response, string(body)
+	}
+
+	Describe("#Start", func() {
+		It("should listen on a local port", func() {
+			Expect(networkProxy.Address()).To(HavePrefix("127.0.0.1:"))
+		})
+
+		Context("when the proxy is already started", func() {
+			It("should return an error", func() {
+				Expect(networkProxy.Start()).To(MatchError("already started"))
+			})
+		})
+	})
+
+	Describe("#Stop", func() {
+		It("should stop the proxy", func() {
+			Expect(networkProxy.Stop()).To(Succeed())
+			Expect(networkProxy.Address()).To(BeEmpty())
+			_, err := client.Get(backend.URL)
+			Expect(err).To(HaveOccurred())
+			Expect(networkProxy.Stop()).To(MatchError("already stopped"))
+		})
+	})
+
+	Context("when a request does not match a route", func() {
+		It("should pass the request through to its destination", func() {
+			response, body := get("/some/path?some=query")
+			Expect(response.StatusCode).To(Equal(http.StatusTeapot))
+			Expect(response.Header.Get("X-Backend")).To(Equal("true"))
+			Expect(body).To(Equal("backend GET /some/path?some=query "))
+		})
+	})
+
+	Describe("#Route", func() {
+		It("should respond to matching requests using the handler", func() {
+			networkProxy.Route("GET", "/api/*", proxy.Fulfill(http.StatusOK, "text/plain", "some body"))
+			response, body := get("/api/users/1")
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(response.Header.Get("Content-Type")).To(Equal("text/plain"))
+			Expect(body).To(Equal("some body"))
+
+			_, body = get("/other")
+			Expect(body).To(Equal("backend GET /other "))
+		})
+
+		It("should only match requests with the provided method", func() {
+			networkProxy.Route("post", "/api", proxy.Fulfill(http.StatusCreated, "", ""))
+			response, _ := get("/api")
+			Expect(response.StatusCode).To(Equal(http.StatusTeapot))
+			response, err := client.Post(backend.URL+"/api", "text/plain", strings.NewReader("some body"))
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusCreated))
+		})
+
+		It("should match any method when the method is empty", func() {
+			networkProxy.Route("", "/api", proxy.Fulfill(http.StatusCreated, "", ""))
+			response, _ := get("/api")
+			Expect(response.StatusCode).To(Equal(http.StatusCreated))
+		})
+
+		It("should match full URLs", func() {
+			networkProxy.Route("GET", backend.URL+"/*", proxy.Fulfill(http.StatusOK, "", "matched"))
+			networkProxy.Route("GET", "http://example.invalid/*", proxy.Fulfill(http.StatusOK, "", "other"))
+			_, body := get("/some/path")
+			Expect(body).To(Equal("matched"))
+		})
+
+		It("should only match the query when the pattern contains one", func() {
+			networkProxy.Route("GET", "/search", proxy.Fulfill(http.StatusOK, "", "any query"))
+			networkProxy.Route("GET", "/search?q=special", proxy.Fulfill(http.StatusOK, "", "special query"))
+			_, body := get("/search?q=other")
+			Expect(body).To(Equal("any query"))
+			_, body = get("/search?q=special")
+			Expect(body).To(Equal("special query"))
+		})
+
+		It("should give precedence to routes registered later", func() {
+			networkProxy.Route("GET", "/*", proxy.Fulfill(http.StatusOK, "", "first"))
+			networkProxy.Route("GET", "/api", proxy.PassThrough())
+			_, body := get("/api")
+			Expect(body).To(Equal("backend GET /api "))
+			_, body = get("/other")
+			Expect(body).To(Equal("first"))
+		})
+	})
+
+	Describe("#ClearRoutes", func() {
+		It("should pass all requests through", func() {
+			networkProxy.Route("GET", "/*", proxy.Fulfill(http.StatusOK, "", "routed"))
+			networkProxy.ClearRoutes()
+			_, body := get("/api")
+			Expect(body).To(Equal("backend GET /api "))
+		})
+	})
+
+	Describe("handlers", func() {
+		Describe("JSON", func() {
+			It("should respond with the encoded value", func() {
+				networkProxy.Route("GET", "/api", proxy.JSON(http.StatusCreated, map[string]int{"id": 1}))
+				response, body := get("/api")
+				Expect(response.StatusCode).To(Equal(http.StatusCreated))
+				Expect(response.Header.Get("Content-Type")).To(Equal("application/json"))
+				Expect(body).To(MatchJSON(`{"id": 1}`))
+			})
+
+			Context("when the value cannot be encoded", func() {
+				It("should respond with an error", func() {
+					networkProxy.Route("GET", "/api", proxy.JSON(http.StatusOK, func() {}))
+					response, body := get("/api")
+					Expect(response.StatusCode).To(Equal(http.StatusInternalServerError))
+					Expect(body).To(HavePrefix("agouti proxy: failed to encode JSON: "))
+				})
+			})
+		})
+
+		Describe("Delay", func() {
+			It("should call the handler after the delay", func() {
+				networkProxy.Route("GET", "/api", proxy.Delay(50*time.Millisecond, proxy.Fulfill(http.StatusOK, "", "delayed")))
+				start := time.Now()
+				_, body := get("/api")
+				Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+				Expect(body).To(Equal("delayed"))
+			})
+
+			It("should pass the request through when the handler is nil", func() {
+				networkProxy.Route("GET", "/api", proxy.Delay(time.Millisecond, nil))
+				_, body := get("/api")
+				Expect(body).To(Equal("backend GET /api "))
+			})
+		})
+
+		Describe("Fail", func() {
+			It("should close the connection without responding", func() {
+				networkProxy.Route("GET", "/api", proxy.Fail())
+				_, err := client.Get(backend.URL + "/api")
+				Expect(err).To(HaveOccurred())
+				Eventually(networkProxy.Traffic).Should(HaveLen(1))
+				Expect(networkProxy.Traffic()[0].StatusCode).To(BeZero())
+			})
+		})
+
+		Describe("custom handlers", func() {
+			It("should allow modifying passed through responses", func() {
+				networkProxy.Route("GET", "/api", func(w http.ResponseWriter, r *http.Request, passThrough http.Handler) {
+					w.Header().Set("X-Modified", "true")
+					passThrough.ServeHTTP(w, r)
+				})
+				response, body := get("/api")
+				Expect(response.Header.Get("X-Modified")).To(Equal("true"))
+				Expect(body).To(Equal("backend GET /api "))
+			})
+		})
+	})
+
+	Describe("#Traffic", func() {
+		It("should record each request and its response", func() {
+			networkProxy.Route("POST", "/api/orders", proxy.JSON(http.StatusCreated, map[string]int{"id": 1}))
+			response, err := client.Post(backend.URL+"/api/orders", "application/json", strings.NewReader(`{"item": "some-item"}`))
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+			get("/other")
+
+			traffic := networkProxy.Traffic()
+			Expect(traffic).To(HaveLen(2))
+
+			Expect(traffic[0].Method).To(Equal("POST"))
+			Expect(traffic[0].URL.String()).To(Equal(backend.URL + "/api/orders"))
+			Expect(traffic[0].Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(traffic[0].StatusCode).To(Equal(http.StatusCreated))
+			Expect(traffic[0].ResponseHeader.Get("Content-Type")).To(Equal("application/json"))
+			Expect(string(traffic[0].ResponseBody)).To(MatchJSON(`{"id": 1}`))
+			Expect(traffic[0].Intercepted).To(BeTrue())
+			Expect(traffic[0].String()).To(Equal("POST " + backend.URL + "/api/orders (201)"))
+
+			var order struct{ Item string }
+			Expect(traffic[0].JSON(&order)).To(Succeed())
+			Expect(order.Item).To(Equal("some-item"))
+
+			Expect(traffic[1].StatusCode).To(Equal(http.StatusTeapot))
+			Expect(string(traffic[1].ResponseBody)).To(Equal("backend GET /other "))
+			Expect(traffic[1].Intercepted).To(BeFalse())
+		})
+
+		It("should record tunneled HTTPS requests without their contents", func() {
+			tlsBackend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("secure"))
+			}))
+			defer tlsBackend.Close()
+
+			response, err := client.Get(tlsBackend.URL)
+			Expect(err).NotTo(HaveOccurred())
+			body, _ := ioutil.ReadAll(response.Body)
+			response.Body.Close()
+			Expect(string(body)).To(Equal("secure"))
+
+			client.Transport.(*http.Transport).CloseIdleConnections()
+			Eventually(networkProxy.Traffic).Should(HaveLen(1))
+			Expect(networkProxy.Traffic()[0].Method).To(Equal("CONNECT"))
+			Expect(networkProxy.Traffic()[0].URL.Host).To(Equal(strings.TrimPrefix(tlsBackend.URL, "https://")))
+			Expect(networkProxy.Traffic()[0].StatusCode).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("#Requests", func() {
+		It("should return recorded requests that match the method and pattern", func() {
+			get("/api/users")
+			get("/api/orders")
+			get("/other")
+			requests := networkProxy.Requests("GET", "/api/*")
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[0].URL.Path).To(Equal("/api/users"))
+			Expect(requests[1].URL.Path).To(Equal("/api/orders"))
+			Expect(networkProxy.Requests("POST", "/api/*")).To(BeEmpty())
+		})
+	})
+
+	Describe("#ClearTraffic", func() {
+		It("should discard recorded requests", func() {
+			get("/api")
+			networkProxy.ClearTraffic()
+			Expect(networkProxy.Traffic()).To(BeEmpty())
+		})
+	})
+
+	Context("when a request is not a proxy request", func() {
+		It("should respond with an error", func() {
+			response, err := http.Get("http://" + networkProxy.Address() + "/some/path")
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
@@ -0,0 +1,288 @@
+package agouti
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+
+	"github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/internal/element"
+)
+
+// ScreenshotPNG returns a PNG screenshot of the visible portion of the page.
+func (p *Page) ScreenshotPNG() ([]byte, error) {
+	screenshot, err := p.session.GetScreenshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve screenshot: %w", err)
+	}
+	return screenshot, nil
+}
+
+// ScreenshotImage returns a screenshot of the visible portion of the page.
+func (p *Page) ScreenshotImage() (image.Image, error) {
+	return p.screenshotImage()
+}
+
+// FullPageScreenshot takes a screenshot of the entire page and saves it as a
+// PNG to the provided filename. See FullPageScreenshotImage.
+func (p *Page) FullPageScreenshot(filename string) error {
+	screenshot, err := p.FullPageScreenshotImage()
+	if err != nil {
+		return err
+	}
+	encoded, err := encodePNG(screenshot)
+	if err != nil {
+		return err
+	}
+	return savePNG(filename, encoded)
+}
+
+// FullPageScreenshotPNG returns a PNG screenshot of the entire page.
+// See FullPageScreenshotImage.
+func (p *Page) FullPageScreenshotPNG() ([]byte, error) {
+	screenshot, err := p.FullPageScreenshotImage()
+	if err != nil {
+		return nil, err
+	}
+	return encodePNG(screenshot)
+}
+
+// FullPageScreenshotImage returns a screenshot of the entire page. The page is
+// scrolled through using JavaScript, and screenshots of each portion of the
+// page are stitched together. The original scroll position is restored
+// afterwards. Fixed-position elements (ex. sticky headers) will appear in
+// each portion of the page.
+func (p *Page) FullPageScreenshotImage() (image.Image, error) {
+	original, err := p.scrollTo(-1, -1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve page dimensions: %w", err)
+	}
+	defer p.scrollTo(original.ScrollX, original.ScrollY)
+
+	var canvas *image.RGBA
+	var scale float64
+	for y := 0.0; ; y += original.Height {
+		for x := 0.0; ; x += original.Width {
+			viewport, err := p.scrollTo(x, y)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scroll page: %w", err)
+			}
+			screenshot, err := p.screenshotImage()
+			if err != nil {
+				return nil, err
+			}
+
+			bounds := screenshot.Bounds()
+			if canvas == nil {
+				scale = viewport.scale(bounds)
+				canvas = image.NewRGBA(image.Rect(0, 0,
+					int(math.Ceil(math.Max(viewport.PageWidth, viewport.Width)*scale)),
+					int(math.Ceil(math.Max(viewport.PageHeight, viewport.Height)*scale)),
+				))
+			}
+			offset := image.Pt(round(viewport.ScrollX*scale), round(viewport.ScrollY*scale))
+			draw.Draw(canvas, bounds.Sub(bounds.Min).Add(offset), screenshot, bounds.Min, draw.Src)
+
+			if x+original.Width >= original.PageWidth {
+				break
+			}
+		}
+		if y+original.Height >= original.PageHeight {
+			break
+		}
+	}
+	return canvas, nil
+}
+
+// Screenshot takes a screenshot of exactly one element and saves it as a PNG
+// to the provided filename. See ScreenshotPNG.
+func (s *Selection) Screenshot(filename string) error {
+	screenshot, err := s.ScreenshotPNG()
+	if err != nil {
+		return err
+	}
+	return savePNG(filename, screenshot)
+}
+
+// ScreenshotPNG returns a PNG screenshot of exactly one element. If the
+// WebDriver does not support element screenshots, a screenshot of the page
+// is cropped to the location and size of the element.
+func (s *Selection) ScreenshotPNG() ([]byte, error) {
+	var screenshot []byte
+	err := s.forExactlyOne(func(selectedElement element.Element) error {
+		var err error
+		screenshot, err = selectedElement.GetScreenshot()
+		if api.IsUnsupported(err) {
+			screenshot, err = s.croppedScreenshot(selectedElement)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to retrieve screenshot of %s: %w", s, err)
+		}
+		return nil
+	})
+	return screenshot, err
+}
+
+// ScreenshotImage returns a screenshot of exactly one element. See ScreenshotPNG.
+func (s *Selection) ScreenshotImage() (image.Image, error) {
+	screenshot, err := s.ScreenshotPNG()
+	if err != nil {
+		return nil, err
+	}
+	return decodePNG(screenshot)
+}
+
+// Bounds returns the location and size of exactly one element in screenshot
+// pixels, relative to the visible portion of the page. If the scroll position
+// and pixel ratio of the page cannot be determined using JavaScript, the
+// location of the element on the page is returned.
+func (s *Selection) Bounds() (image.Rectangle, error) {
+	var bounds image.Rectangle
+	err := s.forExactlyOne(func(selectedElement element.Element) error {
+		x, y, err := selectedElement.GetLocation()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve location of %s: %w", s, err)
+		}
+		width, height, err := selectedElement.GetSize()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve size of %s: %w", s, err)
+		}
+
+		scale, left, top := 1.0, float64(x), float64(y)
+		if viewport, err := s.scrollTo(-1, -1); err == nil {
+			if viewport.PixelRatio > 0 {
+				scale = viewport.PixelRatio
+			}
+			left -= viewport.ScrollX
+			top -= viewport.ScrollY
+		}
+		bounds = image.Rect(
+			round(left*scale), round(top*scale),
+			round((left+float64(width))*scale), round((top+float64(height))*scale),
+		)
+		return nil
+	})
+	return bounds, err
+}
+
+func (s *Selection) croppedScreenshot(selectedElement element.Element) ([]byte, error) {
+	x, y, err := selectedElement.GetLocation()
+	if err != nil {
+		return nil, err
+	}
+	width, height, err := selectedElement.GetSize()
+	if err != nil {
+		return nil, err
+	}
+	screenshot, err := s.screenshotImage()
+	if err != nil {
+		return nil, err
+	}
+
+	// element locations are relative to the page, and screenshots may be
+	// scaled on high-DPI displays
+	bounds := screenshot.Bounds()
+	scale := 1.0
+	if viewport, err := s.scrollTo(-1, -1); err == nil {
+		scale = viewport.scale(bounds)
+		x -= round(viewport.ScrollX)
+		y -= round(viewport.ScrollY)
+	}
+	crop := image.Rect(
+		round(float64(x)*scale), round(float64(y)*scale),
+		round(float64(x+width)*scale), round(float64(y+height)*scale),
+	).Add(bounds.Min).Intersect(bounds)
+	if crop.Empty() {
+		return nil, fmt.Errorf("element is outside of the visible page")
+	}
+
+	cropped := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), screenshot, crop.Min, draw.Src)
+	return encodePNG(cropped)
+}
+
+type viewport struct {
+	ScrollX    float64 `json:"scrollX"`
+	ScrollY    float64 `json:"scrollY"`
+	Width      float64 `json:"width"`
+	Height     float64 `json:"height"`
+	PageWidth  float64 `json:"pageWidth"`
+	PageHeight float64 `json:"pageHeight"`
+	PixelRatio float64 `json:"pixelRatio"`
+}
+
+// scale returns the ratio of screenshot pixels to CSS pixels.
+func (v viewport) scale(screenshot image.Rectangle) float64 {
+	if v.Width <= 0 {
+		return 1
+	}
+	return float64(screenshot.Dx()) / v.Width
+}
+
+const scrollScript = `
+	if (arguments[0] >= 0) window.scrollTo(arguments[0], arguments[1]);
+	var root = document.documentElement, body = document.body || root;
+	return {
+		scrollX: window.pageXOffset, scrollY: window.pageYOffset,
+		width: root.clientWidth || window.innerWidth, height: root.clientHeight || window.innerHeight,
+		pageWidth: Math.max(root.scrollWidth, body.scrollWidth),
+		pageHeight: Math.max(root.scrollHeight, body.scrollHeight),
+		pixelRatio: window.devicePixelRatio || 1
+	};`
+
+// scrollTo scrolls to the provided position, unless it is negative, and
+// returns the resulting viewport.
+func (s *selectable) scrollTo(x, y float64) (viewport, error) {
+	var result viewport
+	if err := s.session.Execute(scrollScript, []interface{}{x, y}, &result); err != nil {
+		return viewport{}, err
+	}
+	if result.Width <= 0 || result.Height <= 0 {
+		return viewport{}, fmt.Errorf("invalid viewport size %vx%v", result.Width, result.Height)
+	}
+	return result, nil
+}
+
+func (s *selectable) screenshotImage() (image.Image, error) {
+	screenshot, err := s.session.GetScreenshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve screenshot: %w", err)
+	}
+	return decodePNG(screenshot)
+}
+
+func decodePNG(screenshot []byte) (image.Image, error) {
+	decoded, err := png.Decode(bytes.NewReader(screenshot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	return decoded, nil
+}
+
+func encodePNG(screenshot image.Image) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, screenshot); err != nil {
+		return nil, fmt.Errorf("failed to encode screenshot: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+func savePNG(filename string, screenshot []byte) error {
+	absFilePath, err := filepath.Abs(filename)
+	if err != nil {
+		return fmt.Errorf("failed to find absolute path for filename: %w", err)
+	}
+	if err := ioutil.WriteFile(absFilePath, screenshot, 0666); err != nil {
+		return fmt.Errorf("failed to save screenshot: %w", err)
+	}
+	return nil
+}
+
+func round(number float64) int {
+	return int(math.Floor(number + 0.5))
+}
@@ -0,0 +1,275 @@
+package agouti_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti"
+	"github.com/sclevine/agouti/agoutitest"
+	"github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/internal/mocks"
+)
+
+func gradientPNG(width, height, offsetX, offsetY int) []byte {
+	gradient := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			gradient.Set(x, y, color.RGBA{uint8(x + offsetX), uint8(y + offsetY), 0, 255})
+		}
+	}
+	var buffer bytes.Buffer
+	png.Encode(&buffer, gradient)
+	return buffer.Bytes()
+}
+
+func decodePNG(screenshot []byte) image.Image {
+	decoded, err := png.Decode(bytes.NewReader(screenshot))
+	Expect(err).NotTo(HaveOccurred())
+	return decoded
+}
+
+var _ = Describe("Screenshots", func() {
+	Describe("Selection", func() {
+		var (
+			selection         *Selection
+			session           *mocks.Session
+			elementRepository *mocks.ElementRepository
+			element           *mocks.Element
+		)
+
+		BeforeEach(func() {
+			session = &mocks.Session{}
+			element = &mocks.Element{}
+			elementRepository = &mocks.ElementRepository{}
+			elementRepository.GetExactlyOneCall.ReturnElement = element
+			selection = NewTestSelection(session, elementRepository, "#selector")
+		})
+
+		Describe("#ScreenshotPNG", func() {
+			It("should return the element screenshot", func() {
+				element.GetScreenshotCall.ReturnImage = []byte("some-image")
+				Expect(selection.ScreenshotPNG()).To(Equal([]byte("some-image")))
+			})
+
+			Context("when the WebDriver does not support element screenshots", func() {
+				BeforeEach(func() {
+					element.GetScreenshotCall.Err = &api.Error{Code: "unknown command"}
+					element.GetLocationCall.ReturnX = 10
+					element.GetLocationCall.ReturnY = 30
+					element.GetSizeCall.ReturnWidth = 20
+					element.GetSizeCall.ReturnHeight = 10
+					session.GetScreenshotCall.ReturnImage = gradientPNG(200, 100, 0, 0)
+					session.ExecuteCall.Result = `{"scrollX": 0, "scrollY": 20, "width": 100, "height": 50, "pageWidth": 100, "pageHeight": 500}`
+				})
+
+				It("should crop the page screenshot to the element", func() {
+					screenshot, err := selection.ScreenshotPNG()
+					Expect(err).NotTo(HaveOccurred())
+					cropped := decodePNG(screenshot)
+					Expect(cropped.Bounds()).To(Equal(image.Rect(0, 0, 40, 20)))
+					Expect(color.RGBAModel.Convert(cropped.At(0, 0))).To(Equal(color.RGBA{20, 20, 0, 255}))
+					Expect(session.ExecuteCall.Arguments).To(Equal([]interface{}{-1.0, -1.0}))
+				})
+
+				Context("when the viewport cannot be determined", func() {
+					It("should crop the page screenshot without scaling", func() {
+						session.ExecuteCall.Err = errors.New("some error")
+						screenshot, err := selection.ScreenshotPNG()
+						Expect(err).NotTo(HaveOccurred())
+						cropped := decodePNG(screenshot)
+						Expect(cropped.Bounds()).To(Equal(image.Rect(0, 0, 20, 10)))
+						Expect(color.RGBAModel.Convert(cropped.At(0, 0))).To(Equal(color.RGBA{10, 30, 0, 255}))
+					})
+				})
+
+				Context("when the element is not visible", func() {
+					It("should return an error", func() {
+						element.GetLocationCall.ReturnY = 200
+						_, err := selection.ScreenshotPNG()
+						Expect(err).To(MatchError("failed to retrieve screenshot of selection 'CSS: #selector [single]': element is outside of the visible page"))
+					})
+				})
+
+				Context("when the page screenshot cannot be retrieved", func() {
+					It("should return an error", func() {
+						session.GetScreenshotCall.Err = errors.New("some error")
+						_, err := selection.ScreenshotPNG()
+						Expect(err).To(MatchError("failed to retrieve screenshot of selection 'CSS: #selector [single]': failed to retrieve screenshot: some error"))
+					})
+				})
+			})
+
+			Context("when the element repository fails to return exactly one element", func() {
+				It("should return an error", func() {
+					elementRepository.GetExactlyOneCall.Err = errors.New("some error")
+					_, err := selection.ScreenshotPNG()
+					Expect(err).To(MatchError("failed to select element from selection 'CSS: #selector [single]': some error"))
+				})
+			})
+
+			Context("when the element screenshot fails", func() {
+				It("should return an error", func() {
+					element.GetScreenshotCall.Err = errors.New("some error")
+					_, err := selection.ScreenshotPNG()
+					Expect(err).To(MatchError("failed to retrieve screenshot of selection 'CSS: #selector [single]': some error"))
+				})
+			})
+		})
+
+		Describe("#Bounds", func() {
+			BeforeEach(func() {
+				element.GetLocationCall.ReturnX = 10
+				element.GetLocationCall.ReturnY = 30
+				element.GetSizeCall.ReturnWidth = 20
+				element.GetSizeCall.ReturnHeight = 10
+			})
+
+			It("should return the scaled bounds of the element relative to the visible page", func() {
+				session.ExecuteCall.Result = `{"scrollX": 0, "scrollY": 20, "width": 100, "height": 50, "pixelRatio": 2}`
+				Expect(selection.Bounds()).To(Equal(image.Rect(20, 20, 60, 40)))
+			})
+
+			Context("when the viewport cannot be determined", func() {
+				It("should return the bounds of the element on the page", func() {
+					session.ExecuteCall.Err = errors.New("some error")
+					Expect(selection.Bounds()).To(Equal(image.Rect(10, 30, 30, 40)))
+				})
+			})
+
+			Context("when the element size cannot be retrieved", func() {
+				It("should return an error", func() {
+					element.GetSizeCall.Err = errors.New("some error")
+					_, err := selection.Bounds()
+					Expect(err).To(MatchError("failed to retrieve size of selection 'CSS: #selector [single]': some error"))
+				})
+			})
+		})
+
+		Describe("#ScreenshotImage", func() {
+			It("should return the decoded element screenshot", func() {
+				element.GetScreenshotCall.ReturnImage = gradientPNG(4, 2, 0, 0)
+				screenshot, err := selection.ScreenshotImage()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(screenshot.Bounds()).To(Equal(image.Rect(0, 0, 4, 2)))
+			})
+
+			Context("when the screenshot is not a PNG", func() {
+				It("should return an error", func() {
+					element.GetScreenshotCall.ReturnImage = []byte("some-image")
+					_, err := selection.ScreenshotImage()
+					Expect(err.Error()).To(HavePrefix("failed to decode screenshot: "))
+				})
+			})
+		})
+	})
+
+	Describe("Page", func() {
+		var (
+			page    *Page
+			session *mocks.Session
+		)
+
+		BeforeEach(func() {
+			session = &mocks.Session{}
+			page = NewTestPage(session)
+		})
+
+		Describe("#ScreenshotPNG", func() {
+			It("should return the screenshot", func() {
+				session.GetScreenshotCall.ReturnImage = []byte("some-image")
+				Expect(page.ScreenshotPNG()).To(Equal([]byte("some-image")))
+			})
+
+			Context("when the session fails to retrieve a screenshot", func() {
+				It("should return an error", func() {
+					session.GetScreenshotCall.Err = errors.New("some error")
+					_, err := page.ScreenshotPNG()
+					Expect(err).To(MatchError("failed to retrieve screenshot: some error"))
+				})
+			})
+		})
+
+		Describe("#ScreenshotImage", func() {
+			It("should return the decoded screenshot", func() {
+				session.GetScreenshotCall.ReturnImage = gradientPNG(4, 2, 0, 0)
+				screenshot, err := page.ScreenshotImage()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(screenshot.Bounds()).To(Equal(image.Rect(0, 0, 4, 2)))
+			})
+		})
+
+		Describe("#FullPageScreenshotImage", func() {
+			var (
+				server  *agoutitest.Server
+				scrollX int
+				scrollY int
+				scrolls [][]int
+			)
+
+			BeforeEach(func() {
+				server = agoutitest.NewServer()
+				scrollX, scrollY, scrolls = 0, 0, nil
+
+				// a 60x50 page in a 40x30 viewport at 2x scale
+				server.Handle("POST", "execute", func(_ *agoutitest.Session, body []byte) (interface{}, error) {
+					var request struct{ Args []int }
+					if err := json.Unmarshal(body, &request); err != nil {
+						return nil, err
+					}
+					if x, y := request.Args[0], request.Args[1]; x >= 0 {
+						scrollX, scrollY = atMost(x, 20), atMost(y, 20)
+						scrolls = append(scrolls, []int{x, y})
+					}
+					return map[string]int{
+						"scrollX": scrollX, "scrollY": scrollY,
+						"width": 40, "height": 30,
+						"pageWidth": 60, "pageHeight": 50,
+					}, nil
+				})
+				server.Handle("GET", "screenshot", func(_ *agoutitest.Session, _ []byte) (interface{}, error) {
+					return base64.StdEncoding.EncodeToString(gradientPNG(80, 60, scrollX*2, scrollY*2)), nil
+				})
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
+			It("should stitch together screenshots of each portion of the page", func() {
+				page, err := NewPage(server.URL())
+				Expect(err).NotTo(HaveOccurred())
+				scrollX, scrollY = 5, 7
+
+				screenshot, err := page.FullPageScreenshotImage()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(screenshot.Bounds()).To(Equal(image.Rect(0, 0, 120, 100)))
+				for _, point := range []image.Point{{0, 0}, {79, 59}, {119, 0}, {0, 99}, {119, 99}, {60, 50}} {
+					Expect(color.RGBAModel.Convert(screenshot.At(point.X, point.Y))).To(Equal(color.RGBA{uint8(point.X), uint8(point.Y), 0, 255}))
+				}
+				Expect(scrolls).To(Equal([][]int{{0, 0}, {40, 0}, {0, 30}, {40, 30}, {5, 7}}))
+			})
+
+			Context("when the page dimensions cannot be retrieved", func() {
+				It("should return an error", func() {
+					session.ExecuteCall.Err = errors.New("some error")
+					_, err := page.FullPageScreenshotImage()
+					Expect(err).To(MatchError("failed to retrieve page dimensions: some error"))
+				})
+			})
+		})
+	})
+})
+
+func atMost(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -1,6 +1,8 @@
 package agouti
 
 import (
+	"context"
+
 	"github.com/sclevine/agouti/api"
 	"github.com/sclevine/agouti/internal/element"
 	"github.com/sclevine/agouti/internal/target"
@@ -11,8 +13,9 @@ type Selectors interface {
 }
 
 type selectable struct {
-	session   apiSession
-	selectors target.Selectors
+	session      apiSession
+	selectors    target.Selectors
+	staleRetries int
 }
 
 type apiSession interface {
@@ -58,134 +61,159 @@ type apiSession interface {
 	TouchLongClick(element *api.Element) error
 	TouchFlick(element *api.Element, offset api.Offset, speed api.Speed) error
 	TouchScroll(element *api.Element, offset api.Offset) error
+	PerformActions(actions *api.Actions) error
+	ReleaseActions() error
 	DeleteLocalStorage() error
 	DeleteSessionStorage() error
 	SetImplicitWait(timout int) error
 	SetPageLoad(timout int) error
 	SetScriptTimeout(timout int) error
+	ExecuteCDP(command string, params, result interface{}) error
+	DebuggerAddress() (string, error)
+}
+
+func (s *selectable) sessionWithContext(ctx context.Context) apiSession {
+	if session, ok := s.session.(*api.Session); ok {
+		return session.WithContext(ctx)
+	}
+	return s.session
+}
+
+func (s *selectable) selection(selectors target.Selectors) *Selection {
+	return &Selection{
+		selectable{s.session, selectors, s.staleRetries},
+		&element.Repository{
+			Client:    s.session,
+			Selectors: selectors,
+		},
+	}
+}
+
+func (s *selectable) multiSelection(selectors target.Selectors) *MultiSelection {
+	return &MultiSelection{*s.selection(selectors)}
 }
 
 // Find finds exactly one element by CSS selector.
 func (s *selectable) Find(selector string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.CSS, selector).Single())
+	return s.selection(s.selectors.Append(target.CSS, selector).Single())
 }
 
 // FindByXPath finds exactly one element by XPath selector.
 func (s *selectable) FindByXPath(selector string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.XPath, selector).Single())
+	return s.selection(s.selectors.Append(target.XPath, selector).Single())
 }
 
 // FindByLink finds exactly one anchor element by its text content.
 func (s *selectable) FindByLink(text string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Link, text).Single())
+	return s.selection(s.selectors.Append(target.Link, text).Single())
 }
 
 // FindByLabel finds exactly one element by associated label text.
 func (s *selectable) FindByLabel(text string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Label, text).Single())
+	return s.selection(s.selectors.Append(target.Label, text).Single())
 }
 
 // FindByButton finds exactly one button element with the provided text.
 // Supports <button>, <input type="button">, and <input type="submit">.
 func (s *selectable) FindByButton(text string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Button, text).Single())
+	return s.selection(s.selectors.Append(target.Button, text).Single())
 }
 
 // FindByName finds exactly element with the provided name attribute.
 func (s *selectable) FindByName(name string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Name, name).Single())
+	return s.selection(s.selectors.Append(target.Name, name).Single())
 }
 
 // FindByClass finds exactly one element with a given CSS class.
 func (s *selectable) FindByClass(text string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Class, text).Single())
+	return s.selection(s.selectors.Append(target.Class, text).Single())
 }
 
 // FindByID finds exactly one element that has the given ID.
 func (s *selectable) FindByID(id string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.ID, id).Single())
+	return s.selection(s.selectors.Append(target.ID, id).Single())
 }
 
 // First finds the first element by CSS selector.
 func (s *selectable) First(selector string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.CSS, selector).At(0))
+	return s.selection(s.selectors.Append(target.CSS, selector).At(0))
 }
 
 // FirstByXPath finds the first element by XPath selector.
 func (s *selectable) FirstByXPath(selector string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.XPath, selector).At(0))
+	return s.selection(s.selectors.Append(target.XPath, selector).At(0))
 }
 
 // FirstByLink finds the first anchor element by its text content.
 func (s *selectable) FirstByLink(text string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Link, text).At(0))
+	return s.selection(s.selectors.Append(target.Link, text).At(0))
 }
 
 // FirstByLabel finds the first element by associated label text.
 func (s *selectable) FirstByLabel(text string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Label, text).At(0))
+	return s.selection(s.selectors.Append(target.Label, text).At(0))
 }
 
 // FirstByButton finds the first button element with the provided text.
 // Supports <button>, <input type="button">, and <input type="submit">.
 func (s *selectable) FirstByButton(text string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Button, text).At(0))
+	return s.selection(s.selectors.Append(target.Button, text).At(0))
 }
 
 // FirstByName finds the first element with the provided name attribute.
 func (s *selectable) FirstByName(name string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Name, name).At(0))
+	return s.selection(s.selectors.Append(target.Name, name).At(0))
 }
 
 // FirstByClass finds the first element with a given CSS class.
 func (s *selectable) FirstByClass(text string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Class, text).At(0))
+	return s.selection(s.selectors.Append(target.Class, text).At(0))
 }
 
 // All finds zero or more elements by CSS selector.
 func (s *selectable) All(selector string) *MultiSelection {
-	return newMultiSelection(s.session, s.selectors.Append(target.CSS, selector))
+	return s.multiSelection(s.selectors.Append(target.CSS, selector))
 }
 
 // AllByXPath finds zero or more elements by XPath selector.
 func (s *selectable) AllByXPath(selector string) *MultiSelection {
-	return newMultiSelection(s.session, s.selectors.Append(target.XPath, selector))
+	return s.multiSelection(s.selectors.Append(target.XPath, selector))
 }
 
 // AllByLink finds zero or more anchor elements by their text content.
 func (s *selectable) AllByLink(text string) *MultiSelection {
-	return newMultiSelection(s.session, s.selectors.Append(target.Link, text))
+	return s.multiSelection(s.selectors.Append(target.Link, text))
 }
 
 // AllByLabel finds zero or more elements by associated label text.
 func (s *selectable) AllByLabel(text string) *MultiSelection {
-	return newMultiSelection(s.session, s.selectors.Append(target.Label, text))
+	return s.multiSelection(s.selectors.Append(target.Label, text))
 }
 
 // AllByButton finds zero or more button elements with the provided text.
 // Supports <button>, <input type="button">, and <input type="submit">.
 func (s *selectable) AllByButton(text string) *MultiSelection {
-	return newMultiSelection(s.session, s.selectors.Append(target.Button, text))
+	return s.multiSelection(s.selectors.Append(target.Button, text))
 }
 
 // AllByName finds zero or more elements with the provided name attribute.
 func (s *selectable) AllByName(name string) *MultiSelection {
-	return newMultiSelection(s.session, s.selectors.Append(target.Name, name))
+	return s.multiSelection(s.selectors.Append(target.Name, name))
 }
 
 // AllByClass finds zero or more elements with a given CSS class.
 func (s *selectable) AllByClass(text string) *MultiSelection {
-	return newMultiSelection(s.session, s.selectors.Append(target.Class, text))
+	return s.multiSelection(s.selectors.Append(target.Class, text))
 }
 
 // AllByID finds zero or more elements with a given ID.
 func (s *selectable) AllByID(text string) *MultiSelection {
-	return newMultiSelection(s.session, s.selectors.Append(target.ID, text))
+	return s.multiSelection(s.selectors.Append(target.ID, text))
 }
 
 // FirstByClass finds the first element with a given CSS class.
 func (s *selectable) FindForAppium(selectorType string, text string) *Selection {
-	return newSelection(s.session, s.selectors.Append(target.Class, text).At(0))
+	return s.selection(s.selectors.Append(target.Class, text).At(0))
 }
 
 func (s *selectable) Selectors() Selectors {
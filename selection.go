@@ -1,11 +1,11 @@
 package agouti
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sclevine/agouti/api"
 	"github.com/sclevine/agouti/internal/element"
-	"github.com/sclevine/agouti/internal/target"
 )
 
 // Selection instances refer to a selection of elements.
@@ -32,22 +32,19 @@ type elementRepository interface {
 	GetExactlyOne() (element.Element, error)
 }
 
-func newSelection(session apiSession, selectors target.Selectors) *Selection {
-	return &Selection{
-		selectable{session, selectors},
-		&element.Repository{
-			Client:    session,
-			Selectors: selectors,
-		},
-	}
-}
-
 // String returns a string representation of the selection, ex.
 //    selection 'CSS: .some-class | XPath: //table [3] | Link "click me" [single]'
 func (s *Selection) String() string {
 	return fmt.Sprintf("selection '%s'", s.selectors)
 }
 
+// WithContext returns a copy of the selection that aborts all WebDriver
+// requests when the provided context is canceled or exceeds its deadline.
+func (s *Selection) WithContext(ctx context.Context) *Selection {
+	contextSelectable := selectable{s.sessionWithContext(ctx), nil, s.staleRetries}
+	return contextSelectable.selection(s.selectors)
+}
+
 // Elements returns a []*api.Element that can be used to send direct commands
 // to WebDriver elements. See: https://code.google.com/p/selenium/wiki/JsonWireProtocol
 func (s *Selection) Elements() ([]*api.Element, error) {
@@ -66,7 +63,7 @@ func (s *Selection) Elements() ([]*api.Element, error) {
 func (s *Selection) Count() (int, error) {
 	elements, err := s.elements.Get()
 	if err != nil {
-		return 0, fmt.Errorf("failed to select elements from %s: %s", s, err)
+		return 0, fmt.Errorf("failed to select elements from %s: %w", s, err)
 	}
 
 	return len(elements), nil
@@ -86,17 +83,17 @@ func (s *Selection) EqualsElement(other interface{}) (bool, error) {
 
 	selectedElement, err := s.elements.GetExactlyOne()
 	if err != nil {
-		return false, fmt.Errorf("failed to select element from %s: %s", s, err)
+		return false, fmt.Errorf("failed to select element from %s: %w", s, err)
 	}
 
 	otherElement, err := otherSelection.elements.GetExactlyOne()
 	if err != nil {
-		return false, fmt.Errorf("failed to select element from %s: %s", other, err)
+		return false, fmt.Errorf("failed to select element from %s: %w", other, err)
 	}
 
 	equal, err := selectedElement.IsEqualTo(otherElement.(*api.Element))
 	if err != nil {
-		return false, fmt.Errorf("failed to compare %s to %s: %s", s, other, err)
+		return false, fmt.Errorf("failed to compare %s to %s: %w", s, other, err)
 	}
 
 	return equal, nil
@@ -104,14 +101,10 @@ func (s *Selection) EqualsElement(other interface{}) (bool, error) {
 
 // MouseToElement moves the mouse over exactly one element in the selection.
 func (s *Selection) MouseToElement() error {
-	selectedElement, err := s.elements.GetExactlyOne()
-	if err != nil {
-		return fmt.Errorf("failed to select element from %s: %s", s, err)
-	}
-
-	if err := s.session.MoveTo(selectedElement.(*api.Element), nil); err != nil {
-		return fmt.Errorf("failed to move mouse to element for %s: %s", s, err)
-	}
-
-	return nil
+	return s.forExactlyOne(func(selectedElement element.Element) error {
+		if err := s.session.MoveTo(selectedElement.(*api.Element), nil); err != nil {
+			return fmt.Errorf("failed to move mouse to element for %s: %w", s, err)
+		}
+		return nil
+	})
 }
@@ -12,24 +12,48 @@ import (
 type actionsFunc func(element.Element) error
 
 func (s *Selection) forEachElement(actions actionsFunc) error {
-	elements, err := s.elements.GetAtLeastOne()
-	if err != nil {
-		return fmt.Errorf("failed to select elements from %s: %s", s, err)
-	}
+	// elements that the action succeeded on are skipped when it is retried
+	completed := 0
+	return s.retryStale(func() error {
+		elements, err := s.elements.GetAtLeastOne()
+		if err != nil {
+			return fmt.Errorf("failed to select elements from %s: %w", s, err)
+		}
+
+		for ; completed < len(elements); completed++ {
+			if err := actions(elements[completed]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
 
-	for _, element := range elements {
-		if err := actions(element); err != nil {
-			return err
+func (s *Selection) forExactlyOne(action actionsFunc) error {
+	return s.retryStale(func() error {
+		selectedElement, err := s.elements.GetExactlyOne()
+		if err != nil {
+			return fmt.Errorf("failed to select element from %s: %w", s, err)
 		}
+		return action(selectedElement)
+	})
+}
+
+// retryStale re-selects the elements of the selection by calling attempt
+// again when it fails due to a stale element, up to the configured limit.
+func (s *Selection) retryStale(attempt func() error) error {
+	err := attempt()
+	for retries := 0; retries < s.staleRetries && api.IsStale(err); retries++ {
+		err = attempt()
 	}
-	return nil
+	return err
 }
 
 // Click clicks on all of the elements that the selection refers to.
 func (s *Selection) Click() error {
 	return s.forEachElement(func(selectedElement element.Element) error {
 		if err := selectedElement.Click(); err != nil {
-			return fmt.Errorf("failed to click on %s: %s", s, err)
+			return fmt.Errorf("failed to click on %s: %w", s, err)
 		}
 		return nil
 	})
@@ -39,10 +63,10 @@ func (s *Selection) Click() error {
 func (s *Selection) DoubleClick() error {
 	return s.forEachElement(func(selectedElement element.Element) error {
 		if err := s.session.MoveTo(selectedElement.(*api.Element), nil); err != nil {
-			return fmt.Errorf("failed to move mouse to %s: %s", s, err)
+			return fmt.Errorf("failed to move mouse to %s: %w", s, err)
 		}
 		if err := s.session.DoubleClick(); err != nil {
-			return fmt.Errorf("failed to double-click on %s: %s", s, err)
+			return fmt.Errorf("failed to double-click on %s: %w", s, err)
 		}
 		return nil
 	})
@@ -52,7 +76,7 @@ func (s *Selection) DoubleClick() error {
 func (s *Selection) Clear() error {
         return s.forEachElement(func(selectedElement element.Element) error {
                 if err := selectedElement.Clear(); err != nil {
-                        return fmt.Errorf("failed to clear %s: %s", s, err)
+                        return fmt.Errorf("failed to clear %s: %w", s, err)
                 }
                 return nil
         })
@@ -62,10 +86,10 @@ func (s *Selection) Clear() error {
 func (s *Selection) Fill(text string) error {
 	return s.forEachElement(func(selectedElement element.Element) error {
 		if err := selectedElement.Clear(); err != nil {
-			return fmt.Errorf("failed to clear %s: %s", s, err)
+			return fmt.Errorf("failed to clear %s: %w", s, err)
 		}
 		if err := selectedElement.Value(text); err != nil {
-			return fmt.Errorf("failed to enter text into %s: %s", s, err)
+			return fmt.Errorf("failed to enter text into %s: %w", s, err)
 		}
 		return nil
 	})
@@ -77,25 +101,25 @@ func (s *Selection) Fill(text string) error {
 func (s *Selection) UploadFile(filename string) error {
 	absFilePath, err := filepath.Abs(filename)
 	if err != nil {
-		return fmt.Errorf("failed to find absolute path for filename: %s", err)
+		return fmt.Errorf("failed to find absolute path for filename: %w", err)
 	}
 	return s.forEachElement(func(selectedElement element.Element) error {
 		tagName, err := selectedElement.GetName()
 		if err != nil {
-			return fmt.Errorf("failed to determine tag name of %s: %s", s, err)
+			return fmt.Errorf("failed to determine tag name of %s: %w", s, err)
 		}
 		if tagName != "input" {
 			return fmt.Errorf("element for %s is not an input element", s)
 		}
 		inputType, err := selectedElement.GetAttribute("type")
 		if err != nil {
-			return fmt.Errorf("failed to determine type attribute of %s: %s", s, err)
+			return fmt.Errorf("failed to determine type attribute of %s: %w", s, err)
 		}
 		if inputType != "file" {
 			return fmt.Errorf("element for %s is not a file uploader", s)
 		}
 		if err := selectedElement.Value(absFilePath); err != nil {
-			return fmt.Errorf("failed to enter text into %s: %s", s, err)
+			return fmt.Errorf("failed to enter text into %s: %w", s, err)
 		}
 		return nil
 	})
@@ -115,7 +139,7 @@ func (s *Selection) setChecked(checked bool) error {
 	return s.forEachElement(func(selectedElement element.Element) error {
 		elementType, err := selectedElement.GetAttribute("type")
 		if err != nil {
-			return fmt.Errorf("failed to retrieve type attribute of %s: %s", s, err)
+			return fmt.Errorf("failed to retrieve type attribute of %s: %w", s, err)
 		}
 
 		if elementType != "checkbox" {
@@ -124,12 +148,12 @@ func (s *Selection) setChecked(checked bool) error {
 
 		elementChecked, err := selectedElement.IsSelected()
 		if err != nil {
-			return fmt.Errorf("failed to retrieve state of %s: %s", s, err)
+			return fmt.Errorf("failed to retrieve state of %s: %w", s, err)
 		}
 
 		if elementChecked != checked {
 			if err := selectedElement.Click(); err != nil {
-				return fmt.Errorf("failed to click on %s: %s", s, err)
+				return fmt.Errorf("failed to click on %s: %w", s, err)
 			}
 		}
 		return nil
@@ -144,7 +168,7 @@ func (s *Selection) Select(text string) error {
 		optionToSelect := target.Selector{Type: target.XPath, Value: optionXPath}
 		options, err := selectedElement.GetElements(optionToSelect.API())
 		if err != nil {
-			return fmt.Errorf("failed to select specified option for %s: %s", s, err)
+			return fmt.Errorf("failed to select specified option for %s: %w", s, err)
 		}
 
 		if len(options) == 0 {
@@ -153,7 +177,7 @@ func (s *Selection) Select(text string) error {
 
 		for _, option := range options {
 			if err := option.Click(); err != nil {
-				return fmt.Errorf(`failed to click on option with text "%s" for %s: %s`, text, s, err)
+				return fmt.Errorf(`failed to click on option with text "%s" for %s: %w`, text, s, err)
 			}
 		}
 		return nil
@@ -165,7 +189,7 @@ func (s *Selection) Select(text string) error {
 func (s *Selection) Submit() error {
 	return s.forEachElement(func(selectedElement element.Element) error {
 		if err := selectedElement.Submit(); err != nil {
-			return fmt.Errorf("failed to submit %s: %s", s, err)
+			return fmt.Errorf("failed to submit %s: %w", s, err)
 		}
 		return nil
 	})
@@ -187,7 +211,7 @@ func (s *Selection) Tap(event Tap) error {
 
 	return s.forEachElement(func(selectedElement element.Element) error {
 		if err := touchFunc(selectedElement.(*api.Element)); err != nil {
-			return fmt.Errorf("failed to %s on %s: %s", event, s, err)
+			return fmt.Errorf("failed to %s on %s: %w", event, s, err)
 		}
 		return nil
 	})
@@ -211,10 +235,10 @@ func (s *Selection) Touch(event Touch) error {
 	return s.forEachElement(func(selectedElement element.Element) error {
 		x, y, err := selectedElement.GetLocation()
 		if err != nil {
-			return fmt.Errorf("failed to retrieve location of %s: %s", s, err)
+			return fmt.Errorf("failed to retrieve location of %s: %w", s, err)
 		}
 		if err := touchFunc(x, y); err != nil {
-			return fmt.Errorf("failed to flick finger on %s: %s", s, err)
+			return fmt.Errorf("failed to flick finger on %s: %w", s, err)
 		}
 		return nil
 	})
@@ -223,35 +247,29 @@ func (s *Selection) Touch(event Touch) error {
 // FlickFinger performs a flick touch action by the provided offset and at the
 // provided speed on exactly one element.
 func (s *Selection) FlickFinger(xOffset, yOffset int, speed uint) error {
-	selectedElement, err := s.elements.GetExactlyOne()
-	if err != nil {
-		return fmt.Errorf("failed to select element from %s: %s", s, err)
-	}
-
-	if err := s.session.TouchFlick(selectedElement.(*api.Element), api.XYOffset{X: xOffset, Y: yOffset}, api.ScalarSpeed(speed)); err != nil {
-		return fmt.Errorf("failed to flick finger on %s: %s", s, err)
-	}
-	return nil
+	return s.forExactlyOne(func(selectedElement element.Element) error {
+		if err := s.session.TouchFlick(selectedElement.(*api.Element), api.XYOffset{X: xOffset, Y: yOffset}, api.ScalarSpeed(speed)); err != nil {
+			return fmt.Errorf("failed to flick finger on %s: %w", s, err)
+		}
+		return nil
+	})
 }
 
 // ScrollFinger performs a scroll touch action by the provided offset on exactly
 // one element.
 func (s *Selection) ScrollFinger(xOffset, yOffset int) error {
-	selectedElement, err := s.elements.GetExactlyOne()
-	if err != nil {
-		return fmt.Errorf("failed to select element from %s: %s", s, err)
-	}
-
-	if err := s.session.TouchScroll(selectedElement.(*api.Element), api.XYOffset{X: xOffset, Y: yOffset}); err != nil {
-		return fmt.Errorf("failed to scroll finger on %s: %s", s, err)
-	}
-	return nil
+	return s.forExactlyOne(func(selectedElement element.Element) error {
+		if err := s.session.TouchScroll(selectedElement.(*api.Element), api.XYOffset{X: xOffset, Y: yOffset}); err != nil {
+			return fmt.Errorf("failed to scroll finger on %s: %w", s, err)
+		}
+		return nil
+	})
 }
 
 func (s *Selection) SendKeys(key string) error {
 	return s.forEachElement(func(selectedElement element.Element) error {
 		if err := selectedElement.Value(key); err != nil {
-			return fmt.Errorf("failed to send key %s on %s: %s", key, s, err)
+			return fmt.Errorf("failed to send key %s on %s: %w", key, s, err)
 		}
 		return nil
 	})
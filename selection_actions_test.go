@@ -2,6 +2,8 @@ package agouti_test
 
 import (
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
@@ -51,6 +53,15 @@ var _ = Describe("Selection Actions", func() {
 				Expect(selection.Click()).To(MatchError("failed to click on selection 'CSS: #selector': some error"))
 			})
 		})
+
+		Context("when a click fails with a WebDriver error", func() {
+			It("should preserve the error for inspection", func() {
+				secondElement.ClickCall.Err = &api.Error{Code: "stale element reference", Message: "some error"}
+				err := selection.Click()
+				Expect(err).To(MatchError("failed to click on selection 'CSS: #selector': request unsuccessful: some error"))
+				Expect(api.IsStale(err)).To(BeTrue())
+			})
+		})
 	})
 
 	// TODO: extend mock to test multiple calls
@@ -537,3 +548,92 @@ var _ = Describe("Selection Actions", func() {
 		})
 	})
 })
+
+type staleElement struct {
+	mocks.Element
+	staleClicks int
+	clicks      int
+}
+
+func (e *staleElement) Click() error {
+	e.clicks++
+	if e.clicks <= e.staleClicks {
+		return &api.Error{Code: "stale element reference", Message: "stale"}
+	}
+	return nil
+}
+
+var _ = Describe("Selection stale element recovery", func() {
+	var (
+		selection         *MultiSelection
+		elementRepository *mocks.ElementRepository
+		selectedElement   *staleElement
+	)
+
+	BeforeEach(func() {
+		selectedElement = &staleElement{staleClicks: 2}
+		elementRepository = &mocks.ElementRepository{}
+		elementRepository.GetAtLeastOneCall.ReturnElements = []element.Element{selectedElement}
+		selection = NewTestMultiSelection(&mocks.Session{}, elementRepository, "#selector")
+	})
+
+	It("should not retry by default", func() {
+		err := selection.Click()
+		Expect(api.IsStale(err)).To(BeTrue())
+		Expect(selectedElement.clicks).To(Equal(1))
+	})
+
+	It("should re-select the elements and retry when an element is stale", func() {
+		SetTestStaleRetries(&selection.Selection, 2)
+		Expect(selection.Click()).To(Succeed())
+		Expect(selectedElement.clicks).To(Equal(3))
+	})
+
+	It("should only retry the action on elements that it did not already succeed on", func() {
+		SetTestStaleRetries(&selection.Selection, 2)
+		firstElement := &staleElement{}
+		elementRepository.GetAtLeastOneCall.ReturnElements = []element.Element{firstElement, selectedElement}
+		Expect(selection.Click()).To(Succeed())
+		Expect(firstElement.clicks).To(Equal(1))
+		Expect(selectedElement.clicks).To(Equal(3))
+	})
+
+	It("should return the stale element error when the retries are exhausted", func() {
+		SetTestStaleRetries(&selection.Selection, 1)
+		err := selection.Click()
+		Expect(err).To(MatchError("failed to click on selection 'CSS: #selector': request unsuccessful: stale"))
+		Expect(selectedElement.clicks).To(Equal(2))
+	})
+
+	It("should not retry errors other than stale element errors", func() {
+		SetTestStaleRetries(&selection.Selection, 2)
+		elementRepository.GetExactlyOneCall.Err = errors.New("some error")
+		_, err := selection.Text()
+		Expect(err).To(MatchError("failed to select element from selection 'CSS: #selector': some error"))
+	})
+
+	It("should apply the StaleRetries Option to selections created from a page", func() {
+		var lookups, clicks int
+		server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			switch request.URL.Path {
+			case "/session/some-id/elements":
+				lookups++
+				response.Write([]byte(`{"value": [{"ELEMENT": "some-element"}]}`))
+			case "/session/some-id/element/some-element/click":
+				clicks++
+				if clicks == 1 {
+					response.WriteHeader(404)
+					response.Write([]byte(`{"value": {"error": "stale element reference", "message": "stale"}}`))
+					return
+				}
+				response.Write([]byte(`{}`))
+			}
+		}))
+		defer server.Close()
+
+		page := JoinPage(server.URL+"/session/some-id", StaleRetries(1))
+		Expect(page.All("a").Click()).To(Succeed())
+		Expect(clicks).To(Equal(2))
+		Expect(lookups).To(Equal(2))
+	})
+})
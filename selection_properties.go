@@ -8,51 +8,50 @@ import (
 
 // Text returns the entirety of the text content for exactly one element.
 func (s *Selection) Text() (string, error) {
-	selectedElement, err := s.elements.GetExactlyOne()
-	if err != nil {
-		return "", fmt.Errorf("failed to select element from %s: %s", s, err)
-	}
-
-	text, err := selectedElement.GetText()
-	if err != nil {
-		return "", fmt.Errorf("failed to retrieve text for %s: %s", s, err)
-	}
-	return text, nil
+	var text string
+	err := s.forExactlyOne(func(selectedElement element.Element) error {
+		elementText, err := selectedElement.GetText()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve text for %s: %w", s, err)
+		}
+		text = elementText
+		return nil
+	})
+	return text, err
 }
 
 // Active returns true if the single element that the selection refers to is active.
 func (s *Selection) Active() (bool, error) {
-	selectedElement, err := s.elements.GetExactlyOne()
-	if err != nil {
-		return false, fmt.Errorf("failed to select element from %s: %s", s, err)
-	}
-
-	activeElement, err := s.session.GetActiveElement()
-	if err != nil {
-		return false, fmt.Errorf("failed to retrieve active element: %s", err)
-	}
-
-	equal, err := selectedElement.IsEqualTo(activeElement)
-	if err != nil {
-		return false, fmt.Errorf("failed to compare selection to active element: %s", err)
-	}
+	var active bool
+	err := s.forExactlyOne(func(selectedElement element.Element) error {
+		activeElement, err := s.session.GetActiveElement()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve active element: %w", err)
+		}
 
-	return equal, nil
+		equal, err := selectedElement.IsEqualTo(activeElement)
+		if err != nil {
+			return fmt.Errorf("failed to compare selection to active element: %w", err)
+		}
+		active = equal
+		return nil
+	})
+	return active, err
 }
 
 type propertyMethod func(element element.Element, property string) (string, error)
 
 func (s *Selection) hasProperty(method propertyMethod, property, name string) (string, error) {
-	selectedElement, err := s.elements.GetExactlyOne()
-	if err != nil {
-		return "", fmt.Errorf("failed to select element from %s: %s", s, err)
-	}
-
-	value, err := method(selectedElement, property)
-	if err != nil {
-		return "", fmt.Errorf("failed to retrieve %s value for %s: %s", name, s, err)
-	}
-	return value, nil
+	var value string
+	err := s.forExactlyOne(func(selectedElement element.Element) error {
+		propertyValue, err := method(selectedElement, property)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve %s value for %s: %w", name, s, err)
+		}
+		value = propertyValue
+		return nil
+	})
+	return value, err
 }
 
 // Attribute returns an attribute value for exactly one element.
@@ -68,22 +67,28 @@ func (s *Selection) CSS(property string) (string, error) {
 type stateMethod func(element element.Element) (bool, error)
 
 func (s *Selection) hasState(method stateMethod, name string) (bool, error) {
-	elements, err := s.elements.GetAtLeastOne()
-	if err != nil {
-		return false, fmt.Errorf("failed to select elements from %s: %s", s, err)
-	}
-
-	for _, selectedElement := range elements {
-		pass, err := method(selectedElement)
+	var pass bool
+	err := s.retryStale(func() error {
+		elements, err := s.elements.GetAtLeastOne()
 		if err != nil {
-			return false, fmt.Errorf("failed to determine whether %s is %s: %s", s, name, err)
+			return fmt.Errorf("failed to select elements from %s: %w", s, err)
 		}
-		if !pass {
-			return false, nil
+
+		for _, selectedElement := range elements {
+			pass, err = method(selectedElement)
+			if err != nil {
+				return fmt.Errorf("failed to determine whether %s is %s: %w", s, name, err)
+			}
+			if !pass {
+				return nil
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return false, err
 	}
-
-	return true, nil
+	return pass, nil
 }
 
 // Selected returns true if all of the elements that the selection refers to are selected.
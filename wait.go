@@ -0,0 +1,181 @@
+package agouti
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/internal/element"
+)
+
+// DefaultWaitInterval is the polling interval used by WaitFor, WaitForURL,
+// and WaitForTitle when a non-positive interval is provided.
+const DefaultWaitInterval = 100 * time.Millisecond
+
+// A Condition is a state that a selection may be waited for using
+// *Selection.WaitFor. Predefined conditions include Visible, Hidden,
+// Enabled, TextContains, CountEquals, AttributeEquals, and Stale.
+type Condition interface {
+	// String describes the condition, ex. "be visible"
+	String() string
+
+	// Check returns whether the selection meets the condition, along with
+	// the last observed value used in timeout errors.
+	Check(selection *Selection) (met bool, observed interface{}, err error)
+}
+
+type condition struct {
+	description string
+	check       func(selection *Selection) (bool, interface{}, error)
+}
+
+func (c *condition) String() string {
+	return c.description
+}
+
+func (c *condition) Check(selection *Selection) (bool, interface{}, error) {
+	return c.check(selection)
+}
+
+// Visible is met when all of the elements that the selection refers to are visible.
+func Visible() Condition {
+	return &condition{"be visible", func(selection *Selection) (bool, interface{}, error) {
+		visible, err := selection.Visible()
+		return visible, visible, err
+	}}
+}
+
+// Hidden is met when none of the elements that the selection refers to are
+// visible, including when the selection does not refer to any elements.
+func Hidden() Condition {
+	return &condition{"be hidden", func(selection *Selection) (bool, interface{}, error) {
+		elements, err := selection.elements.Get()
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to select elements from %s: %w", selection, err)
+		}
+		visible := 0
+		for _, selectedElement := range elements {
+			displayed, err := selectedElement.IsDisplayed()
+			if err != nil && !api.IsStale(err) {
+				return false, nil, fmt.Errorf("failed to determine whether %s is visible: %w", selection, err)
+			}
+			if displayed {
+				visible++
+			}
+		}
+		return visible == 0, fmt.Sprintf("%d visible", visible), nil
+	}}
+}
+
+// Enabled is met when all of the elements that the selection refers to are enabled.
+func Enabled() Condition {
+	return &condition{"be enabled", func(selection *Selection) (bool, interface{}, error) {
+		enabled, err := selection.Enabled()
+		return enabled, enabled, err
+	}}
+}
+
+// TextContains is met when the text of exactly one element contains the provided text.
+func TextContains(text string) Condition {
+	return &condition{fmt.Sprintf("have text containing %q", text), func(selection *Selection) (bool, interface{}, error) {
+		actualText, err := selection.Text()
+		return strings.Contains(actualText, text), fmt.Sprintf("%q", actualText), err
+	}}
+}
+
+// CountEquals is met when the selection refers to exactly count elements.
+func CountEquals(count int) Condition {
+	return &condition{fmt.Sprintf("have count %d", count), func(selection *Selection) (bool, interface{}, error) {
+		actualCount, err := selection.Count()
+		return actualCount == count, actualCount, err
+	}}
+}
+
+// AttributeEquals is met when the provided attribute of exactly one element
+// is equal to the provided value.
+func AttributeEquals(attribute, value string) Condition {
+	description := fmt.Sprintf("have attribute %q equal to %q", attribute, value)
+	return &condition{description, func(selection *Selection) (bool, interface{}, error) {
+		actualValue, err := selection.Attribute(attribute)
+		return actualValue == value, fmt.Sprintf("%q", actualValue), err
+	}}
+}
+
+// Stale is met when all of the elements that the selection referred to when the
+// condition was first checked are no longer attached to the page, such as after
+// the page navigates. Each Condition returned by Stale should only be used once.
+func Stale() Condition {
+	var captured []element.Element
+	return &condition{"become stale", func(selection *Selection) (bool, interface{}, error) {
+		if captured == nil {
+			elements, err := selection.elements.GetAtLeastOne()
+			if err != nil {
+				return false, nil, fmt.Errorf("failed to select elements from %s: %w", selection, err)
+			}
+			captured = elements
+		}
+		attached := 0
+		for _, capturedElement := range captured {
+			if _, err := capturedElement.IsEnabled(); !api.IsStale(err) {
+				attached++
+			}
+		}
+		return attached == 0, fmt.Sprintf("%d attached", attached), nil
+	}}
+}
+
+// WaitFor polls the selection every interval until the provided condition is
+// met or the timeout elapses. Errors encountered while checking the condition
+// are retried. When the timeout elapses, the returned error describes the
+// selection, the condition, and the last observed value or error.
+func (s *Selection) WaitFor(condition Condition, timeout, interval time.Duration) error {
+	return wait(s.String(), condition.String(), timeout, interval, func() (bool, interface{}, error) {
+		return condition.Check(s)
+	})
+}
+
+// WaitForURL polls the page every interval until its URL is equal to the
+// provided URL or the timeout elapses.
+func (p *Page) WaitForURL(url string, timeout, interval time.Duration) error {
+	return wait(p.String(), fmt.Sprintf("have URL %q", url), timeout, interval, func() (bool, interface{}, error) {
+		actualURL, err := p.URL()
+		return actualURL == url, fmt.Sprintf("%q", actualURL), err
+	})
+}
+
+// WaitForTitle polls the page every interval until its title is equal to the
+// provided title or the timeout elapses.
+func (p *Page) WaitForTitle(title string, timeout, interval time.Duration) error {
+	return wait(p.String(), fmt.Sprintf("have title %q", title), timeout, interval, func() (bool, interface{}, error) {
+		actualTitle, err := p.Title()
+		return actualTitle == title, fmt.Sprintf("%q", actualTitle), err
+	})
+}
+
+func wait(subject, description string, timeout, interval time.Duration, check func() (bool, interface{}, error)) error {
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		met, observed, err := check()
+		if met && err == nil {
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			if err != nil {
+				return fmt.Errorf("timed out after %s waiting for %s to %s: %w", timeout, subject, description, err)
+			}
+			return fmt.Errorf("timed out after %s waiting for %s to %s, last observed: %v", timeout, subject, description, observed)
+		}
+
+		sleep := interval
+		if remaining := time.Until(deadline); remaining < sleep {
+			sleep = remaining
+		}
+		time.Sleep(sleep)
+	}
+}
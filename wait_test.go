@@ -0,0 +1,220 @@
+package agouti_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/sclevine/agouti"
+	"github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/internal/element"
+	"github.com/sclevine/agouti/internal/mocks"
+)
+
+var _ = Describe("Waits", func() {
+	var (
+		selection         *MultiSelection
+		session           *mocks.Session
+		elementRepository *mocks.ElementRepository
+		firstElement      *mocks.Element
+		secondElement     *mocks.Element
+	)
+
+	BeforeEach(func() {
+		session = &mocks.Session{}
+		firstElement = &mocks.Element{}
+		secondElement = &mocks.Element{}
+		elementRepository = &mocks.ElementRepository{}
+		selection = NewTestMultiSelection(session, elementRepository, "#selector")
+	})
+
+	Describe("#WaitFor", func() {
+		BeforeEach(func() {
+			elementRepository.GetAtLeastOneCall.ReturnElements = []element.Element{firstElement, secondElement}
+		})
+
+		It("should return immediately when the condition is already met", func() {
+			firstElement.IsDisplayedCall.ReturnDisplayed = true
+			secondElement.IsDisplayedCall.ReturnDisplayed = true
+			start := time.Now()
+			Expect(selection.WaitFor(Visible(), time.Second, 10*time.Millisecond)).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically("<", 100*time.Millisecond))
+		})
+
+		It("should poll until the condition is met", func() {
+			firstElement.IsDisplayedCall.ReturnDisplayed = true
+			secondElement.IsDisplayedCall.ReturnDisplayed = true
+			secondElement.IsDisplayedCall.ReturnAfter = 3
+			Expect(selection.WaitFor(Visible(), time.Second, 10*time.Millisecond)).To(Succeed())
+			Expect(secondElement.IsDisplayedCall.Calls).To(Equal(4))
+		})
+
+		Context("when the condition is not met before the timeout", func() {
+			It("should return an error with the selection and last observed value", func() {
+				start := time.Now()
+				err := selection.WaitFor(Visible(), 50*time.Millisecond, 10*time.Millisecond)
+				Expect(err).To(MatchError("timed out after 50ms waiting for selection 'CSS: #selector' to be visible, last observed: false"))
+				Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+			})
+		})
+
+		Context("when checking the condition fails until the timeout", func() {
+			It("should return an error wrapping the last error", func() {
+				elementRepository.GetAtLeastOneCall.Err = &api.Error{Code: "no such element", Message: "some error"}
+				err := selection.WaitFor(Enabled(), 20*time.Millisecond, 0)
+				Expect(err).To(MatchError("timed out after 20ms waiting for selection 'CSS: #selector' to be enabled: " +
+					"failed to select elements from selection 'CSS: #selector': request unsuccessful: some error"))
+				Expect(api.IsNoSuchElement(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("conditions", func() {
+		check := func(condition Condition) (bool, interface{}, error) {
+			return condition.Check(&selection.Selection)
+		}
+
+		isMet := func(condition Condition) bool {
+			met, _, err := check(condition)
+			Expect(err).NotTo(HaveOccurred())
+			return met
+		}
+
+		Describe("Visible", func() {
+			It("should be met when all elements are visible", func() {
+				elementRepository.GetAtLeastOneCall.ReturnElements = []element.Element{firstElement}
+				firstElement.IsDisplayedCall.ReturnDisplayed = true
+				Expect(Visible().String()).To(Equal("be visible"))
+				Expect(isMet(Visible())).To(BeTrue())
+			})
+		})
+
+		Describe("Hidden", func() {
+			It("should be met when no elements are visible", func() {
+				elementRepository.GetCall.ReturnElements = []element.Element{firstElement, secondElement}
+				met, observed, err := check(Hidden())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(met).To(BeTrue())
+				Expect(observed).To(Equal("0 visible"))
+			})
+
+			It("should not be met when any element is visible", func() {
+				elementRepository.GetCall.ReturnElements = []element.Element{firstElement, secondElement}
+				secondElement.IsDisplayedCall.ReturnDisplayed = true
+				met, observed, _ := check(Hidden())
+				Expect(met).To(BeFalse())
+				Expect(observed).To(Equal("1 visible"))
+			})
+
+			It("should be met when there are no elements", func() {
+				Expect(isMet(Hidden())).To(BeTrue())
+			})
+
+			It("should treat stale elements as hidden", func() {
+				elementRepository.GetCall.ReturnElements = []element.Element{firstElement}
+				firstElement.IsDisplayedCall.Err = &api.Error{Code: "stale element reference"}
+				Expect(isMet(Hidden())).To(BeTrue())
+			})
+		})
+
+		Describe("Enabled", func() {
+			It("should be met when all elements are enabled", func() {
+				elementRepository.GetAtLeastOneCall.ReturnElements = []element.Element{firstElement}
+				firstElement.IsEnabledCall.ReturnEnabled = true
+				Expect(isMet(Enabled())).To(BeTrue())
+			})
+		})
+
+		Describe("TextContains", func() {
+			It("should be met when the text contains the provided text", func() {
+				elementRepository.GetExactlyOneCall.ReturnElement = firstElement
+				firstElement.GetTextCall.ReturnText = "some text"
+				Expect(TextContains("me te").String()).To(Equal(`have text containing "me te"`))
+				Expect(isMet(TextContains("me te"))).To(BeTrue())
+				met, observed, _ := check(TextContains("other"))
+				Expect(met).To(BeFalse())
+				Expect(observed).To(Equal(`"some text"`))
+			})
+		})
+
+		Describe("CountEquals", func() {
+			It("should be met when the selection refers to the provided number of elements", func() {
+				elementRepository.GetCall.ReturnElements = []element.Element{firstElement, secondElement}
+				Expect(CountEquals(2).String()).To(Equal("have count 2"))
+				Expect(isMet(CountEquals(2))).To(BeTrue())
+				met, observed, _ := check(CountEquals(3))
+				Expect(met).To(BeFalse())
+				Expect(observed).To(Equal(2))
+			})
+		})
+
+		Describe("AttributeEquals", func() {
+			It("should be met when the attribute is equal to the provided value", func() {
+				elementRepository.GetExactlyOneCall.ReturnElement = firstElement
+				firstElement.GetAttributeCall.ReturnValue = "some value"
+				Expect(AttributeEquals("some-attribute", "some value").String()).To(Equal(`have attribute "some-attribute" equal to "some value"`))
+				Expect(isMet(AttributeEquals("some-attribute", "some value"))).To(BeTrue())
+				Expect(firstElement.GetAttributeCall.Attribute).To(Equal("some-attribute"))
+			})
+		})
+
+		Describe("Stale", func() {
+			It("should be met once the originally selected elements are detached", func() {
+				elementRepository.GetAtLeastOneCall.ReturnElements = []element.Element{firstElement}
+				condition := Stale()
+				met, observed, err := check(condition)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(met).To(BeFalse())
+				Expect(observed).To(Equal("1 attached"))
+
+				elementRepository.GetAtLeastOneCall.ReturnElements = []element.Element{secondElement}
+				firstElement.IsEnabledCall.Err = &api.Error{Code: "stale element reference"}
+				Expect(isMet(condition)).To(BeTrue())
+			})
+
+			It("should return an error when the selection cannot be found initially", func() {
+				elementRepository.GetAtLeastOneCall.Err = errors.New("some error")
+				_, _, err := check(Stale())
+				Expect(err).To(MatchError("failed to select elements from selection 'CSS: #selector': some error"))
+			})
+		})
+	})
+
+	Describe("#WaitForURL", func() {
+		It("should poll until the page has the provided URL", func() {
+			page := NewTestPage(session)
+			session.GetURLCall.PreviousURL = "http://example.com/old"
+			session.GetURLCall.ReturnURL = "http://example.com/new"
+			session.GetURLCall.ReturnAfter = 3
+			Expect(page.WaitForURL("http://example.com/new", time.Second, 10*time.Millisecond)).To(Succeed())
+			Expect(session.GetURLCall.Calls).To(Equal(4))
+		})
+
+		Context("when the URL does not match before the timeout", func() {
+			It("should return an error with the last observed URL", func() {
+				page := NewTestPage(session)
+				session.GetURLCall.ReturnURL = "http://example.com/old"
+				err := page.WaitForURL("http://example.com/new", 20*time.Millisecond, 10*time.Millisecond)
+				Expect(err).To(MatchError(`timed out after 20ms waiting for page to have URL "http://example.com/new", last observed: "http://example.com/old"`))
+			})
+		})
+	})
+
+	Describe("#WaitForTitle", func() {
+		It("should succeed when the page has the provided title", func() {
+			page := NewTestPage(session)
+			session.GetTitleCall.ReturnTitle = "some title"
+			Expect(page.WaitForTitle("some title", time.Second, 10*time.Millisecond)).To(Succeed())
+		})
+
+		Context("when retrieving the title fails until the timeout", func() {
+			It("should return an error wrapping the last error", func() {
+				page := NewTestPage(session)
+				session.GetTitleCall.Err = errors.New("some error")
+				err := page.WaitForTitle("some title", 20*time.Millisecond, 10*time.Millisecond)
+				Expect(err).To(MatchError(`timed out after 20ms waiting for page to have title "some title": failed to retrieve page title: some error`))
+			})
+		})
+	})
+})
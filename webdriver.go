@@ -1,9 +1,14 @@
 package agouti
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"sync"
 
 	"github.com/sclevine/agouti/api"
+	"github.com/sclevine/agouti/proxy"
 )
 
 // A WebDriver controls a WebDriver process. This struct embeds api.WebDriver,
@@ -11,6 +16,10 @@ import (
 type WebDriver struct {
 	*api.WebDriver
 	defaultOptions *config
+	versionCheck   *versionCheck
+
+	proxiesMutex sync.Mutex
+	proxies      []*proxy.Proxy
 }
 
 // NewWebDriver returns an instance of a WebDriver specified by
@@ -25,6 +34,26 @@ type WebDriver struct {
 // The HTTPClient Option specifies a *http.Client to use for all WebDriver
 // communications. The default client is http.DefaultClient.
 //
+// The DriverAddress Option restricts the address that the web service is
+// started on. If the web service exits before it becomes available (ex.
+// because another process took its port), it is started again on a new port
+// up to the number of times specified by the StartRetries Option.
+//
+// The DriverOutput and DriverLogDir Options capture the output of the
+// WebDriver process. The last lines of output are included in errors returned
+// when the process fails to start or a new page fails to open.
+//
+// The WebDriver process is supervised while the WebDriver is started: pages
+// return errors describing how the process exited if it crashes, and the
+// RestartOnCrash Option restarts it. See Done, Err, and Healthy.
+//
+// The SessionIdleTimeout Option deletes sessions that go unused for the
+// provided duration. Sessions opened by the WebDriver are listed by Sessions,
+// and sessions of destroyed pages are not listed.
+//
+// The InterceptNetwork Option starts a proxy for each new page that the page
+// is configured to use.
+//
 // Any other provided Options are treated as default Options for new pages.
 //
 // Valid template parameters are:
@@ -36,12 +65,85 @@ type WebDriver struct {
 //   command := []string{"java", "-jar", "selenium-server.jar", "-port", "{{.Port}}"}
 //   agouti.NewWebDriver("http://{{.Address}}/wd/hub", command)
 func NewWebDriver(url string, command []string, options ...Option) *WebDriver {
-	apiWebDriver := api.NewWebDriver(url, command)
-	defaultOptions := config{Timeout: apiWebDriver.Timeout}.Merge(options)
+	return newWebDriver(api.NewWebDriver(url, command), options)
+}
+
+// NewRemoteWebDriver returns an instance of a WebDriver for a WebDriver
+// service that is already running at the provided URL, such as a Selenium
+// Grid hub. No process is started or stopped, but Start must still be called
+// to wait for the service to become available, and Stop must be called to
+// delete the sessions of pages that were not destroyed.
+//
+// The BasicAuth and Header Options specify credentials and headers that are
+// sent with every request to the service. All other Options behave as they
+// do for NewWebDriver. For example:
+//    driver := agouti.NewRemoteWebDriver("https://grid.example.com/wd/hub",
+//        agouti.BasicAuth("user", "secret"), agouti.Browser("firefox"))
+func NewRemoteWebDriver(url string, options ...Option) *WebDriver {
+	return newWebDriver(api.NewRemoteWebDriver(url), options)
+}
+
+func newWebDriver(apiWebDriver *api.WebDriver, options []Option) *WebDriver {
+	defaultOptions := config{
+		Timeout:      apiWebDriver.Timeout,
+		StartRetries: apiWebDriver.StartRetries,
+	}.Merge(options)
 	apiWebDriver.Timeout = defaultOptions.Timeout
+	apiWebDriver.StartRetries = defaultOptions.StartRetries
+	apiWebDriver.Host = defaultOptions.DriverHost
+	apiWebDriver.MinPort = defaultOptions.DriverMinPort
+	apiWebDriver.MaxPort = defaultOptions.DriverMaxPort
 	apiWebDriver.Debug = defaultOptions.Debug
-	apiWebDriver.HTTPClient = defaultOptions.HTTPClient
-	return &WebDriver{apiWebDriver, defaultOptions}
+	apiWebDriver.HTTPClient = withHeaders(defaultOptions.HTTPClient, defaultOptions.Headers)
+	apiWebDriver.IdleTimeout = defaultOptions.SessionIdleTimeout
+	apiWebDriver.Stdout = defaultOptions.DriverStdout
+	apiWebDriver.Stderr = defaultOptions.DriverStderr
+	apiWebDriver.LogDir = defaultOptions.DriverLogDir
+	apiWebDriver.RestartOnCrash = defaultOptions.RestartOnCrash
+	return &WebDriver{WebDriver: apiWebDriver, defaultOptions: defaultOptions}
+}
+
+// Start starts the WebDriver process.
+func (w *WebDriver) Start() error {
+	return w.start(w.WebDriver.Start)
+}
+
+// StartContext is like Start, but stops waiting for the WebDriver process to
+// boot when the provided context is canceled or exceeds its deadline.
+func (w *WebDriver) StartContext(ctx context.Context) error {
+	return w.start(func() error {
+		return w.WebDriver.StartContext(ctx)
+	})
+}
+
+func (w *WebDriver) start(startWebDriver func() error) error {
+	if w.versionCheck != nil {
+		warnings := w.defaultOptions.Warnings
+		if warnings == nil {
+			warnings = os.Stderr
+		}
+		if warning := w.versionCheck.warning(); warning != "" {
+			fmt.Fprintln(warnings, "WARNING: "+warning)
+		}
+	}
+	return startWebDriver()
+}
+
+// Stop stops the WebDriver process, along with the proxies of pages that were
+// not destroyed if the InterceptNetwork Option was provided.
+func (w *WebDriver) Stop() error {
+	err := w.WebDriver.Stop()
+
+	w.proxiesMutex.Lock()
+	proxies := w.proxies
+	w.proxies = nil
+	w.proxiesMutex.Unlock()
+	for _, networkProxy := range proxies {
+		if proxyErr := stopProxy(networkProxy); proxyErr != nil && err == nil {
+			err = proxyErr
+		}
+	}
+	return err
 }
 
 // NewPage returns a *Page that corresponds to a new WebDriver session.
@@ -57,12 +159,79 @@ func NewWebDriver(url string, command []string, options ...Option) *WebDriver {
 // The HTTPClient Option will be ignored if passed to this function. New pages
 // will always use the *http.Client provided to their WebDriver, or
 // http.DefaultClient if none was provided.
+//
+// If the InterceptNetwork Option was provided, a new proxy is started for the
+// page, so that its routes and traffic are isolated from other pages.
 func (w *WebDriver) NewPage(options ...Option) (*Page, error) {
 	newOptions := w.defaultOptions.Merge(options)
+	if newOptions.InterceptNetwork {
+		newOptions.NetworkProxy = proxy.New()
+		if err := newOptions.NetworkProxy.Start(); err != nil {
+			return nil, fmt.Errorf("failed to start proxy: %w", err)
+		}
+	}
+
 	session, err := w.Open(newOptions.Capabilities())
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to WebDriver: %s", err)
+		stopProxy(newOptions.NetworkProxy)
+		return nil, fmt.Errorf("failed to connect to WebDriver: %w", err)
 	}
 
-	return newPage(session), nil
+	if newOptions.NetworkProxy != nil {
+		w.trackProxy(newOptions.NetworkProxy)
+	}
+	return newPage(session, newOptions), nil
+}
+
+// trackProxy records a proxy started for a page, so that it is stopped with
+// the WebDriver if the page is not destroyed. Proxies of destroyed pages are
+// forgotten.
+func (w *WebDriver) trackProxy(networkProxy *proxy.Proxy) {
+	w.proxiesMutex.Lock()
+	defer w.proxiesMutex.Unlock()
+	var proxies []*proxy.Proxy
+	for _, existing := range w.proxies {
+		if existing.Address() != "" {
+			proxies = append(proxies, existing)
+		}
+	}
+	w.proxies = append(proxies, networkProxy)
+}
+
+// stopProxy stops the proxy if it is started.
+func stopProxy(networkProxy *proxy.Proxy) error {
+	if networkProxy == nil || networkProxy.Address() == "" {
+		return nil
+	}
+	return networkProxy.Stop()
+}
+
+// headerTransport adds headers to each request sent by a WebDriver.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	for name, values := range t.headers {
+		request.Header[name] = values
+	}
+	return t.base.RoundTrip(request)
+}
+
+func withHeaders(client *http.Client, headers http.Header) *http.Client {
+	if len(headers) == 0 {
+		return client
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	withHeaders := *client
+	withHeaders.Transport = &headerTransport{base, headers}
+	return &withHeaders
 }
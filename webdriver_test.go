@@ -0,0 +1,194 @@
+package agouti_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	. "github.com/sclevine/agouti"
+	"github.com/sclevine/agouti/agoutitest"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (r roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return r(request)
+}
+
+var _ = Describe("WebDriver", func() {
+	Describe("NewRemoteWebDriver", func() {
+		var (
+			server  *agoutitest.Server
+			mutex   sync.Mutex
+			headers []http.Header
+			client  *http.Client
+		)
+
+		BeforeEach(func() {
+			server = agoutitest.NewServer()
+			headers = nil
+			client = &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				mutex.Lock()
+				headers = append(headers, request.Header)
+				mutex.Unlock()
+				return http.DefaultTransport.RoundTrip(request)
+			})}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should manage sessions on the remote WebDriver", func() {
+			driver := NewRemoteWebDriver(server.URL(), HTTPClient(client), Browser("some-browser"))
+			Expect(driver.Start()).To(Succeed())
+
+			page, err := driver.NewPage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(server.Sessions()).To(HaveLen(1))
+			Expect(driver.Sessions()).To(HaveLen(1))
+			Expect(driver.Sessions()[0].Capabilities).To(HaveKeyWithValue("browserName", "some-browser"))
+
+			Expect(page.Destroy()).To(Succeed())
+			Expect(driver.Sessions()).To(BeEmpty())
+
+			_, err = driver.NewPage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(driver.Stop()).To(Succeed())
+			Expect(server.Sessions()).To(BeEmpty())
+		})
+
+		It("should send the provided credentials and headers with every request", func() {
+			driver := NewRemoteWebDriver(server.URL(), HTTPClient(client),
+				BasicAuth("some-user", "some-password"), Header("Some-Header", "some value"))
+			Expect(driver.Start()).To(Succeed())
+			page, err := driver.NewPage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page.Navigate("about:blank")).To(Succeed())
+			Expect(driver.Stop()).To(Succeed())
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			Expect(len(headers)).To(BeNumerically(">=", 4))
+			for _, header := range headers {
+				username, password, ok := (&http.Request{Header: header}).BasicAuth()
+				Expect(ok).To(BeTrue())
+				Expect(username).To(Equal("some-user"))
+				Expect(password).To(Equal("some-password"))
+				Expect(header.Get("Some-Header")).To(Equal("some value"))
+			}
+		})
+
+		Context("when the InterceptNetwork Option is provided", func() {
+			It("should configure each page to use its own proxy", func() {
+				driver := NewRemoteWebDriver(server.URL(), InterceptNetwork)
+				Expect(driver.Start()).To(Succeed())
+				first, err := driver.NewPage()
+				Expect(err).NotTo(HaveOccurred())
+				second, err := driver.NewPage()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(first.Proxy()).NotTo(BeNil())
+				Expect(second.Proxy()).NotTo(BeNil())
+				Expect(first.Proxy()).NotTo(BeIdenticalTo(second.Proxy()))
+				sessions := driver.Sessions()
+				Expect(sessions[0].Capabilities["proxy"]).To(HaveKeyWithValue("httpProxy", first.Proxy().Address()))
+				Expect(sessions[1].Capabilities["proxy"]).To(HaveKeyWithValue("httpProxy", second.Proxy().Address()))
+
+				firstProxy := first.Proxy()
+				Expect(first.Destroy()).To(Succeed())
+				Expect(firstProxy.Address()).To(BeEmpty())
+				Expect(second.Proxy().Address()).NotTo(BeEmpty())
+				Expect(driver.Stop()).To(Succeed())
+				Expect(second.Proxy().Address()).To(BeEmpty())
+			})
+		})
+
+		Context("when the remote WebDriver has not been started", func() {
+			It("should fail to open pages", func() {
+				driver := NewRemoteWebDriver(server.URL())
+				_, err := driver.NewPage()
+				Expect(err).To(MatchError("failed to connect to WebDriver: service not started"))
+			})
+		})
+	})
+
+	Describe("ChromeDriver", func() {
+		var (
+			binDir     string
+			driverPath string
+			chromePath string
+			stderr     *gbytes.Buffer
+			warnings   *gbytes.Buffer
+		)
+
+		writeBinary := func(name, version string) string {
+			path := filepath.Join(binDir, name)
+			script := "#!/bin/sh\n" +
+				"if [ \"$1\" = --version ]; then echo '" + version + "'; exit 0; fi\n" +
+				"exit 1\n"
+			Expect(ioutil.WriteFile(path, []byte(script), 0755)).To(Succeed())
+			return path
+		}
+
+		BeforeEach(func() {
+			var err error
+			binDir, err = ioutil.TempDir("", "bin")
+			Expect(err).NotTo(HaveOccurred())
+			driverPath = writeBinary("chromedriver", "ChromeDriver 120.0.6099.109 (some-commit)")
+			chromePath = writeBinary("chrome", "Google Chrome 119.0.6045.105")
+			stderr = gbytes.NewBuffer()
+			warnings = gbytes.NewBuffer()
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("AGOUTI_CHROMEDRIVER")
+			os.RemoveAll(binDir)
+		})
+
+		It("should warn before starting when the major versions of ChromeDriver and Chrome differ", func() {
+			driver := ChromeDriver(DriverBinary(driverPath), ChromeOptions("binary", chromePath),
+				DriverOutput(nil, stderr), Warnings(warnings), StartRetries(0))
+			Expect(driver.Start()).NotTo(Succeed())
+			Expect(warnings).To(gbytes.Say("WARNING: ChromeDriver 120.0.6099.109 may not support Chrome 119.0.6045.105, as their major versions differ\n"))
+			Expect(stderr).NotTo(gbytes.Say("WARNING"))
+		})
+
+		It("should not warn when the major versions of ChromeDriver and Chrome match", func() {
+			chromePath = writeBinary("chrome", "Google Chrome 120.0.6099.129")
+			driver := ChromeDriver(DriverBinary(driverPath), ChromeOptions("binary", chromePath),
+				Warnings(warnings), StartRetries(0))
+			Expect(driver.Start()).NotTo(Succeed())
+			Expect(warnings).NotTo(gbytes.Say("WARNING"))
+		})
+
+		It("should start the binary named by the AGOUTI_CHROMEDRIVER environment variable", func() {
+			os.Setenv("AGOUTI_CHROMEDRIVER", driverPath)
+			driver := ChromeDriver(ChromeOptions("binary", chromePath), DriverOutput(nil, stderr), Warnings(warnings), StartRetries(0))
+			Expect(driver.Start()).To(MatchError(HavePrefix("failed to start: driver process exited with status 1")))
+			Expect(warnings).To(gbytes.Say("WARNING: ChromeDriver 120"))
+		})
+
+		Context("when the binary is not in PATH", func() {
+			It("should return an error naming the binary before starting", func() {
+				os.Setenv("AGOUTI_CHROMEDRIVER", "not-a-chromedriver")
+				driver := ChromeDriver(DriverOutput(nil, stderr))
+				Expect(driver.Start()).To(MatchError("failed to start service: not-a-chromedriver not found in PATH"))
+			})
+		})
+
+		Context("when the provided binary does not exist", func() {
+			It("should return an error naming the binary before starting", func() {
+				missingPath := filepath.Join(binDir, "missing")
+				os.Setenv("AGOUTI_CHROMEDRIVER", driverPath)
+				driver := ChromeDriver(DriverBinary(missingPath))
+				Expect(driver.Start()).To(MatchError("failed to start service: " + missingPath + " not found"))
+			})
+		})
+	})
+})